@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// influxDecoder decodes Points from data in the Influx line protocol, as
+// written by serialize.InfluxSerializer
+type influxDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+	ts      time.Time
+}
+
+func newInfluxDecoder(r io.Reader) *influxDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &influxDecoder{scanner: scanner}
+}
+
+// Decode decodes the next line into p, returning false at the end of the
+// data. Blank lines and comments are skipped.
+func (d *influxDecoder) Decode(p *serialize.Point) (bool, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if err := d.parseLine(line, p); err != nil {
+			return false, fmt.Errorf("line %d: %v", d.line, err)
+		}
+		return true, nil
+	}
+	return false, d.scanner.Err()
+}
+
+// parseLine parses a line of the form
+//
+// <measurement>[,<tag key>=<tag value>...] <field key>=<field value>[,...] <timestamp>
+//
+// into p. Keys and values may escape commas, spaces and equal signs with
+// backslashes, and string field values are double quoted.
+func (d *influxDecoder) parseLine(line []byte, p *serialize.Point) error {
+	// quotes only delimit strings in the fields
+	sections := splitUnescaped(line, ' ', false)
+	if len(sections) > 1 {
+		sections = append(sections[:1], splitUnescaped(line[len(sections[0])+1:], ' ', true)...)
+	}
+	if len(sections) != 3 {
+		return fmt.Errorf("line does not have 3 sections, has %d", len(sections))
+	}
+
+	series := splitUnescaped(sections[0], ',', false)
+	if len(series[0]) == 0 {
+		return fmt.Errorf("missing measurement")
+	}
+	p.SetMeasurementName(unescape(series[0]))
+	for _, tag := range series[1:] {
+		kv := splitUnescaped(tag, '=', false)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return fmt.Errorf("invalid tag: %s", tag)
+		}
+		p.AppendTag(unescape(kv[0]), unescape(kv[1]))
+	}
+
+	for _, field := range splitUnescaped(sections[1], ',', true) {
+		kv := splitUnescaped(field, '=', true)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return fmt.Errorf("invalid field: %s", field)
+		}
+		v, err := parseFieldValue(kv[1])
+		if err != nil {
+			return fmt.Errorf("invalid value of field %s: %v", kv[0], err)
+		}
+		p.AppendField(unescape(kv[0]), v)
+	}
+
+	ns, err := strconv.ParseInt(string(sections[2]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %v", err)
+	}
+	d.ts = time.Unix(0, ns).UTC()
+	p.SetTimestamp(&d.ts)
+	return nil
+}
+
+// parseFieldValue returns the value of a field: a string if quoted, an int64
+// if suffixed with i, a bool, or else a float64
+func parseFieldValue(v []byte) (interface{}, error) {
+	if len(v) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	if v[0] == '"' {
+		if len(v) < 2 || v[len(v)-1] != '"' {
+			return nil, fmt.Errorf("unterminated string: %s", v)
+		}
+		return unescape(v[1 : len(v)-1]), nil
+	}
+	switch string(v) {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+	if v[len(v)-1] == 'i' {
+		return strconv.ParseInt(string(v[:len(v)-1]), 10, 64)
+	}
+	return strconv.ParseFloat(string(v), 64)
+}
+
+// splitUnescaped splits b around the occurrences of sep that are not escaped
+// with a backslash, nor within double quotes if quotes is set
+func splitUnescaped(b []byte, sep byte, quotes bool) [][]byte {
+	var parts [][]byte
+	start := 0
+	quoted := false
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '\\':
+			i++
+		case c == '"' && quotes:
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, b[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, b[start:])
+}
+
+// unescape returns b without the backslashes escaping its characters. It
+// returns b itself if there are none.
+func unescape(b []byte) []byte {
+	if bytes.IndexByte(b, '\\') < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) {
+			i++
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
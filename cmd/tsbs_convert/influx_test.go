@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestInfluxDecoderDecode(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		wantName    string
+		wantTags    map[string]string
+		wantFields  []string
+		wantValues  []interface{}
+		wantTS      int64
+		shouldError bool
+	}{
+		{
+			desc:       "float, int and bool fields",
+			input:      "cpu,hostname=host_0,region=eu-west-1 usage_user=58.1,usage_system=2i,up=t 1451606400000000000\n",
+			wantName:   "cpu",
+			wantTags:   map[string]string{"hostname": "host_0", "region": "eu-west-1"},
+			wantFields: []string{"usage_user", "usage_system", "up"},
+			wantValues: []interface{}{58.1, int64(2), true},
+			wantTS:     1451606400000000000,
+		},
+		{
+			desc:       "no tags, skipped comments and blank lines",
+			input:      "# comment\n\nmem free=1 1000\n",
+			wantName:   "mem",
+			wantTags:   map[string]string{},
+			wantFields: []string{"free"},
+			wantValues: []interface{}{1.0},
+			wantTS:     1000,
+		},
+		{
+			desc:       "escaped keys and values, quoted strings",
+			input:      `my\ cpu,host\,name=a\=b note="x y,z=\"w\"",n=F 5` + "\n",
+			wantName:   "my cpu",
+			wantTags:   map[string]string{"host,name": "a=b"},
+			wantFields: []string{"note", "n"},
+			wantValues: []interface{}{[]byte(`x y,z="w"`), false},
+			wantTS:     5,
+		},
+		{
+			desc:        "missing timestamp",
+			input:       "cpu,hostname=host_0 usage_user=1\n",
+			shouldError: true,
+		},
+		{
+			desc:        "invalid tag",
+			input:       "cpu,hostname usage_user=1 1\n",
+			shouldError: true,
+		},
+		{
+			desc:        "invalid field value",
+			input:       "cpu usage_user=abc 1\n",
+			shouldError: true,
+		},
+		{
+			desc:        "unterminated string",
+			input:       `cpu note="abc 1` + "\n",
+			shouldError: true,
+		},
+	}
+
+	for _, c := range cases {
+		d := newInfluxDecoder(strings.NewReader(c.input))
+		p := serialize.NewPoint()
+		ok, err := d.Decode(p)
+		if c.shouldError {
+			if err == nil {
+				t.Errorf("%s: did not return an error when it should", c.desc)
+			}
+			continue
+		}
+		if err != nil || !ok {
+			t.Fatalf("%s: unexpected result: %v, %v", c.desc, ok, err)
+		}
+		if got := string(p.MeasurementName()); got != c.wantName {
+			t.Errorf("%s: incorrect measurement: got %s want %s", c.desc, got, c.wantName)
+		}
+		tags := map[string]string{}
+		for i, k := range p.TagKeys() {
+			tags[string(k)] = string(p.TagValues()[i])
+		}
+		if !reflect.DeepEqual(tags, c.wantTags) {
+			t.Errorf("%s: incorrect tags: got %v want %v", c.desc, tags, c.wantTags)
+		}
+		fields := []string{}
+		for _, k := range p.FieldKeys() {
+			fields = append(fields, string(k))
+		}
+		if !reflect.DeepEqual(fields, c.wantFields) {
+			t.Errorf("%s: incorrect fields: got %v want %v", c.desc, fields, c.wantFields)
+		}
+		if !reflect.DeepEqual(p.FieldValues(), c.wantValues) {
+			t.Errorf("%s: incorrect values: got %v want %v", c.desc, p.FieldValues(), c.wantValues)
+		}
+		if got := p.Timestamp().UnixNano(); got != c.wantTS {
+			t.Errorf("%s: incorrect timestamp: got %d want %d", c.desc, got, c.wantTS)
+		}
+		if ok, err := d.Decode(serialize.NewPoint()); ok || err != nil {
+			t.Errorf("%s: incorrect end of data: got %v, %v", c.desc, ok, err)
+		}
+	}
+}
+
+func TestInfluxDecoderRoundTrip(t *testing.T) {
+	ts := time.Unix(0, 1451606400123456789)
+	p := serialize.NewPoint()
+	p.SetMeasurementName([]byte("cpu"))
+	p.AppendTag([]byte("hostname"), []byte("host_0"))
+	p.AppendTag([]byte("region"), []byte("eu-west-1"))
+	p.AppendField([]byte("usage_user"), 58.13)
+	p.AppendField([]byte("usage_guest"), int64(-3))
+	p.AppendField([]byte("usage_nice"), 0.0001)
+	p.SetTimestamp(&ts)
+
+	var want bytes.Buffer
+	s := &serialize.InfluxSerializer{}
+	if err := s.Serialize(p, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newInfluxDecoder(bytes.NewReader(want.Bytes()))
+	decoded := serialize.NewPoint()
+	if ok, err := d.Decode(decoded); !ok || err != nil {
+		t.Fatalf("unexpected result: %v, %v", ok, err)
+	}
+	var got bytes.Buffer
+	if err := s.Serialize(decoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("incorrect round trip:\ngot\n%s\nwant\n%s", got.String(), want.String())
+	}
+}
@@ -0,0 +1,281 @@
+// tsbs_convert converts data generated by tsbs_generate_data from one format
+// to another.
+//
+// It decodes the data from -file or stdin back into Points and writes them
+// to stdout with the serializer of another format, so that every database
+// can load the same logical data from a single generated file instead of
+// generating it again.
+//
+// Supported input formats:
+// InfluxDB bulk load format
+//
+// The formats loaded into tables, whose header lists the fields of every
+// measurement, need the manifest of the input (see -manifest of
+// tsbs_generate_data), from which missing values are restored as well.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/manifest"
+)
+
+const (
+	// Input data format choices
+	inputFormatInflux = "influx"
+
+	// Output data format choices (alphabetical order)
+	formatCassandra   = "cassandra"
+	formatClickHouse  = "clickhouse"
+	formatElastic     = "elasticsearch"
+	formatGraphite    = "graphite"
+	formatInflux      = "influx"
+	formatMongo       = "mongo"
+	formatOpenTSDB    = "opentsdb"
+	formatPrometheus  = "prometheus"
+	formatTimescaleDB = "timescaledb"
+)
+
+var formatChoices = []string{formatCassandra, formatClickHouse, formatElastic, formatGraphite, formatInflux, formatMongo, formatOpenTSDB, formatPrometheus, formatTimescaleDB}
+
+// Program option vars:
+var (
+	inputFormat  string
+	format       string
+	fileName     string
+	manifestFile string
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Register flags, parsed by main:
+func init() {
+	flag.StringVar(&inputFormat, "input-format", inputFormatInflux, fmt.Sprintf("Format of the data to convert. (choices: %s)", inputFormatInflux))
+	flag.StringVar(&format, "format", "", fmt.Sprintf("Format to emit. (choices: %s)", strings.Join(formatChoices, ", ")))
+	flag.StringVar(&fileName, "file", "", "File name to read data from, or a glob pattern to read several files in natural order (data_2 before data_10). Gzip and zstd compressed files are decompressed transparently. Empty means reading from stdin.")
+	flag.StringVar(&manifestFile, "manifest", "", "Manifest of the input, written by tsbs_generate_data. Required by the clickhouse, elasticsearch and timescaledb formats.")
+}
+
+// pointDecoder decodes the Points of data in an input format
+type pointDecoder interface {
+	// Decode decodes the next Point into p, returning false at the end of
+	// the data
+	Decode(p *serialize.Point) (bool, error)
+}
+
+// schema is the fields of every measurement, in the order of the dataset
+type schema map[string][][]byte
+
+func newSchema(m *manifest.Manifest) schema {
+	s := schema{}
+	for name, mm := range m.Measurements {
+		fields := make([][]byte, len(mm.Fields))
+		for i, f := range mm.Fields {
+			fields[i] = []byte(f)
+		}
+		s[name] = fields
+	}
+	return s
+}
+
+// restoreFields sets the fields of to those of the measurement of from in
+// the order of the schema, with the values of from or nil if missing
+func (s schema) restoreFields(from, to *serialize.Point) error {
+	name := from.MeasurementName()
+	fields, ok := s[string(name)]
+	if !ok {
+		return fmt.Errorf("measurement %s is not in the manifest", name)
+	}
+	to.SetMeasurementName(name)
+	to.SetTimestamp(from.Timestamp())
+	keys, values := from.TagKeys(), from.TagValues()
+	for i := range keys {
+		to.AppendTag(keys[i], values[i])
+	}
+
+	fieldValues := make(map[string]interface{}, len(fields))
+	for i, k := range from.FieldKeys() {
+		fieldValues[string(k)] = from.FieldValues()[i]
+	}
+	for _, f := range fields {
+		v, ok := fieldValues[string(f)]
+		if ok {
+			delete(fieldValues, string(f))
+		}
+		to.AppendField(f, v)
+	}
+	for f := range fieldValues {
+		return fmt.Errorf("field %s of measurement %s is not in the manifest", f, name)
+	}
+	return nil
+}
+
+func main() {
+	// parsed here rather than in init so that the tests can run
+	flag.Parse()
+
+	if inputFormat != inputFormatInflux {
+		log.Fatalf("invalid input format: %s", inputFormat)
+	}
+	if !validateFormat(format) {
+		log.Fatal("invalid format specifier")
+	}
+
+	var r io.Reader = os.Stdin
+	if len(fileName) > 0 {
+		var err error
+		r, err = load.OpenFiles(fileName)
+		if err != nil {
+			log.Fatalf("cannot open file for read %s: %v", fileName, err)
+		}
+	}
+	in := bufio.NewReaderSize(r, 4<<20)
+	out := bufio.NewWriterSize(os.Stdout, 4<<20)
+	defer out.Flush()
+
+	var s schema
+	if len(manifestFile) > 0 {
+		m, err := manifest.Load(manifestFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s = newSchema(m)
+	} else if needsHeader(format) {
+		log.Fatalf("format %s needs the -manifest of the input", format)
+	}
+
+	n, err := convert(newInfluxDecoder(in), s, format, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("converted %d points from %s to %s", n, inputFormat, format)
+}
+
+// convert decodes the Points of d and writes them to out in format,
+// returning the number of Points converted. If s is not nil, the fields of
+// the Points are restored from it.
+func convert(d pointDecoder, s schema, format string, out io.Writer) (uint64, error) {
+	decoded := serialize.NewPoint()
+	restored := serialize.NewPoint()
+
+	// the header of the formats loaded into tables needs the tag keys,
+	// which are those of the first point
+	ok, err := d.Decode(decoded)
+	if err != nil {
+		return 0, err
+	}
+	serializer := getSerializer(format, s, decoded.TagKeys(), out)
+
+	n := uint64(0)
+	for ; ok; ok, err = d.Decode(decoded) {
+		p := decoded
+		if s != nil {
+			if err := s.restoreFields(decoded, restored); err != nil {
+				return n, err
+			}
+			p = restored
+		}
+		if err := serializer.Serialize(p, out); err != nil {
+			return n, err
+		}
+		n++
+		decoded.Reset()
+		restored.Reset()
+	}
+	if err != nil {
+		return n, err
+	}
+
+	if flusher, ok := serializer.(serialize.Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			return n, fmt.Errorf("unable to flush serializer %q: %s", format, err)
+		}
+	}
+	return n, nil
+}
+
+func validateFormat(format string) bool {
+	for _, s := range formatChoices {
+		if s == format {
+			return true
+		}
+	}
+	return false
+}
+
+// needsHeader tells whether the data of format starts with a table header
+func needsHeader(format string) bool {
+	return format == formatClickHouse || format == formatElastic || format == formatTimescaleDB
+}
+
+func getSerializer(format string, s schema, tagKeys [][]byte, out io.Writer) serialize.PointSerializer {
+	if needsHeader(format) {
+		writeTableHeader(s, tagKeys, out)
+	}
+	switch format {
+	case formatCassandra:
+		return &serialize.CassandraSerializer{}
+	case formatClickHouse:
+		return &serialize.ClickHouseSerializer{}
+	case formatElastic:
+		return &serialize.ElasticsearchSerializer{}
+	case formatGraphite:
+		return &serialize.GraphiteSerializer{}
+	case formatInflux:
+		return &serialize.InfluxSerializer{}
+	case formatMongo:
+		return &serialize.MongoSerializer{}
+	case formatOpenTSDB:
+		return &serialize.OpenTSDBSerializer{}
+	case formatPrometheus:
+		return &serialize.PrometheusSerializer{}
+	case formatTimescaleDB:
+		return &serialize.TimescaleDBSerializer{}
+	default:
+		fatal("unknown format: '%s'", format)
+		return nil
+	}
+}
+
+// writeTableHeader writes the header of the formats loaded into tables, the
+// same as tsbs_generate_data: a first line with the tag keys, then a line per
+// measurement with its fields, and a blank line
+func writeTableHeader(s schema, tagKeys [][]byte, out io.Writer) {
+	buf := []byte("tags")
+	for _, key := range tagKeys {
+		buf = append(buf, ',')
+		buf = append(buf, key...)
+	}
+	buf = append(buf, '\n')
+	// sort the keys so the header is deterministic
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, measurementName := range keys {
+		buf = append(buf, measurementName...)
+		for _, field := range s[measurementName] {
+			buf = append(buf, ',')
+			buf = append(buf, field...)
+		}
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	if _, err := out.Write(buf); err != nil {
+		fatal("could not write header: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hagen1778/tsbs/manifest"
+)
+
+func TestConvert(t *testing.T) {
+	s := newSchema(&manifest.Manifest{
+		Measurements: map[string]*manifest.Measurement{
+			"mem": {Fields: []string{"free"}},
+			"cpu": {Fields: []string{"usage_user", "usage_system", "usage_idle"}},
+		},
+	})
+	input := "cpu,hostname=host_0 usage_user=1,usage_idle=3 1000000\n" +
+		"mem,hostname=host_0 free=5i 1000000\n"
+
+	cases := []struct {
+		desc        string
+		schema      schema
+		format      string
+		input       string
+		want        string
+		shouldError bool
+	}{
+		{
+			desc:   "influx without manifest",
+			format: formatInflux,
+			input:  input,
+			want: "cpu,hostname=host_0 usage_user=1,usage_idle=3 1000000\n" +
+				"mem,hostname=host_0 free=5i 1000000\n",
+		},
+		{
+			desc:   "timescaledb restores missing fields",
+			schema: s,
+			format: formatTimescaleDB,
+			input:  input,
+			want: "tags,hostname\ncpu,usage_user,usage_system,usage_idle\nmem,free\n\n" +
+				"tags,hostname=host_0\ncpu,1000000,1,,3\n" +
+				"tags,hostname=host_0\nmem,1000000,5\n",
+		},
+		{
+			desc:        "measurement not in manifest",
+			schema:      s,
+			format:      formatInflux,
+			input:       "disk,hostname=host_0 used=1 1000\n",
+			shouldError: true,
+		},
+		{
+			desc:        "field not in manifest",
+			schema:      s,
+			format:      formatInflux,
+			input:       "mem,hostname=host_0 used=1 1000\n",
+			shouldError: true,
+		},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		_, err := convert(newInfluxDecoder(strings.NewReader(c.input)), c.schema, c.format, &out)
+		if c.shouldError {
+			if err == nil {
+				t.Errorf("%s: did not return an error when it should", c.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		if got := out.String(); got != c.want {
+			t.Errorf("%s: incorrect output:\ngot\n%s\nwant\n%s", c.desc, got, c.want)
+		}
+	}
+}
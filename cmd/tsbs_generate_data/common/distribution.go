@@ -3,6 +3,7 @@ package common
 import (
 	"math"
 	"math/rand"
+	"time"
 )
 
 // Distribution provides an interface to model a statistical distribution.
@@ -11,12 +12,48 @@ type Distribution interface {
 	Get() float64 // should be idempotent
 }
 
+// TimedDistribution is a Distribution whose values depend on the time they
+// are read at, e.g. on the time of the day.
+type TimedDistribution interface {
+	Distribution
+	SetTime(t time.Time)
+}
+
+// AdvanceAt advances d to its value at t, which only matters if d is a
+// TimedDistribution. Simulators advance distributions with it.
+func AdvanceAt(d Distribution, t time.Time) {
+	if td, ok := d.(TimedDistribution); ok {
+		td.SetTime(t)
+	}
+	d.Advance()
+}
+
+// randFloat64 returns a pseudo-random number in [0.0,1.0) drawn from r, or
+// from the global source of math/rand if r is nil.
+func randFloat64(r *rand.Rand) float64 {
+	if r != nil {
+		return r.Float64()
+	}
+	return rand.Float64()
+}
+
+// randNormFloat64 returns a normally distributed number with mean 0 and
+// standard deviation 1 drawn from r, or from the global source of math/rand
+// if r is nil.
+func randNormFloat64(r *rand.Rand) float64 {
+	if r != nil {
+		return r.NormFloat64()
+	}
+	return rand.NormFloat64()
+}
+
 // NormalDistribution models a normal distribution (stateless).
 type NormalDistribution struct {
 	Mean   float64
 	StdDev float64
 
 	value float64
+	rand  *rand.Rand // nil means the global source of math/rand
 }
 
 // ND creates a new normal distribution with the given mean/stddev
@@ -27,7 +64,7 @@ func ND(mean, stddev float64) *NormalDistribution {
 // Advance advances this distribution. Since the distribution is
 // stateless, this just overwrites the internal cache value.
 func (d *NormalDistribution) Advance() {
-	d.value = rand.NormFloat64()*d.StdDev + d.Mean
+	d.value = randNormFloat64(d.rand)*d.StdDev + d.Mean
 }
 
 // Get returns the last computed value for this distribution.
@@ -41,6 +78,7 @@ type UniformDistribution struct {
 	High float64
 
 	value float64
+	rand  *rand.Rand // nil means the global source of math/rand
 }
 
 // UD creates a new uniform distribution with the given range
@@ -51,7 +89,7 @@ func UD(low, high float64) *UniformDistribution {
 // Advance advances this distribution. Since the distribution is
 // stateless, this just overwrites the internal cache value.
 func (d *UniformDistribution) Advance() {
-	x := rand.Float64() // uniform
+	x := randFloat64(d.rand) // uniform
 	x *= d.High - d.Low
 	x += d.Low
 	d.value = x
@@ -163,3 +201,311 @@ func (d *ConstantDistribution) Advance() {
 func (d *ConstantDistribution) Get() float64 {
 	return d.State
 }
+
+// ParetoDistribution models a Pareto distribution (stateless), which is
+// heavy-tailed: most values are close to Scale, but a few are much larger,
+// e.g. the sizes of bursts of network traffic. The lower the Shape, the
+// heavier the tail; below 2 the variance is infinite.
+type ParetoDistribution struct {
+	Scale float64
+	Shape float64
+
+	value float64
+	rand  *rand.Rand // nil means the global source of math/rand
+}
+
+// PD creates a new Pareto distribution with the given scale (i.e. minimum)
+// and shape. Its mean is Scale*Shape/(Shape-1) for a shape above 1.
+func PD(scale, shape float64) *ParetoDistribution {
+	return &ParetoDistribution{Scale: scale, Shape: shape}
+}
+
+// Advance advances this distribution. Since the distribution is
+// stateless, this just overwrites the internal cache value.
+func (d *ParetoDistribution) Advance() {
+	u := 1 - randFloat64(d.rand) // in (0, 1]
+	d.value = d.Scale / math.Pow(u, 1/d.Shape)
+}
+
+// Get returns the last computed value for this distribution.
+func (d *ParetoDistribution) Get() float64 {
+	return d.value
+}
+
+// ZipfDistribution models a Zipf distribution (stateless) of integers in
+// [0, Max], where the probability of k is proportional to (V+k)^-S: a few
+// values are very common while most are rare.
+type ZipfDistribution struct {
+	S   float64 // S must be > 1
+	V   float64 // V must be >= 1
+	Max uint64
+
+	value float64
+	zipf  *rand.Zipf
+	rand  *rand.Rand // nil means a source seeded from math/rand's global one
+}
+
+// ZD creates a new Zipf distribution with the given parameters
+func ZD(s, v float64, max uint64) *ZipfDistribution {
+	return &ZipfDistribution{S: s, V: v, Max: max}
+}
+
+// Advance advances this distribution. Since the distribution is
+// stateless, this just overwrites the internal cache value.
+func (d *ZipfDistribution) Advance() {
+	if d.zipf == nil {
+		r := d.rand
+		if r == nil {
+			r = rand.New(rand.NewSource(rand.Int63()))
+		}
+		d.zipf = rand.NewZipf(r, d.S, d.V, d.Max)
+	}
+	d.value = float64(d.zipf.Uint64())
+}
+
+// Get returns the last computed value for this distribution.
+func (d *ZipfDistribution) Get() float64 {
+	return d.value
+}
+
+// SeasonalDistribution adds daily and weekly cycles and a linear trend to an
+// underlying distribution, e.g. to model the load of a service following the
+// activity of its users. It is a TimedDistribution: the cycles depend on the
+// time it is advanced at, and the trend on the time elapsed since it was
+// first advanced.
+type SeasonalDistribution struct {
+	Base Distribution
+	// Daily is the amplitude of the daily cycle, which peaks at Peak every day
+	Daily float64
+	// Weekly is the amplitude of the weekly cycle, which peaks at Peak on
+	// Wednesdays and is the lowest on Sundays
+	Weekly float64
+	Peak   time.Duration
+	// Trend is added every hour
+	Trend float64
+
+	start, now time.Time
+}
+
+// Seasonal creates a new SeasonalDistribution of base, without trend
+func Seasonal(base Distribution, daily, weekly float64, peak time.Duration) *SeasonalDistribution {
+	return &SeasonalDistribution{Base: base, Daily: daily, Weekly: weekly, Peak: peak}
+}
+
+// SetTime sets the time the next value is computed at.
+func (d *SeasonalDistribution) SetTime(t time.Time) {
+	if d.start.IsZero() {
+		d.start = t
+	}
+	d.now = t
+}
+
+// Advance computes the next value of this distribution and stores it.
+func (d *SeasonalDistribution) Advance() {
+	AdvanceAt(d.Base, d.now)
+}
+
+// Get returns the last computed value for this distribution.
+func (d *SeasonalDistribution) Get() float64 {
+	const day, week = 24 * time.Hour, 7 * 24 * time.Hour
+	sinceMidnight := time.Duration(d.now.Hour())*time.Hour + time.Duration(d.now.Minute())*time.Minute + time.Duration(d.now.Second())*time.Second
+	// weeks start on Mondays, so Wednesdays are their third day
+	sinceMonday := time.Duration((int(d.now.Weekday())+6)%7)*day + sinceMidnight
+
+	v := d.Base.Get()
+	v += d.Daily * math.Cos(2*math.Pi*float64(sinceMidnight-d.Peak)/float64(day))
+	v += d.Weekly * math.Cos(2*math.Pi*float64(sinceMonday-2*day-d.Peak)/float64(week))
+	v += d.Trend * d.now.Sub(d.start).Hours()
+	return v
+}
+
+// AnomalyDistribution injects anomalies into an underlying distribution:
+// spikes, which add SpikeSize to its values for SpikeLength advances, and
+// level shifts, which add or subtract ShiftSize to all its later values.
+type AnomalyDistribution struct {
+	Base Distribution
+	// SpikeProbability is the chance of a spike starting at each advance
+	SpikeProbability float64
+	SpikeSize        float64
+	SpikeLength      int
+	// ShiftProbability is the chance of a level shift at each advance
+	ShiftProbability float64
+	ShiftSize        float64
+
+	spikeLeft int
+	shift     float64
+	rand      *rand.Rand // nil means the global source of math/rand
+}
+
+// Anomalies creates a new AnomalyDistribution of base with the given
+// probabilities and sizes of spikes and level shifts
+func Anomalies(base Distribution, spikeProbability, spikeSize float64, spikeLength int, shiftProbability, shiftSize float64) *AnomalyDistribution {
+	return &AnomalyDistribution{
+		Base:             base,
+		SpikeProbability: spikeProbability,
+		SpikeSize:        spikeSize,
+		SpikeLength:      spikeLength,
+		ShiftProbability: shiftProbability,
+		ShiftSize:        shiftSize,
+	}
+}
+
+// SetTime sets the time the next value is computed at.
+func (d *AnomalyDistribution) SetTime(t time.Time) {
+	if td, ok := d.Base.(TimedDistribution); ok {
+		td.SetTime(t)
+	}
+}
+
+// Advance computes the next value of this distribution and stores it.
+func (d *AnomalyDistribution) Advance() {
+	d.Base.Advance()
+	if d.spikeLeft > 0 {
+		d.spikeLeft--
+	} else if d.SpikeProbability > 0 && randFloat64(d.rand) < d.SpikeProbability {
+		d.spikeLeft = d.SpikeLength
+	}
+	if d.ShiftProbability > 0 && randFloat64(d.rand) < d.ShiftProbability {
+		if randFloat64(d.rand) < 0.5 {
+			d.shift -= d.ShiftSize
+		} else {
+			d.shift += d.ShiftSize
+		}
+	}
+}
+
+// Get returns the last computed value for this distribution.
+func (d *AnomalyDistribution) Get() float64 {
+	v := d.Base.Get() + d.shift
+	if d.spikeLeft > 0 {
+		v += d.SpikeSize
+	}
+	return v
+}
+
+// ResetDistribution models a counter that is reset to zero from time to
+// time, e.g. when the process keeping it restarts. Its underlying
+// distribution should only increase, e.g. a MonotonicRandomWalkDistribution.
+type ResetDistribution struct {
+	Base Distribution
+	// Probability is the chance of a reset at each advance
+	Probability float64
+
+	offset float64
+	rand   *rand.Rand // nil means the global source of math/rand
+}
+
+// Resets creates a new ResetDistribution of base
+func Resets(base Distribution, probability float64) *ResetDistribution {
+	return &ResetDistribution{Base: base, Probability: probability}
+}
+
+// SetTime sets the time the next value is computed at.
+func (d *ResetDistribution) SetTime(t time.Time) {
+	if td, ok := d.Base.(TimedDistribution); ok {
+		td.SetTime(t)
+	}
+}
+
+// Advance computes the next value of this distribution and stores it.
+func (d *ResetDistribution) Advance() {
+	d.Base.Advance()
+	if d.Probability > 0 && randFloat64(d.rand) < d.Probability {
+		d.offset = d.Base.Get()
+	}
+}
+
+// Get returns the last computed value for this distribution.
+func (d *ResetDistribution) Get() float64 {
+	return d.Base.Get() - d.offset
+}
+
+// ClampedDistribution bounds the values of an underlying distribution to
+// [Min, Max].
+type ClampedDistribution struct {
+	Base Distribution
+	Min  float64
+	Max  float64
+}
+
+// Clamp creates a new ClampedDistribution of base
+func Clamp(base Distribution, min, max float64) *ClampedDistribution {
+	return &ClampedDistribution{Base: base, Min: min, Max: max}
+}
+
+// SetTime sets the time the next value is computed at.
+func (d *ClampedDistribution) SetTime(t time.Time) {
+	if td, ok := d.Base.(TimedDistribution); ok {
+		td.SetTime(t)
+	}
+}
+
+// Advance computes the next value of this distribution and stores it.
+func (d *ClampedDistribution) Advance() {
+	d.Base.Advance()
+}
+
+// Get returns the last computed value for this distribution.
+func (d *ClampedDistribution) Get() float64 {
+	return math.Max(d.Min, math.Min(d.Max, d.Base.Get()))
+}
+
+// WithRand returns a copy of d, and of the distributions it is built on,
+// drawing from r instead of the global source of math/rand, so that it can be
+// advanced concurrently with distributions drawing from other sources.
+// Distributions of other types are returned as they are.
+func WithRand(d Distribution, r *rand.Rand) Distribution {
+	switch d := d.(type) {
+	case *NormalDistribution:
+		c := *d
+		c.rand = r
+		return &c
+	case *UniformDistribution:
+		c := *d
+		c.rand = r
+		return &c
+	case *RandomWalkDistribution:
+		c := *d
+		c.Step = WithRand(d.Step, r)
+		return &c
+	case *ClampedRandomWalkDistribution:
+		c := *d
+		c.Step = WithRand(d.Step, r)
+		return &c
+	case *MonotonicRandomWalkDistribution:
+		c := *d
+		c.Step = WithRand(d.Step, r)
+		return &c
+	case *ConstantDistribution:
+		c := *d
+		return &c
+	case *ParetoDistribution:
+		c := *d
+		c.rand = r
+		return &c
+	case *ZipfDistribution:
+		c := *d
+		c.rand = r
+		c.zipf = nil
+		return &c
+	case *SeasonalDistribution:
+		c := *d
+		c.Base = WithRand(d.Base, r)
+		return &c
+	case *AnomalyDistribution:
+		c := *d
+		c.Base = WithRand(d.Base, r)
+		c.rand = r
+		return &c
+	case *ResetDistribution:
+		c := *d
+		c.Base = WithRand(d.Base, r)
+		c.rand = r
+		return &c
+	case *ClampedDistribution:
+		c := *d
+		c.Base = WithRand(d.Base, r)
+		return &c
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestWithRand(t *testing.T) {
+	step := ND(0, 1)
+	d := CWD(step, -10, 10, 0)
+	a := WithRand(d, rand.New(rand.NewSource(1)))
+	b := WithRand(d, rand.New(rand.NewSource(1)))
+	if a.(*ClampedRandomWalkDistribution).Step == Distribution(step) {
+		t.Fatalf("step distribution not copied")
+	}
+	for i := 0; i < 100; i++ {
+		a.Advance()
+		b.Advance()
+		if a.Get() != b.Get() {
+			t.Fatalf("distributions with the same seed differ at step %d: %v != %v", i, a.Get(), b.Get())
+		}
+	}
+	if d.Get() != 0 {
+		t.Errorf("original distribution advanced: got %v want %v", d.Get(), 0)
+	}
+}
+
+func TestParetoDistribution(t *testing.T) {
+	d := PD(10, 1.5)
+	d.rand = rand.New(rand.NewSource(1))
+	sum, max := 0.0, 0.0
+	const n = 100000
+	for i := 0; i < n; i++ {
+		d.Advance()
+		if d.Get() < 10 {
+			t.Fatalf("value below the scale: %v", d.Get())
+		}
+		sum += d.Get()
+		max = math.Max(max, d.Get())
+	}
+	// the mean is 30, but the tail is heavy
+	if mean := sum / n; mean < 25 || mean > 40 {
+		t.Errorf("incorrect mean: got %v want about %v", mean, 30)
+	}
+	if max < 1000 {
+		t.Errorf("tail not heavy: max is %v", max)
+	}
+}
+
+func TestZipfDistribution(t *testing.T) {
+	d := WithRand(ZD(1.5, 1, 100), rand.New(rand.NewSource(1)))
+	counts := map[float64]int{}
+	for i := 0; i < 10000; i++ {
+		d.Advance()
+		if d.Get() < 0 || d.Get() > 100 {
+			t.Fatalf("value out of bounds: %v", d.Get())
+		}
+		counts[d.Get()]++
+	}
+	if counts[0] < counts[1] || counts[1] < counts[10] {
+		t.Errorf("values not skewed: %d, %d, %d", counts[0], counts[1], counts[10])
+	}
+}
+
+func TestSeasonalDistribution(t *testing.T) {
+	// a Wednesday
+	start := time.Date(2016, 1, 6, 0, 0, 0, 0, time.UTC)
+	d := Seasonal(&ConstantDistribution{State: 50}, 20, 10, 14*time.Hour)
+	d.Trend = 1
+	cases := []struct {
+		at   time.Duration
+		want float64
+	}{
+		{0, 50 + 20*math.Cos(-2*math.Pi*14/24) + 10*math.Cos(-2*math.Pi*14/168)},
+		{14 * time.Hour, 50 + 20 + 10 + 14},
+		{2 * time.Hour, 50 + 20*math.Cos(-2*math.Pi*12/24) + 10*math.Cos(-2*math.Pi*12/168) + 2},
+		// on Sunday, the weekly cycle is at its lowest
+		{(4*24 + 14) * time.Hour, 50 + 20 - 10*math.Cos(2*math.Pi*0.5/7) + 4*24 + 14},
+	}
+	for _, c := range cases {
+		AdvanceAt(d, start.Add(c.at))
+		if got := d.Get(); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("incorrect value at %v: got %v want %v", c.at, got, c.want)
+		}
+	}
+}
+
+func TestAnomalyDistribution(t *testing.T) {
+	d := Anomalies(&ConstantDistribution{State: 10}, 0.01, 100, 5, 0.01, 1)
+	d.rand = rand.New(rand.NewSource(1))
+	spikes, shifted := 0, false
+	for i := 0; i < 10000; i++ {
+		d.Advance()
+		v := d.Get() - d.shift
+		if v != 10 && v != 110 {
+			t.Fatalf("incorrect value: got %v want %v or %v", v, 10, 110)
+		}
+		if v == 110 {
+			spikes++
+		}
+		if d.shift != 0 {
+			shifted = true
+		}
+	}
+	if spikes == 0 {
+		t.Errorf("no spike")
+	}
+	if !shifted {
+		t.Errorf("no level shift")
+	}
+}
+
+func TestResetDistribution(t *testing.T) {
+	d := Resets(MWD(&ConstantDistribution{State: 1}, 0), 0.1)
+	d.rand = rand.New(rand.NewSource(1))
+	resets := 0
+	last := 0.0
+	for i := 0; i < 1000; i++ {
+		d.Advance()
+		if d.Get() == 0 {
+			resets++
+		} else if d.Get() != last+1 {
+			t.Fatalf("counter not increased: %v after %v", d.Get(), last)
+		}
+		last = d.Get()
+	}
+	if resets == 0 {
+		t.Errorf("counter never reset")
+	}
+}
+
+func TestClampedDistribution(t *testing.T) {
+	d := Clamp(WD(&ConstantDistribution{State: 1}, 0), -2, 2)
+	for i := 0; i < 5; i++ {
+		d.Advance()
+	}
+	if got := d.Get(); got != 2 {
+		t.Errorf("incorrect value: got %v want %v", got, 2)
+	}
+}
@@ -0,0 +1,234 @@
+package common
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+const (
+	// LateDistributionUniform delays late points uniformly up to LateConfig.Delay
+	LateDistributionUniform = "uniform"
+	// LateDistributionPareto delays late points by at least LateConfig.Delay,
+	// following a heavy-tailed Pareto distribution
+	LateDistributionPareto = "pareto"
+
+	// paretoShape is the shape of the Pareto distribution of delays. Below 2
+	// its variance is infinite, so a few points are delayed by a lot.
+	paretoShape = 1.5
+)
+
+// LateConfig describes how a LateSimulator delivers points out of order.
+type LateConfig struct {
+	// Fraction is the fraction of points that are delivered late
+	Fraction float64
+	// Delay bounds the delay of late points for the uniform distribution
+	// and is the minimum delay for the Pareto distribution
+	Delay time.Duration
+	// Distribution of the delays, either LateDistributionUniform or
+	// LateDistributionPareto
+	Distribution string
+
+	// BackfillFraction is the fraction of windows of BackfillWindow whose
+	// points are all held back, to be replayed after the rest of the dataset
+	BackfillFraction float64
+	// BackfillWindow is the size of the windows considered for backfill
+	BackfillWindow time.Duration
+
+	// Seed of the decisions of which points are late, so that the order of
+	// points is deterministic
+	Seed int64
+}
+
+// Enabled returns whether c delivers any point out of order.
+func (c *LateConfig) Enabled() bool {
+	return c.Fraction > 0 || c.BackfillFraction > 0
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c *LateConfig) Validate() error {
+	if c.Fraction < 0 || c.Fraction > 1 {
+		return fmt.Errorf("late fraction must be between 0 and 1; got %v", c.Fraction)
+	}
+	if c.BackfillFraction < 0 || c.BackfillFraction > 1 {
+		return fmt.Errorf("backfill fraction must be between 0 and 1; got %v", c.BackfillFraction)
+	}
+	if c.Fraction > 0 && c.Delay <= 0 {
+		return fmt.Errorf("late delay must be positive; got %v", c.Delay)
+	}
+	if c.BackfillFraction > 0 && c.BackfillWindow <= 0 {
+		return fmt.Errorf("backfill window must be positive; got %v", c.BackfillWindow)
+	}
+	switch c.Distribution {
+	case LateDistributionUniform, LateDistributionPareto:
+	default:
+		return fmt.Errorf("unknown late distribution: '%s'", c.Distribution)
+	}
+	return nil
+}
+
+// LateSimulator wraps a Simulator to deliver some of its points late. Points
+// are held back until a point whose timestamp is past their delivery time has
+// been made, and backfilled windows are replayed once the wrapped Simulator is
+// finished. The set of points is the same as the wrapped Simulator's.
+type LateSimulator struct {
+	Simulator
+	c    *LateConfig
+	rand *rand.Rand
+
+	// now is the latest timestamp made by the wrapped Simulator
+	now      time.Time
+	late     latePoints
+	seq      uint64
+	windows  map[time.Time]bool
+	backfill []*serialize.Point
+	free     []*serialize.Point
+}
+
+// NewLateSimulator returns a LateSimulator delivering the points of sim
+// according to c.
+func NewLateSimulator(sim Simulator, c *LateConfig) *LateSimulator {
+	return &LateSimulator{
+		Simulator: sim,
+		c:         c,
+		rand:      rand.New(rand.NewSource(c.Seed)),
+		windows:   make(map[time.Time]bool),
+	}
+}
+
+// Finished tells whether all the points, including the late ones, have been
+// delivered.
+func (s *LateSimulator) Finished() bool {
+	return s.Simulator.Finished() && len(s.late) == 0 && len(s.backfill) == 0
+}
+
+// Next advances p to the next point to deliver, returning whether it should
+// be written.
+func (s *LateSimulator) Next(p *serialize.Point) bool {
+	if s.due() {
+		return s.pop(p)
+	}
+	if s.Simulator.Finished() {
+		return s.drain(p)
+	}
+	if !s.Simulator.Next(p) {
+		return false
+	}
+
+	ts := *p.Timestamp()
+	if ts.After(s.now) {
+		s.now = ts
+	}
+	if s.inBackfillWindow(ts) {
+		s.backfill = append(s.backfill, s.clone(p))
+		return false
+	}
+
+	deliverAt := ts
+	if s.c.Fraction > 0 && s.rand.Float64() < s.c.Fraction {
+		deliverAt = ts.Add(s.delay())
+	} else if !s.due() {
+		// on time and nothing held back is due before it
+		return true
+	}
+	s.push(deliverAt, p)
+	if s.due() {
+		return s.pop(p)
+	}
+	return false
+}
+
+func (s *LateSimulator) delay() time.Duration {
+	// 1 - Float64 is in (0, 1], so neither distribution yields 0
+	u := 1 - s.rand.Float64()
+	if s.c.Distribution == LateDistributionPareto {
+		return time.Duration(float64(s.c.Delay) / math.Pow(u, 1/paretoShape))
+	}
+	return time.Duration(float64(s.c.Delay) * u)
+}
+
+func (s *LateSimulator) inBackfillWindow(ts time.Time) bool {
+	if s.c.BackfillFraction <= 0 {
+		return false
+	}
+	w := ts.Truncate(s.c.BackfillWindow)
+	held, ok := s.windows[w]
+	if !ok {
+		held = s.rand.Float64() < s.c.BackfillFraction
+		s.windows[w] = held
+	}
+	return held
+}
+
+// due returns whether the earliest held back point should be delivered
+func (s *LateSimulator) due() bool {
+	return len(s.late) > 0 && !s.late[0].deliverAt.After(s.now)
+}
+
+func (s *LateSimulator) push(deliverAt time.Time, p *serialize.Point) {
+	heap.Push(&s.late, &latePoint{deliverAt: deliverAt, seq: s.seq, p: s.clone(p)})
+	s.seq++
+}
+
+func (s *LateSimulator) pop(p *serialize.Point) bool {
+	lp := heap.Pop(&s.late).(*latePoint)
+	p.Copy(lp.p)
+	s.free = append(s.free, lp.p)
+	return true
+}
+
+// drain delivers the held back points once the wrapped Simulator is finished:
+// first the late ones in order of delivery, then the backfilled windows
+func (s *LateSimulator) drain(p *serialize.Point) bool {
+	if len(s.late) > 0 {
+		return s.pop(p)
+	}
+	p.Copy(s.backfill[0])
+	s.backfill[0] = nil
+	s.backfill = s.backfill[1:]
+	return true
+}
+
+func (s *LateSimulator) clone(p *serialize.Point) *serialize.Point {
+	var c *serialize.Point
+	if n := len(s.free); n > 0 {
+		c = s.free[n-1]
+		s.free = s.free[:n-1]
+	} else {
+		c = serialize.NewPoint()
+	}
+	c.Copy(p)
+	return c
+}
+
+type latePoint struct {
+	deliverAt time.Time
+	// seq keeps points due at the same time in the order they were made
+	seq uint64
+	p   *serialize.Point
+}
+
+// latePoints is a min-heap of points by delivery time
+type latePoints []*latePoint
+
+func (h latePoints) Len() int { return len(h) }
+func (h latePoints) Less(i, j int) bool {
+	if h[i].deliverAt.Equal(h[j].deliverAt) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deliverAt.Before(h[j].deliverAt)
+}
+func (h latePoints) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *latePoints) Push(x interface{}) { *h = append(*h, x.(*latePoint)) }
+func (h *latePoints) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
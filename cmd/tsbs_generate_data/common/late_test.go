@@ -0,0 +1,140 @@
+package common
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+var testStart = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testSimulator makes n points one second apart
+type testSimulator struct {
+	n, made int
+	ts      time.Time
+}
+
+func (s *testSimulator) Finished() bool              { return s.made >= s.n }
+func (s *testSimulator) Fields() map[string][][]byte { return nil }
+func (s *testSimulator) TagKeys() [][]byte           { return nil }
+func (s *testSimulator) Next(p *serialize.Point) bool {
+	s.ts = testStart.Add(time.Duration(s.made) * time.Second)
+	s.made++
+	p.SetMeasurementName([]byte("test"))
+	p.SetTimestamp(&s.ts)
+	return true
+}
+
+func runLate(c *LateConfig, n int) []time.Time {
+	sim := NewLateSimulator(&testSimulator{n: n}, c)
+	p := serialize.NewPoint()
+	got := []time.Time{}
+	for !sim.Finished() {
+		if sim.Next(p) {
+			got = append(got, *p.Timestamp())
+		}
+		p.Reset()
+	}
+	return got
+}
+
+func TestLateSimulatorSamePoints(t *testing.T) {
+	const n = 1000
+	cases := []struct {
+		desc string
+		c    *LateConfig
+	}{
+		{
+			desc: "uniform",
+			c:    &LateConfig{Fraction: 0.2, Delay: time.Minute, Distribution: LateDistributionUniform, Seed: 1},
+		},
+		{
+			desc: "pareto",
+			c:    &LateConfig{Fraction: 0.2, Delay: time.Minute, Distribution: LateDistributionPareto, Seed: 1},
+		},
+		{
+			desc: "backfill",
+			c:    &LateConfig{BackfillFraction: 0.5, BackfillWindow: time.Minute, Distribution: LateDistributionUniform, Seed: 1},
+		},
+	}
+
+	for _, c := range cases {
+		got := runLate(c.c, n)
+		if len(got) != n {
+			t.Fatalf("%s: incorrect number of points: got %d want %d", c.desc, len(got), n)
+		}
+		if sort.SliceIsSorted(got, func(i, j int) bool { return got[i].Before(got[j]) }) {
+			t.Errorf("%s: points delivered in order", c.desc)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].Before(got[j]) })
+		for i, ts := range got {
+			if want := testStart.Add(time.Duration(i) * time.Second); !ts.Equal(want) {
+				t.Fatalf("%s: point %d missing or duplicated: got %v want %v", c.desc, i, ts, want)
+			}
+		}
+	}
+}
+
+func TestLateSimulatorDeterministic(t *testing.T) {
+	c := &LateConfig{Fraction: 0.3, Delay: time.Minute, Distribution: LateDistributionPareto, BackfillFraction: 0.2, BackfillWindow: time.Minute, Seed: 123}
+	a := runLate(c, 500)
+	b := runLate(c, 500)
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			t.Fatalf("order differs at %d: %v vs %v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestLateSimulatorUniformDelayBounded(t *testing.T) {
+	c := &LateConfig{Fraction: 0.5, Delay: 10 * time.Second, Distribution: LateDistributionUniform, Seed: 7}
+	got := runLate(c, 1000)
+	latest := got[0]
+	for _, ts := range got[1:] {
+		if ts.After(latest) {
+			latest = ts
+		}
+		// points are only held back while the simulator runs, so the
+		// bound holds for all but the points drained at the end
+		if latest.Sub(ts) > c.Delay+time.Second && latest.Before(testStart.Add(999*time.Second)) {
+			t.Errorf("point %v delivered after %v, more than %v late", ts, latest, c.Delay)
+		}
+	}
+}
+
+func TestLateSimulatorBackfillLast(t *testing.T) {
+	c := &LateConfig{BackfillFraction: 1, BackfillWindow: time.Minute, Distribution: LateDistributionUniform, Seed: 1}
+	sim := NewLateSimulator(&testSimulator{n: 10}, c)
+	p := serialize.NewPoint()
+	for i := 0; i < 10; i++ {
+		if sim.Next(p) {
+			t.Fatalf("point %d of a backfilled window delivered on time", i)
+		}
+		p.Reset()
+	}
+	if sim.Finished() {
+		t.Fatalf("simulator finished with backfill pending")
+	}
+}
+
+func TestLateConfigValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		c       LateConfig
+		wantErr bool
+	}{
+		{desc: "disabled", c: LateConfig{Distribution: LateDistributionUniform}},
+		{desc: "valid", c: LateConfig{Fraction: 0.1, Delay: time.Second, Distribution: LateDistributionPareto}},
+		{desc: "fraction > 1", c: LateConfig{Fraction: 1.1, Delay: time.Second, Distribution: LateDistributionUniform}, wantErr: true},
+		{desc: "no delay", c: LateConfig{Fraction: 0.1, Distribution: LateDistributionUniform}, wantErr: true},
+		{desc: "no backfill window", c: LateConfig{BackfillFraction: 0.1, Distribution: LateDistributionUniform}, wantErr: true},
+		{desc: "bad distribution", c: LateConfig{Distribution: "foo"}, wantErr: true},
+	}
+	for _, c := range cases {
+		if err := c.c.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package common
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+const (
+	// shardBatchSize is the number of points a shard hands over at once
+	shardBatchSize = 1024
+	// shardBatches is the number of batches a shard can fill ahead of the
+	// points being consumed
+	shardBatches = 4
+)
+
+// ShardedSimulator runs each of its shards in its own goroutine and merges
+// the points they write in time order. Points with the same timestamp are
+// merged in the order of the shards, so that for given shards the points
+// are always the same and in the same order.
+type ShardedSimulator struct {
+	shards  []*shard
+	fields  map[string][][]byte
+	tagKeys [][]byte
+}
+
+// shard hands over the points a Simulator writes in batches
+type shard struct {
+	batches chan []*serialize.Point
+	free    chan []*serialize.Point
+
+	batch []*serialize.Point
+	next  int
+	done  bool
+}
+
+// NewShardedSimulator starts simulating shards, which must all simulate the
+// same measurements and tags, and returns a Simulator of all their points.
+func NewShardedSimulator(shards []Simulator) *ShardedSimulator {
+	s := &ShardedSimulator{
+		// read before the shards start, as they are not safe for
+		// concurrent use
+		fields:  shards[0].Fields(),
+		tagKeys: shards[0].TagKeys(),
+	}
+	for _, sim := range shards {
+		sh := &shard{
+			batches: make(chan []*serialize.Point, shardBatches),
+			free:    make(chan []*serialize.Point, shardBatches),
+		}
+		for i := 0; i < shardBatches; i++ {
+			batch := make([]*serialize.Point, shardBatchSize)
+			for j := range batch {
+				batch[j] = serialize.NewPoint()
+			}
+			sh.free <- batch[:0]
+		}
+		s.shards = append(s.shards, sh)
+		go sh.run(sim)
+	}
+	return s
+}
+
+// run simulates sim until it is finished, handing over the points to write.
+// Points are copied, as sim can change them once they are made.
+func (sh *shard) run(sim Simulator) {
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		batch := <-sh.free
+		for len(batch) < cap(batch) && !sim.Finished() {
+			if sim.Next(p) {
+				batch = batch[:len(batch)+1]
+				batch[len(batch)-1].Copy(p)
+			}
+			p.Reset()
+		}
+		sh.batches <- batch
+	}
+	close(sh.batches)
+}
+
+// head returns the next point of the shard, or nil if it has none left
+func (sh *shard) head() *serialize.Point {
+	for !sh.done && sh.next == len(sh.batch) {
+		if sh.batch != nil {
+			sh.free <- sh.batch[:0]
+			sh.batch = nil
+		}
+		batch, ok := <-sh.batches
+		if !ok {
+			sh.done = true
+			break
+		}
+		sh.batch, sh.next = batch, 0
+	}
+	if sh.done {
+		return nil
+	}
+	return sh.batch[sh.next]
+}
+
+// Finished tells whether all shards have written all their points
+func (s *ShardedSimulator) Finished() bool {
+	for _, sh := range s.shards {
+		if sh.head() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Fields returns the fields of the measurements of the shards
+func (s *ShardedSimulator) Fields() map[string][][]byte {
+	return s.fields
+}
+
+// TagKeys returns the tag keys of the shards
+func (s *ShardedSimulator) TagKeys() [][]byte {
+	return s.tagKeys
+}
+
+// Next overwrites p with the earliest point of all shards. It returns false
+// if there is none left.
+func (s *ShardedSimulator) Next(p *serialize.Point) bool {
+	var next *shard
+	var nextPoint *serialize.Point
+	for _, sh := range s.shards {
+		hp := sh.head()
+		if hp != nil && (nextPoint == nil || hp.Timestamp().Before(*nextPoint.Timestamp())) {
+			next, nextPoint = sh, hp
+		}
+	}
+	if next == nil {
+		return false
+	}
+	p.Copy(nextPoint)
+	next.next++
+	return true
+}
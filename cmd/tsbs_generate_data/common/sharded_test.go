@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestShardedSimulator(t *testing.T) {
+	ns := []int{2500, 0, 1000, 3000}
+	shards := make([]Simulator, len(ns))
+	for i, n := range ns {
+		shards[i] = &testSimulator{n: n}
+	}
+	sim := NewShardedSimulator(shards)
+
+	got := map[time.Time]int{}
+	var last time.Time
+	total := 0
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if !sim.Next(p) {
+			t.Fatalf("no point before being finished")
+		}
+		if p.Timestamp().Before(last) {
+			t.Fatalf("points out of time order: %v after %v", *p.Timestamp(), last)
+		}
+		last = *p.Timestamp()
+		got[last]++
+		total++
+		p.Reset()
+	}
+	if sim.Next(p) {
+		t.Errorf("point made after being finished")
+	}
+
+	if total != 6500 {
+		t.Errorf("incorrect number of points: got %d want %d", total, 6500)
+	}
+	for i := 0; i < 3000; i++ {
+		want := 0
+		for _, n := range ns {
+			if i < n {
+				want++
+			}
+		}
+		if ts := testStart.Add(time.Duration(i) * time.Second); got[ts] != want {
+			t.Fatalf("incorrect number of points at %v: got %d want %d", ts, got[ts], want)
+		}
+	}
+}
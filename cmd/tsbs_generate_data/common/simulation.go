@@ -11,11 +11,22 @@ type SimulatorConfig interface {
 	ToSimulator(time.Duration) Simulator
 }
 
+// ShardableSimulatorConfig is a SimulatorConfig that can split the series it
+// simulates into shards, which can be simulated concurrently.
+type ShardableSimulatorConfig interface {
+	SimulatorConfig
+	// ToShards produces up to n Simulators over the specified interval, each
+	// simulating a part of the series and drawing its values from its own
+	// random source, derived from seed.
+	ToShards(interval time.Duration, n int, seed int64) []Simulator
+}
+
 // Simulator simulates a use case.
 type Simulator interface {
 	Finished() bool
 	Next(*serialize.Point) bool
 	Fields() map[string][][]byte
+	TagKeys() [][]byte
 }
 
 // SimulatedMeasurement simulates one measurement (e.g. Redis for DevOps).
@@ -0,0 +1,140 @@
+package common
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// SparseConfig describes which points and fields a SparseSimulator leaves
+// out.
+type SparseConfig struct {
+	// FieldFraction is the fraction of field values that are missing
+	FieldFraction float64
+	// NullableFields are the only fields whose values can be missing. All
+	// fields can be if empty.
+	NullableFields []string
+
+	// SilenceProbability is the probability for a series to go silent at
+	// each of its timestamps
+	SilenceProbability float64
+	// SilenceDuration is the mean duration of silences
+	SilenceDuration time.Duration
+
+	// Seed of the decisions of which values are missing, so that they are
+	// deterministic
+	Seed int64
+}
+
+// Enabled returns whether c leaves anything out.
+func (c *SparseConfig) Enabled() bool {
+	return c.FieldFraction > 0 || c.SilenceProbability > 0
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c *SparseConfig) Validate() error {
+	if c.FieldFraction < 0 || c.FieldFraction > 1 {
+		return fmt.Errorf("missing field fraction must be between 0 and 1; got %v", c.FieldFraction)
+	}
+	if c.SilenceProbability < 0 || c.SilenceProbability > 1 {
+		return fmt.Errorf("silence probability must be between 0 and 1; got %v", c.SilenceProbability)
+	}
+	if c.SilenceProbability > 0 && c.SilenceDuration <= 0 {
+		return fmt.Errorf("silence duration must be positive; got %v", c.SilenceDuration)
+	}
+	return nil
+}
+
+// SparseSimulator wraps a Simulator to make its data sparse: series go silent
+// for periods of time, during which none of their points are written, and
+// field values go missing at random. A series is identified by the value of
+// the first tag of its points, e.g. the hostname for devops.
+type SparseSimulator struct {
+	Simulator
+	c        *SparseConfig
+	rand     *rand.Rand
+	nullable map[string]bool
+	series   map[string]*seriesSilence
+}
+
+type seriesSilence struct {
+	// last is the last timestamp of the series, and silent whether the
+	// series was silent at it
+	last   time.Time
+	silent bool
+	until  time.Time
+}
+
+// NewSparseSimulator returns a SparseSimulator making the data of sim sparse
+// according to c.
+func NewSparseSimulator(sim Simulator, c *SparseConfig) *SparseSimulator {
+	s := &SparseSimulator{
+		Simulator: sim,
+		c:         c,
+		rand:      rand.New(rand.NewSource(c.Seed)),
+		series:    make(map[string]*seriesSilence),
+	}
+	if len(c.NullableFields) > 0 {
+		s.nullable = make(map[string]bool, len(c.NullableFields))
+		for _, f := range c.NullableFields {
+			s.nullable[f] = true
+		}
+	}
+	return s
+}
+
+// Next advances p to the next point, returning whether it should be written.
+// Points of silent series and points whose fields are all missing are not.
+func (s *SparseSimulator) Next(p *serialize.Point) bool {
+	if !s.Simulator.Next(p) {
+		return false
+	}
+	if s.c.SilenceProbability > 0 && s.silent(p) {
+		return false
+	}
+	if s.c.FieldFraction <= 0 {
+		return true
+	}
+
+	present := false
+	for i, k := range p.FieldKeys() {
+		if (s.nullable == nil || s.nullable[string(k)]) && s.rand.Float64() < s.c.FieldFraction {
+			p.SetFieldValue(i, nil)
+			continue
+		}
+		present = true
+	}
+	return present
+}
+
+// silent returns whether the series of p is silent at the time of p. Whether
+// a silence starts is decided once per series and timestamp, so all the
+// measurements of a series are silent at the same time.
+func (s *SparseSimulator) silent(p *serialize.Point) bool {
+	tagValues := p.TagValues()
+	if len(tagValues) == 0 {
+		return false
+	}
+	ss, ok := s.series[string(tagValues[0])]
+	if !ok {
+		ss = &seriesSilence{}
+		s.series[string(tagValues[0])] = ss
+	}
+
+	ts := *p.Timestamp()
+	if !ts.After(ss.last) {
+		return ss.silent
+	}
+	ss.last = ts
+	if ts.Before(ss.until) {
+		ss.silent = true
+	} else if s.rand.Float64() < s.c.SilenceProbability {
+		ss.until = ts.Add(time.Duration(s.rand.ExpFloat64() * float64(s.c.SilenceDuration)))
+		ss.silent = true
+	} else {
+		ss.silent = false
+	}
+	return ss.silent
+}
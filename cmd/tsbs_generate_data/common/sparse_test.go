@@ -0,0 +1,131 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+var (
+	testTagKey    = []byte("hostname")
+	testFieldKeys = [][]byte{[]byte("a"), []byte("b")}
+)
+
+// testSeriesSimulator makes points for n series at each second, with two
+// measurements per series and timestamp
+type testSeriesSimulator struct {
+	n, points, made int
+	ts              time.Time
+	names           [][]byte
+}
+
+func newTestSeriesSimulator(n, points int) *testSeriesSimulator {
+	s := &testSeriesSimulator{n: n, points: points}
+	for i := 0; i < n; i++ {
+		s.names = append(s.names, []byte(fmt.Sprintf("host_%d", i)))
+	}
+	return s
+}
+
+func (s *testSeriesSimulator) Finished() bool              { return s.made >= s.points }
+func (s *testSeriesSimulator) Fields() map[string][][]byte { return nil }
+func (s *testSeriesSimulator) TagKeys() [][]byte           { return [][]byte{testTagKey} }
+func (s *testSeriesSimulator) Next(p *serialize.Point) bool {
+	s.ts = testStart.Add(time.Duration(s.made/(2*s.n)) * time.Second)
+	p.SetMeasurementName([]byte("test"))
+	p.SetTimestamp(&s.ts)
+	p.AppendTag(testTagKey, s.names[(s.made/2)%s.n])
+	for i, k := range testFieldKeys {
+		p.AppendField(k, float64(i))
+	}
+	s.made++
+	return true
+}
+
+func TestSparseSimulatorMissingFields(t *testing.T) {
+	cases := []struct {
+		desc     string
+		nullable []string
+		// whether fields a and b can be missing
+		wantMissing [2]bool
+	}{
+		{desc: "all fields nullable", wantMissing: [2]bool{true, true}},
+		{desc: "only b nullable", nullable: []string{"b"}, wantMissing: [2]bool{false, true}},
+	}
+
+	for _, c := range cases {
+		sc := &SparseConfig{FieldFraction: 0.5, NullableFields: c.nullable, Seed: 1}
+		sim := NewSparseSimulator(newTestSeriesSimulator(2, 1000), sc)
+		p := serialize.NewPoint()
+		missing := [2]int{}
+		written := 0
+		for !sim.Finished() {
+			if sim.Next(p) {
+				written++
+				var buf bytes.Buffer
+				(&serialize.InfluxSerializer{}).Serialize(p, &buf)
+				for i, k := range testFieldKeys {
+					if !bytes.Contains(buf.Bytes(), []byte(string(k)+"=")) {
+						missing[i]++
+					}
+				}
+			}
+			p.Reset()
+		}
+		if written == 0 {
+			t.Errorf("%s: no points written", c.desc)
+		}
+		for i, want := range c.wantMissing {
+			if got := missing[i] > 0; got != want {
+				t.Errorf("%s: field %s missing %d times", c.desc, testFieldKeys[i], missing[i])
+			}
+		}
+	}
+}
+
+func TestSparseSimulatorSilence(t *testing.T) {
+	sc := &SparseConfig{SilenceProbability: 0.05, SilenceDuration: 20 * time.Second, Seed: 1}
+	sim := NewSparseSimulator(newTestSeriesSimulator(3, 6000), sc)
+	p := serialize.NewPoint()
+
+	// count the measurements written per series and timestamp
+	counts := map[string]int{}
+	written := 0
+	for !sim.Finished() {
+		if sim.Next(p) {
+			written++
+			counts[fmt.Sprintf("%s/%d", p.TagValues()[0], p.Timestamp().Unix())]++
+		}
+		p.Reset()
+	}
+	if written == 0 || written == 6000 {
+		t.Fatalf("unexpected number of points written: %d", written)
+	}
+	for k, n := range counts {
+		if n != 2 {
+			t.Errorf("series and timestamp %s partially silent: %d of 2 measurements written", k, n)
+		}
+	}
+}
+
+func TestSparseConfigValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		c       SparseConfig
+		wantErr bool
+	}{
+		{desc: "disabled", c: SparseConfig{}},
+		{desc: "valid", c: SparseConfig{FieldFraction: 0.1, SilenceProbability: 0.01, SilenceDuration: time.Minute}},
+		{desc: "fraction > 1", c: SparseConfig{FieldFraction: 2}, wantErr: true},
+		{desc: "negative probability", c: SparseConfig{SilenceProbability: -1}, wantErr: true},
+		{desc: "no silence duration", c: SparseConfig{SilenceProbability: 0.1}, wantErr: true},
+	}
+	for _, c := range cases {
+		if err := c.c.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package devops
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+)
+
+// HostChurn describes how hosts are decommissioned and replaced over time.
+// Each of Hosts slots is held by one host at a time: when a host reaches its
+// Lifetime it is decommissioned, and a new host with a new hostname takes over
+// its slot. The first hosts are of all ages at Start, so that replacements
+// are spread evenly over time, at a rate of Hosts per Lifetime.
+//
+// The schedule does not depend on any random source, which lets the query
+// generator know which hosts were alive in any window of the dataset.
+type HostChurn struct {
+	Start    time.Time
+	Hosts    uint64
+	Lifetime time.Duration
+}
+
+// Enabled tells whether hosts are ever replaced. A nil HostChurn never
+// replaces them.
+func (c *HostChurn) Enabled() bool {
+	return c != nil && c.Lifetime > 0 && c.Hosts > 0
+}
+
+// Generation returns the number of hosts of slot decommissioned before t.
+func (c *HostChurn) Generation(slot uint64, t time.Time) uint64 {
+	if !c.Enabled() || !t.After(c.Start) {
+		return 0
+	}
+	return (uint64(t.Sub(c.Start)) + c.age(slot)) / uint64(c.Lifetime)
+}
+
+// HostID returns the number of the host holding slot at t. The first hosts
+// are numbered as their slots; their replacements get numbers from Hosts up.
+func (c *HostChurn) HostID(slot uint64, t time.Time) uint64 {
+	if !c.Enabled() {
+		return slot
+	}
+	return c.Generation(slot, t)*c.Hosts + slot
+}
+
+// Hostname returns the name of the host holding slot at t.
+func (c *HostChurn) Hostname(slot uint64, t time.Time) string {
+	return fmt.Sprintf("host_%d", c.HostID(slot, t))
+}
+
+// age returns the age at Start of the first host of slot, slot/Hosts of the
+// Lifetime, computed without overflowing for long lifetimes.
+func (c *HostChurn) age(slot uint64) uint64 {
+	hi, lo := bits.Mul64(slot%c.Hosts, uint64(c.Lifetime))
+	age, _ := bits.Div64(hi, lo, c.Hosts)
+	return age
+}
@@ -0,0 +1,120 @@
+package devops
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestHostChurnHostname(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &HostChurn{Start: start, Hosts: 4, Lifetime: 4 * time.Hour}
+	cases := []struct {
+		slot  uint64
+		after time.Duration
+		want  string
+	}{
+		{slot: 0, after: 0, want: "host_0"},
+		{slot: 0, after: 4*time.Hour - time.Second, want: "host_0"},
+		{slot: 0, after: 4 * time.Hour, want: "host_4"},
+		{slot: 0, after: 8 * time.Hour, want: "host_8"},
+		{slot: 3, after: 0, want: "host_3"},
+		{slot: 3, after: 30 * time.Minute, want: "host_3"},
+		{slot: 3, after: time.Hour, want: "host_7"},
+		{slot: 3, after: 5 * time.Hour, want: "host_11"},
+		{slot: 2, after: -time.Hour, want: "host_2"},
+	}
+	for _, tc := range cases {
+		if got := c.Hostname(tc.slot, start.Add(tc.after)); got != tc.want {
+			t.Errorf("slot %d after %v: incorrect hostname: got %s want %s", tc.slot, tc.after, got, tc.want)
+		}
+	}
+
+	// a nil or disabled churn never replaces hosts
+	var nilChurn *HostChurn
+	if got := nilChurn.Hostname(3, start.Add(time.Hour)); got != "host_3" {
+		t.Errorf("nil churn: incorrect hostname: got %s want %s", got, "host_3")
+	}
+	disabled := &HostChurn{Start: start, Hosts: 4}
+	if got := disabled.Hostname(3, start.Add(time.Hour)); got != "host_3" {
+		t.Errorf("disabled churn: incorrect hostname: got %s want %s", got, "host_3")
+	}
+}
+
+func TestHostChurnLongLifetime(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	// slot * Lifetime overflows 64 bits
+	c := &HostChurn{Start: start, Hosts: 1 << 40, Lifetime: 1 << 40}
+	if got := c.Generation(1<<40-2, start.Add(1)); got != 0 {
+		t.Errorf("incorrect generation: got %d want %d", got, 0)
+	}
+	if got := c.Generation(1<<40-2, start.Add(2)); got != 1 {
+		t.Errorf("incorrect generation: got %d want %d", got, 1)
+	}
+}
+
+func TestHostChurnSimulation(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	churn := &HostChurn{Start: start, Hosts: 4, Lifetime: 4 * time.Hour}
+	c := &CPUOnlySimulatorConfig{
+		Start:           start,
+		End:             start.Add(10 * time.Hour),
+		InitHostCount:   4,
+		HostCount:       4,
+		HostConstructor: NewHostCPUOnly,
+		HostLifetime:    churn.Lifetime,
+	}
+
+	sim := c.ToSimulator(time.Minute)
+	counts := map[string]int{}
+	total := 0
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if !sim.Next(p) {
+			p.Reset()
+			continue
+		}
+		name := string(p.TagValues()[0])
+		id, err := strconv.ParseUint(strings.TrimPrefix(name, "host_"), 10, 64)
+		if err != nil {
+			t.Fatalf("unexpected hostname %s", name)
+		}
+		if want := churn.Hostname(id%churn.Hosts, *p.Timestamp()); name != want {
+			t.Errorf("incorrect hostname at %v: got %s want %s", p.Timestamp(), name, want)
+		}
+		counts[name]++
+		total++
+		p.Reset()
+	}
+
+	// every slot reports at every interval, whichever host holds it
+	if want := 4 * 10 * 60; total != want {
+		t.Errorf("incorrect number of points: got %d want %d", total, want)
+	}
+	// slot 3 was held by host_3 for 1h, host_7 for 4h, host_11 for 4h and
+	// host_15 for the last hour
+	for name, want := range map[string]int{"host_3": 60, "host_7": 240, "host_11": 240, "host_15": 60} {
+		if counts[name] != want {
+			t.Errorf("incorrect number of points for %s: got %d want %d", name, counts[name], want)
+		}
+	}
+
+	// shards replace the hosts the same way
+	got := map[string]int{}
+	for _, s := range c.ToShards(time.Minute, 3, 123) {
+		for name, n := range countPoints(s) {
+			got[name] += n
+		}
+	}
+	if len(got) != len(counts) {
+		t.Errorf("incorrect number of hosts in shards: got %d want %d", len(got), len(counts))
+	}
+	for name, n := range counts {
+		if got[name] != n {
+			t.Errorf("incorrect number of points for %s in shards: got %d want %d", name, got[name], n)
+		}
+	}
+}
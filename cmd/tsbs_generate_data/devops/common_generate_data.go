@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
@@ -13,6 +14,10 @@ type commonDevopsSimulator struct {
 
 	hostIndex uint64
 	hosts     []Host
+	// hostOffset is the index of the first of hosts among all hostCount
+	// hosts, when they are simulated in shards
+	hostOffset uint64
+	hostCount  uint64
 
 	epoch      uint64
 	epochs     uint64
@@ -22,6 +27,69 @@ type commonDevopsSimulator struct {
 	timestampStart time.Time
 	timestampEnd   time.Time
 	interval       time.Duration
+
+	// tags are the tags added on top of MachineTagKeys, if any
+	tags *pointTags
+
+	// churn replaces the hosts during the simulation, if enabled;
+	// generations are those of the current hosts
+	churn       *HostChurn
+	generations []uint64
+}
+
+// newCommonDevopsSimulators constructs the hosts of a simulation and splits
+// them into up to n shards of consecutive hosts, each simulated by its own
+// commonDevopsSimulator. Their maxPoints count a single measurement per host.
+func newCommonDevopsSimulators(start, end time.Time, initHosts, hostCount uint64, constructor func(int, time.Time) Host, tags *TagsConfig, realistic bool, lifetime, interval time.Duration, n int) []*commonDevopsSimulator {
+	hostInfos := make([]Host, hostCount)
+	for i := 0; i < len(hostInfos); i++ {
+		hostInfos[i] = constructor(i, start)
+		if realistic {
+			hostInfos[i].makeRealistic()
+		}
+	}
+
+	if uint64(n) > hostCount {
+		n = int(hostCount)
+	}
+	epochs := uint64(end.Sub(start).Nanoseconds() / interval.Nanoseconds())
+	sims := make([]*commonDevopsSimulator, n)
+	for i := range sims {
+		lo, hi := hostCount*uint64(i)/uint64(n), hostCount*uint64(i+1)/uint64(n)
+		s := &commonDevopsSimulator{
+			madePoints: 0,
+			maxPoints:  epochs * (hi - lo),
+
+			hostIndex:  0,
+			hosts:      hostInfos[lo:hi],
+			hostOffset: lo,
+			hostCount:  hostCount,
+
+			epoch:          0,
+			epochs:         epochs,
+			epochHosts:     initHosts,
+			initHosts:      initHosts,
+			timestampStart: start,
+			timestampEnd:   end,
+			interval:       interval,
+		}
+		if tags != nil {
+			s.tags = newPointTags(tags, i, n)
+		}
+		if lifetime > 0 {
+			s.churn = &HostChurn{Start: start, Hosts: hostCount, Lifetime: lifetime}
+			s.generations = make([]uint64, hi-lo)
+		}
+		sims[i] = s
+	}
+	return sims
+}
+
+// setRand makes the hosts of s draw their values from r
+func (s *commonDevopsSimulator) setRand(r *rand.Rand) {
+	for i := range s.hosts {
+		s.hosts[i].setRand(r)
+	}
 }
 
 // Finished tells whether we have simulated all the necessary points
@@ -33,6 +101,14 @@ func (s *commonDevopsSimulator) Fields() map[string][][]byte {
 	return s.fields(s.hosts[0].SimulatedMeasurements)
 }
 
+// TagKeys returns the keys of the tags of every host
+func (s *commonDevopsSimulator) TagKeys() [][]byte {
+	if s.tags != nil {
+		return s.tags.keys
+	}
+	return MachineTagKeys
+}
+
 func (s *commonDevopsSimulator) fields(measurements []common.SimulatedMeasurement) map[string][][]byte {
 	data := make(map[string][][]byte)
 	for _, sm := range measurements {
@@ -58,11 +134,14 @@ func (s *commonDevopsSimulator) populatePoint(p *serialize.Point, measureIdx int
 	p.AppendTag(MachineTagKeys[7], host.Service)
 	p.AppendTag(MachineTagKeys[8], host.ServiceVersion)
 	p.AppendTag(MachineTagKeys[9], host.ServiceEnvironment)
+	if s.tags != nil {
+		s.tags.appendTo(p)
+	}
 
 	// Populate measurement-specific tags and fields:
 	host.SimulatedMeasurements[measureIdx].ToPoint(p)
 
-	ret := s.hostIndex < s.epochHosts
+	ret := s.hostOffset+s.hostIndex < s.epochHosts
 	s.madePoints++
 	s.hostIndex++
 	return ret
@@ -79,6 +158,26 @@ func (s *commonDevopsSimulator) populatePoint(p *serialize.Point, measureIdx int
 // we check whether the point should be recorded by the calling process.
 func (s *commonDevopsSimulator) adjustNumHostsForEpoch() {
 	s.epoch++
-	missingScale := float64(uint64(len(s.hosts)) - s.initHosts)
+	missingScale := float64(s.hostCount - s.initHosts)
 	s.epochHosts = s.initHosts + uint64(missingScale*float64(s.epoch)/float64(s.epochs-1))
+	if s.churn != nil {
+		s.replaceHosts()
+	}
+}
+
+// replaceHosts renames the hosts that were decommissioned during the last
+// epoch after the hosts replacing them. The replacements keep the other tags
+// and the measurements of their slot, as would a new instance of a service.
+func (s *commonDevopsSimulator) replaceHosts() {
+	now := s.timestampStart.Add(time.Duration(s.epoch) * s.interval)
+	for i := range s.hosts {
+		slot := s.hostOffset + uint64(i)
+		g := s.churn.Generation(slot, now)
+		if g == s.generations[i] {
+			continue
+		}
+		s.generations[i] = g
+		// points already made may still refer to the previous name
+		s.hosts[i].Name = []byte(s.churn.Hostname(slot, now))
+	}
 }
@@ -49,3 +49,17 @@ func newCPUMeasurementNumDistributions(start time.Time, numDistributions int) *C
 func (m *CPUMeasurement) ToPoint(p *serialize.Point) {
 	m.toPointAllInt64(p, labelCPU, CPUFields)
 }
+
+// makeRealistic makes the usage follow the activity of users
+func (m *CPUMeasurement) makeRealistic() {
+	for i := range m.distributions {
+		switch string(CPUFields[i].label) {
+		case "usage_user":
+			m.distributions[i] = busy(m.distributions[i], 20, 0, 100)
+		case "usage_system":
+			m.distributions[i] = busy(m.distributions[i], 5, 0, 100)
+		case "usage_idle":
+			m.distributions[i] = busy(m.distributions[i], -25, 0, 100)
+		}
+	}
+}
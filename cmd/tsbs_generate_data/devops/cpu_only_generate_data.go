@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
@@ -44,32 +45,35 @@ type CPUOnlySimulatorConfig struct {
 	// HostCount is the total number of hosts to have in the last reporting period
 	HostCount       uint64
 	HostConstructor func(i int, start time.Time) Host
+
+	// Tags are added to every point on top of MachineTagKeys, if not nil
+	Tags *TagsConfig
+	// Realistic makes the values follow the activity of users, with
+	// anomalies, bursts and counter resets, instead of pure random walks
+	Realistic bool
+	// HostLifetime is the time after which hosts are decommissioned and
+	// replaced by new ones, as described by HostChurn. Zero keeps the same
+	// hosts for the whole simulation.
+	HostLifetime time.Duration
 }
 
 // ToSimulator produces a Simulator that conforms to the given SimulatorConfig over the specified interval
 func (d *CPUOnlySimulatorConfig) ToSimulator(interval time.Duration) common.Simulator {
-	hostInfos := make([]Host, d.HostCount)
-	for i := 0; i < len(hostInfos); i++ {
-		hostInfos[i] = d.HostConstructor(i, d.Start)
-	}
-
-	epochs := uint64(d.End.Sub(d.Start).Nanoseconds() / interval.Nanoseconds())
-	maxPoints := epochs * d.HostCount
-	dg := &CPUOnlySimulator{&commonDevopsSimulator{
-		madePoints: 0,
-		maxPoints:  maxPoints,
-
-		hostIndex: 0,
-		hosts:     hostInfos,
+	return &CPUOnlySimulator{d.shards(interval, 1)[0]}
+}
 
-		epoch:          0,
-		epochs:         epochs,
-		epochHosts:     d.InitHostCount,
-		initHosts:      d.InitHostCount,
-		timestampStart: d.Start,
-		timestampEnd:   d.End,
-		interval:       interval,
-	}}
+// ToShards produces up to n Simulators, each simulating a shard of the hosts
+// with values drawn from its own random source.
+func (d *CPUOnlySimulatorConfig) ToShards(interval time.Duration, n int, seed int64) []common.Simulator {
+	shards := d.shards(interval, n)
+	sims := make([]common.Simulator, len(shards))
+	for i, s := range shards {
+		s.setRand(rand.New(rand.NewSource(seed + int64(i))))
+		sims[i] = &CPUOnlySimulator{s}
+	}
+	return sims
+}
 
-	return dg
+func (d *CPUOnlySimulatorConfig) shards(interval time.Duration, n int) []*commonDevopsSimulator {
+	return newCommonDevopsSimulators(d.Start, d.End, d.InitHostCount, d.HostCount, d.HostConstructor, d.Tags, d.Realistic, d.HostLifetime, interval, n)
 }
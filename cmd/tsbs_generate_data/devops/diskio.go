@@ -49,3 +49,10 @@ func (m *DiskIOMeasurement) ToPoint(p *serialize.Point) {
 	m.toPointAllInt64(p, labelDiskIO, DiskIOFields)
 	p.AppendTag(SerialByteString, m.serial)
 }
+
+// makeRealistic makes the counters reset
+func (m *DiskIOMeasurement) makeRealistic() {
+	for i := range m.distributions {
+		m.distributions[i] = counter(m.distributions[i])
+	}
+}
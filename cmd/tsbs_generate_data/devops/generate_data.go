@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
@@ -43,35 +44,43 @@ type DevopsSimulatorConfig struct {
 	InitHostCount   uint64
 	HostCount       uint64
 	HostConstructor func(i int, start time.Time) Host
+
+	// Tags are added to every point on top of MachineTagKeys, if not nil
+	Tags *TagsConfig
+	// Realistic makes the values follow the activity of users, with
+	// anomalies, bursts and counter resets, instead of pure random walks
+	Realistic bool
+	// HostLifetime is the time after which hosts are decommissioned and
+	// replaced by new ones, as described by HostChurn. Zero keeps the same
+	// hosts for the whole simulation.
+	HostLifetime time.Duration
 }
 
 // ToSimulator produces a Simulator that conforms to the given SimulatorConfig over the specified interval
 func (d *DevopsSimulatorConfig) ToSimulator(interval time.Duration) common.Simulator {
-	hostInfos := make([]Host, d.HostCount)
-	for i := 0; i < len(hostInfos); i++ {
-		hostInfos[i] = d.HostConstructor(i, d.Start)
-	}
+	return d.newSimulator(d.shards(interval, 1)[0])
+}
 
-	epochs := uint64(d.End.Sub(d.Start).Nanoseconds() / interval.Nanoseconds())
-	maxPoints := epochs * d.HostCount * uint64(len(hostInfos[0].SimulatedMeasurements))
-	dg := &DevopsSimulator{
-		commonDevopsSimulator: &commonDevopsSimulator{
-			madePoints: 0,
-			maxPoints:  maxPoints,
+// ToShards produces up to n Simulators, each simulating a shard of the hosts
+// with values drawn from its own random source.
+func (d *DevopsSimulatorConfig) ToShards(interval time.Duration, n int, seed int64) []common.Simulator {
+	shards := d.shards(interval, n)
+	sims := make([]common.Simulator, len(shards))
+	for i, s := range shards {
+		s.setRand(rand.New(rand.NewSource(seed + int64(i))))
+		sims[i] = d.newSimulator(s)
+	}
+	return sims
+}
 
-			hostIndex: 0,
-			hosts:     hostInfos,
+func (d *DevopsSimulatorConfig) shards(interval time.Duration, n int) []*commonDevopsSimulator {
+	return newCommonDevopsSimulators(d.Start, d.End, d.InitHostCount, d.HostCount, d.HostConstructor, d.Tags, d.Realistic, d.HostLifetime, interval, n)
+}
 
-			epoch:          0,
-			epochs:         epochs,
-			epochHosts:     d.InitHostCount,
-			initHosts:      d.InitHostCount,
-			timestampStart: d.Start,
-			timestampEnd:   d.End,
-			interval:       interval,
-		},
+func (d *DevopsSimulatorConfig) newSimulator(s *commonDevopsSimulator) *DevopsSimulator {
+	s.maxPoints *= uint64(len(s.hosts[0].SimulatedMeasurements))
+	return &DevopsSimulator{
+		commonDevopsSimulator:     s,
 		simulatedMeasurementIndex: 0,
 	}
-
-	return dg
 }
@@ -0,0 +1,73 @@
+package devops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// countPoints returns the number of points sim writes for each host
+func countPoints(sim common.Simulator) map[string]int {
+	counts := map[string]int{}
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if sim.Next(p) {
+			counts[string(p.TagValues()[0])]++
+		}
+		p.Reset()
+	}
+	return counts
+}
+
+func TestToShards(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	configs := []common.ShardableSimulatorConfig{
+		&DevopsSimulatorConfig{
+			Start:           start,
+			End:             start.Add(time.Hour),
+			InitHostCount:   3,
+			HostCount:       10,
+			HostConstructor: NewHost,
+		},
+		&CPUOnlySimulatorConfig{
+			Start:           start,
+			End:             start.Add(time.Hour),
+			InitHostCount:   3,
+			HostCount:       10,
+			HostConstructor: NewHostCPUOnly,
+		},
+	}
+
+	for _, c := range configs {
+		want := countPoints(c.ToSimulator(10 * time.Second))
+
+		shards := c.ToShards(10*time.Second, 4, 123)
+		if len(shards) != 4 {
+			t.Fatalf("%T: incorrect number of shards: got %d want %d", c, len(shards), 4)
+		}
+		got := map[string]int{}
+		for _, s := range shards {
+			for host, n := range countPoints(s) {
+				if _, ok := got[host]; ok {
+					t.Errorf("%T: host %s simulated by more than one shard", c, host)
+				}
+				got[host] = n
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("%T: incorrect number of hosts: got %d want %d", c, len(got), len(want))
+		}
+		for host, n := range want {
+			if got[host] != n {
+				t.Errorf("%T: incorrect number of points for %s: got %d want %d", c, host, got[host], n)
+			}
+		}
+
+		// there cannot be more shards than hosts
+		if got := len(c.ToShards(10*time.Second, 20, 123)); got != 10 {
+			t.Errorf("%T: incorrect number of shards: got %d want %d", c, got, 10)
+		}
+	}
+}
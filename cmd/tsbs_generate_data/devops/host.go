@@ -132,7 +132,8 @@ var (
 type Host struct {
 	SimulatedMeasurements []common.SimulatedMeasurement
 
-	// These are all assigned once, at Host creation:
+	// These are all assigned once, at Host creation, but for the Name of
+	// hosts replaced by a HostChurn:
 	Name, Region, Datacenter, Rack, OS, Arch          []byte
 	Team, Service, ServiceVersion, ServiceEnvironment []byte
 }
@@ -215,6 +216,27 @@ func (h *Host) TickAll(d time.Duration) {
 	}
 }
 
+// setRand makes all Distributions of a Host draw from r instead of the global
+// source of math/rand.
+func (h *Host) setRand(r *rand.Rand) {
+	for _, sm := range h.SimulatedMeasurements {
+		if m, ok := sm.(interface{ setRand(*rand.Rand) }); ok {
+			m.setRand(r)
+		}
+	}
+}
+
+// makeRealistic makes the values of a Host follow the daily and weekly
+// activity of users, with anomalies, bursts and counter resets, for the
+// measurements that support it.
+func (h *Host) makeRealistic() {
+	for _, sm := range h.SimulatedMeasurements {
+		if m, ok := sm.(realisticMeasurement); ok {
+			m.makeRealistic()
+		}
+	}
+}
+
 func randChoice(choices [][]byte) []byte {
 	idx := rand.Int63n(int64(len(choices)))
 	return choices[idx]
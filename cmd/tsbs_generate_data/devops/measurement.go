@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
@@ -30,7 +31,14 @@ func newSubsystemMeasurementWithDistributionMakers(start time.Time, makers []lab
 func (m *subsystemMeasurement) Tick(d time.Duration) {
 	m.timestamp = m.timestamp.Add(d)
 	for i := range m.distributions {
-		m.distributions[i].Advance()
+		common.AdvanceAt(m.distributions[i], m.timestamp)
+	}
+}
+
+// setRand makes the distributions of m draw from r
+func (m *subsystemMeasurement) setRand(r *rand.Rand) {
+	for i := range m.distributions {
+		m.distributions[i] = common.WithRand(m.distributions[i], r)
 	}
 }
 
@@ -55,6 +55,12 @@ func NewMemMeasurement(start time.Time) *MemMeasurement {
 	}
 }
 
+// makeRealistic makes the used memory follow the activity of users
+func (m *MemMeasurement) makeRealistic() {
+	total := float64(m.bytesTotal)
+	m.distributions[0] = busy(m.distributions[0], total/8, 0, total)
+}
+
 func (m *MemMeasurement) ToPoint(p *serialize.Point) {
 	p.SetMeasurementName(labelMem)
 	p.SetTimestamp(&m.timestamp)
@@ -49,3 +49,15 @@ func (m *NetMeasurement) ToPoint(p *serialize.Point) {
 	m.toPointAllInt64(p, labelNet, NetFields)
 	p.AppendTag(labelNetTagInterface, m.interfaceName)
 }
+
+// makeRealistic makes the traffic bursty and the counters reset
+func (m *NetMeasurement) makeRealistic() {
+	for i := range m.distributions {
+		switch string(NetFields[i].label) {
+		case "bytes_sent", "bytes_recv", "packets_sent", "packets_recv":
+			m.distributions[i] = burstyCounter(highND.Mean)
+		default:
+			m.distributions[i] = counter(m.distributions[i])
+		}
+	}
+}
@@ -51,3 +51,16 @@ func (m *NginxMeasurement) ToPoint(p *serialize.Point) {
 	p.AppendTag(labelNginxTagPort, m.port)
 	p.AppendTag(labelNginxTagServer, m.serverName)
 }
+
+// makeRealistic makes the connections follow the activity of users and the
+// counters reset
+func (m *NginxMeasurement) makeRealistic() {
+	for i := range m.distributions {
+		switch string(NginxFields[i].label) {
+		case "accepts", "handled", "requests":
+			m.distributions[i] = counter(m.distributions[i])
+		default:
+			m.distributions[i] = busy(m.distributions[i], 20, 0, 100)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package devops
+
+import (
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+)
+
+// Parameters of realistic values, see Host.makeRealistic
+const (
+	// busiestTime is the time of day hosts are the busiest at
+	busiestTime = 14 * time.Hour
+
+	// spikeProbability is the chance of a spike in a gauge at each interval,
+	// which lasts spikeIntervals intervals
+	spikeProbability = 1e-3
+	spikeIntervals   = 6
+	// shiftProbability is the chance of a level shift of a gauge at each
+	// interval, e.g. after a deployment
+	shiftProbability = 1e-4
+
+	// restartProbability is the chance of a counter being reset at each
+	// interval, as when the process keeping it restarts
+	restartProbability = 1e-4
+
+	// burstShape is the shape of the Pareto distribution of the increments
+	// of bursty counters
+	burstShape = 1.5
+)
+
+// realisticMeasurement is a measurement whose values can be made realistic
+type realisticMeasurement interface {
+	makeRealistic()
+}
+
+// busy makes the gauge d follow the activity of users, varying by amplitude
+// over the day, with spikes of the same size and occasional level shifts,
+// within [min, max]
+func busy(d common.Distribution, amplitude, min, max float64) common.Distribution {
+	seasonal := common.Seasonal(d, amplitude, amplitude/4, busiestTime)
+	anomalies := common.Anomalies(seasonal, spikeProbability, amplitude, spikeIntervals, shiftProbability, amplitude/4)
+	return common.Clamp(anomalies, min, max)
+}
+
+// counter makes the counter d reset from time to time
+func counter(d common.Distribution) common.Distribution {
+	return common.Resets(d, restartProbability)
+}
+
+// burstyCounter is a counter whose increments are heavy-tailed, with the
+// given mean, instead of normally distributed
+func burstyCounter(mean float64) common.Distribution {
+	return counter(common.MWD(common.PD(mean*(burstShape-1)/burstShape, burstShape), 0))
+}
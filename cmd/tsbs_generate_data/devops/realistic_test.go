@@ -0,0 +1,49 @@
+package devops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+)
+
+func TestHostMakeRealistic(t *testing.T) {
+	h := NewHost(0, time.Now())
+	h.makeRealistic()
+
+	for _, sm := range h.SimulatedMeasurements {
+		switch m := sm.(type) {
+		case *CPUMeasurement:
+			if _, ok := m.distributions[0].(*common.ClampedDistribution); !ok {
+				t.Errorf("usage_user is not realistic: %T", m.distributions[0])
+			}
+			if _, ok := m.distributions[3].(*common.ClampedRandomWalkDistribution); !ok {
+				t.Errorf("usage_nice changed: %T", m.distributions[3])
+			}
+		case *NetMeasurement:
+			for i, d := range m.distributions {
+				if _, ok := d.(*common.ResetDistribution); !ok {
+					t.Errorf("net counter %s does not reset: %T", NetFields[i].label, d)
+				}
+			}
+		case *DiskIOMeasurement:
+			for i, d := range m.distributions {
+				if _, ok := d.(*common.ResetDistribution); !ok {
+					t.Errorf("diskio counter %s does not reset: %T", DiskIOFields[i].label, d)
+				}
+			}
+		}
+	}
+}
+
+func TestMakeRealisticCPUSingle(t *testing.T) {
+	h := NewHostCPUSingle(0, time.Now())
+	h.makeRealistic()
+	m := h.SimulatedMeasurements[0].(*CPUMeasurement)
+	if got := len(m.distributions); got != 1 {
+		t.Fatalf("incorrect number of distributions: got %d want %d", got, 1)
+	}
+	if _, ok := m.distributions[0].(*common.ClampedDistribution); !ok {
+		t.Errorf("usage_user is not realistic: %T", m.distributions[0])
+	}
+}
@@ -0,0 +1,126 @@
+package devops
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// Distributions of the values of extra tags
+const (
+	TagDistributionUniform = "uniform"
+	TagDistributionZipf    = "zipf"
+
+	// zipfS is the exponent of the Zipf distribution of tag values: the
+	// i-th most common value is about 1/i^zipfS as common as the first
+	zipfS = 1.1
+)
+
+// ExtraTag is a tag added to every point on top of MachineTagKeys. Its value
+// is drawn at every point among Cardinality values, so the number of series
+// grows with it.
+type ExtraTag struct {
+	Key          []byte
+	Cardinality  uint64
+	Distribution string
+}
+
+// TagsConfig describes the tags added to every point on top of
+// MachineTagKeys.
+type TagsConfig struct {
+	Extra []ExtraTag
+	// UniqueKey, if not empty, is the key of a tag whose value is different
+	// for every point, e.g. a request or container ID
+	UniqueKey []byte
+	// Seed of the values of the extra tags, so that they are deterministic
+	Seed int64
+}
+
+// ParseExtraTags parses a comma-separated list of extra tags of the form
+// key:cardinality[:distribution], e.g. "pod:1000:zipf,zone:10".
+func ParseExtraTags(spec string) ([]ExtraTag, error) {
+	tags := []ExtraTag{}
+	if len(spec) == 0 {
+		return tags, nil
+	}
+	for _, s := range strings.Split(spec, ",") {
+		parts := strings.Split(s, ":")
+		if len(parts) < 2 || len(parts) > 3 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid extra tag '%s': want key:cardinality[:distribution]", s)
+		}
+		cardinality, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || cardinality == 0 {
+			return nil, fmt.Errorf("invalid cardinality of extra tag '%s': %s", parts[0], parts[1])
+		}
+		t := ExtraTag{Key: []byte(parts[0]), Cardinality: cardinality, Distribution: TagDistributionUniform}
+		if len(parts) == 3 {
+			t.Distribution = parts[2]
+		}
+		if t.Distribution != TagDistributionUniform && t.Distribution != TagDistributionZipf {
+			return nil, fmt.Errorf("unknown distribution of extra tag '%s': %s", parts[0], t.Distribution)
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// pointTags appends the tags of a TagsConfig to points
+type pointTags struct {
+	keys   [][]byte
+	extra  []tagValues
+	unique []byte
+	// next unique value, which is incremented by step so that shards of
+	// the hosts never use the same values
+	points uint64
+	step   uint64
+}
+
+type tagValues struct {
+	key    []byte
+	prefix string
+	next   func() uint64
+}
+
+// newPointTags returns the pointTags of the given shard of shards shards of
+// the hosts.
+func newPointTags(c *TagsConfig, shard, shards int) *pointTags {
+	t := &pointTags{
+		keys:   append([][]byte{}, MachineTagKeys...),
+		unique: c.UniqueKey,
+		points: uint64(shard),
+		step:   uint64(shards),
+	}
+	r := rand.New(rand.NewSource(c.Seed + int64(shard)))
+	for _, e := range c.Extra {
+		tv := tagValues{key: e.Key, prefix: string(e.Key) + "_"}
+		cardinality := e.Cardinality
+		if e.Distribution == TagDistributionZipf {
+			tv.next = rand.NewZipf(r, zipfS, 1, cardinality-1).Uint64
+		} else {
+			tv.next = func() uint64 { return uint64(r.Int63n(int64(cardinality))) }
+		}
+		t.extra = append(t.extra, tv)
+		t.keys = append(t.keys, e.Key)
+	}
+	if len(c.UniqueKey) > 0 {
+		t.keys = append(t.keys, c.UniqueKey)
+	}
+	return t
+}
+
+// appendTo appends the tags to p. Values are newly allocated, since p can
+// outlive the call (e.g. when it is delivered late).
+func (t *pointTags) appendTo(p *serialize.Point) {
+	for _, tv := range t.extra {
+		v := make([]byte, 0, len(tv.prefix)+8)
+		v = append(v, tv.prefix...)
+		p.AppendTag(tv.key, strconv.AppendUint(v, tv.next(), 10))
+	}
+	if len(t.unique) > 0 {
+		p.AppendTag(t.unique, strconv.AppendUint(nil, t.points, 10))
+		t.points += t.step
+	}
+}
@@ -0,0 +1,132 @@
+package devops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestParseExtraTags(t *testing.T) {
+	cases := []struct {
+		desc    string
+		spec    string
+		want    []ExtraTag
+		wantErr bool
+	}{
+		{desc: "empty", spec: "", want: []ExtraTag{}},
+		{
+			desc: "default distribution",
+			spec: "zone:10",
+			want: []ExtraTag{{[]byte("zone"), 10, TagDistributionUniform}},
+		},
+		{
+			desc: "multiple tags",
+			spec: "pod:1000:zipf,zone:10:uniform",
+			want: []ExtraTag{
+				{[]byte("pod"), 1000, TagDistributionZipf},
+				{[]byte("zone"), 10, TagDistributionUniform},
+			},
+		},
+		{desc: "no cardinality", spec: "pod", wantErr: true},
+		{desc: "zero cardinality", spec: "pod:0", wantErr: true},
+		{desc: "bad cardinality", spec: "pod:many", wantErr: true},
+		{desc: "no key", spec: ":10", wantErr: true},
+		{desc: "bad distribution", spec: "pod:10:normal", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := ParseExtraTags(c.spec)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: incorrect number of tags: got %d want %d", c.desc, len(got), len(c.want))
+			continue
+		}
+		for i := range got {
+			if !bytes.Equal(got[i].Key, c.want[i].Key) || got[i].Cardinality != c.want[i].Cardinality || got[i].Distribution != c.want[i].Distribution {
+				t.Errorf("%s: incorrect tag %d: got %v want %v", c.desc, i, got[i], c.want[i])
+			}
+		}
+	}
+}
+
+func TestPointTags(t *testing.T) {
+	c := &TagsConfig{
+		Extra: []ExtraTag{
+			{[]byte("zone"), 4, TagDistributionUniform},
+			{[]byte("pod"), 1000, TagDistributionZipf},
+		},
+		UniqueKey: []byte("request_id"),
+		Seed:      123,
+	}
+	tags := newPointTags(c, 0, 1)
+	if got, want := len(tags.keys), len(MachineTagKeys)+3; got != want {
+		t.Fatalf("incorrect number of tag keys: got %d want %d", got, want)
+	}
+
+	zones := map[string]int{}
+	pods := map[string]int{}
+	requests := map[string]bool{}
+	p := serialize.NewPoint()
+	for i := 0; i < 10000; i++ {
+		tags.appendTo(p)
+		vals := p.TagValues()
+		zones[string(vals[0])]++
+		pods[string(vals[1])]++
+		requests[string(vals[2])] = true
+		p.Reset()
+	}
+
+	if got := len(zones); got != 4 {
+		t.Errorf("incorrect zone cardinality: got %d want %d", got, 4)
+	}
+	for z := range zones {
+		if !strings.HasPrefix(z, "zone_") {
+			t.Errorf("incorrect zone value: %s", z)
+		}
+	}
+	if len(pods) > 1000 {
+		t.Errorf("pod cardinality over 1000: %d", len(pods))
+	}
+	// with a Zipf distribution the first value is by far the most common
+	if pods["pod_0"] < 10000/10 {
+		t.Errorf("pod values are not skewed: pod_0 drawn %d times", pods["pod_0"])
+	}
+	if got := len(requests); got != 10000 {
+		t.Errorf("request ids are not unique: got %d distinct want %d", got, 10000)
+	}
+}
+
+func TestDevopsSimulatorTags(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &CPUOnlySimulatorConfig{
+		Start:           start,
+		End:             start.Add(time.Minute),
+		InitHostCount:   1,
+		HostCount:       1,
+		HostConstructor: NewHostCPUOnly,
+		Tags:            &TagsConfig{Extra: []ExtraTag{{[]byte("zone"), 4, TagDistributionUniform}}},
+	}
+	sim := c.ToSimulator(10 * time.Second)
+	if got := string(sim.TagKeys()[len(MachineTagKeys)]); got != "zone" {
+		t.Errorf("incorrect extra tag key: got %s want zone", got)
+	}
+
+	p := serialize.NewPoint()
+	sim.Next(p)
+	var buf bytes.Buffer
+	(&serialize.InfluxSerializer{}).Serialize(p, &buf)
+	if !bytes.Contains(buf.Bytes(), []byte(",service_environment=")) || !bytes.Contains(buf.Bytes(), []byte(",zone=zone_")) {
+		t.Errorf("point is missing tags: %s", buf.String())
+	}
+
+	c.Tags = nil
+	if got := len(c.ToSimulator(10 * time.Second).TagKeys()); got != len(MachineTagKeys) {
+		t.Errorf("incorrect number of tag keys without extra tags: got %d want %d", got, len(MachineTagKeys))
+	}
+}
@@ -0,0 +1,116 @@
+package iot
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+)
+
+const (
+	// offlineProbability is the chance of an online device going offline
+	// at each interval, e.g., when a vehicle drives out of network coverage
+	offlineProbability = 0.01
+	// meanOfflineIntervals is the mean number of intervals a device stays
+	// offline for, during which it reports nothing
+	meanOfflineIntervals = 30
+)
+
+// model is a vehicle model, which determines the range of its readings
+type model struct {
+	Name            []byte
+	MaxVelocity     float64 // MaxVelocity is the top speed in km/h
+	FuelConsumption float64 // FuelConsumption is the nominal consumption in l/100km
+}
+
+var (
+	models = []model{
+		{[]byte("F-150"), 160, 15},
+		{[]byte("G-2000"), 120, 25},
+		{[]byte("H-2"), 100, 35},
+	}
+
+	DeviceFleetChoices = [][]byte{
+		[]byte("East"),
+		[]byte("West"),
+		[]byte("North"),
+		[]byte("South"),
+	}
+	DeviceDriverChoices = [][]byte{
+		[]byte("Albert"),
+		[]byte("Derek"),
+		[]byte("Mia"),
+		[]byte("Noor"),
+		[]byte("Ravi"),
+		[]byte("Seth"),
+		[]byte("Trish"),
+		[]byte("Yuki"),
+	}
+	DeviceVersionChoices = [][]byte{
+		[]byte("v1.0"),
+		[]byte("v1.5"),
+		[]byte("v2.0"),
+		[]byte("v2.3"),
+	}
+
+	// DeviceTagKeys fields common to all devices:
+	DeviceTagKeys = [][]byte{
+		[]byte("name"),
+		[]byte("fleet"),
+		[]byte("driver"),
+		[]byte("model"),
+		[]byte("device_version"),
+	}
+)
+
+// Device models a vehicle reporting telemetry for an IoT fleet
+type Device struct {
+	SimulatedMeasurements []common.SimulatedMeasurement
+
+	// These are all assigned once, at Device creation:
+	Name, Fleet, Driver, Model, DeviceVersion []byte
+
+	// offlineIntervals is the number of intervals the device stays offline for
+	offlineIntervals uint64
+}
+
+// NewDevice creates a new device in a simulated iot use case
+func NewDevice(i int, start time.Time) Device {
+	m := &models[rand.Intn(len(models))]
+	return Device{
+		Name:          []byte(fmt.Sprintf("truck_%d", i)),
+		Fleet:         randChoice(DeviceFleetChoices),
+		Driver:        randChoice(DeviceDriverChoices),
+		Model:         m.Name,
+		DeviceVersion: randChoice(DeviceVersionChoices),
+
+		SimulatedMeasurements: []common.SimulatedMeasurement{
+			NewReadingsMeasurement(start, m),
+			NewDiagnosticsMeasurement(start),
+		},
+	}
+}
+
+// TickAll advances all Distributions of a Device, and whether it is offline.
+func (d *Device) TickAll(interval time.Duration) {
+	for i := range d.SimulatedMeasurements {
+		d.SimulatedMeasurements[i].Tick(interval)
+	}
+
+	if d.offlineIntervals > 0 {
+		d.offlineIntervals--
+	} else if rand.Float64() < offlineProbability {
+		d.offlineIntervals = 1 + uint64(rand.ExpFloat64()*meanOfflineIntervals)
+	}
+}
+
+// Offline tells whether the device is currently not reporting
+func (d *Device) Offline() bool {
+	return d.offlineIntervals > 0
+}
+
+func randChoice(choices [][]byte) []byte {
+	idx := rand.Int63n(int64(len(choices)))
+	return choices[idx]
+}
@@ -0,0 +1,136 @@
+package iot
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+var (
+	labelReadings    = []byte("readings") // heap optimization
+	labelDiagnostics = []byte("diagnostics")
+
+	// ReadingsFields are the fields of the readings measurement, which holds
+	// the position and movement of a vehicle
+	ReadingsFields = [][]byte{
+		[]byte("latitude"),
+		[]byte("longitude"),
+		[]byte("elevation"),
+		[]byte("velocity"),
+		[]byte("heading"),
+		[]byte("grade"),
+		[]byte("fuel_consumption"),
+	}
+
+	// DiagnosticsFields are the fields of the diagnostics measurement, which
+	// holds the engine and load state of a vehicle. fuel_state and
+	// current_load are percentages of the tank and load capacity, and status
+	// is an error code, 0 meaning no error.
+	DiagnosticsFields = [][]byte{
+		[]byte("fuel_state"),
+		[]byte("current_load"),
+		[]byte("engine_rpm"),
+		[]byte("engine_temperature"),
+		[]byte("status"),
+	}
+)
+
+// measurement holds the distributions of a measurement's fields, in the same
+// order as its field labels
+type measurement struct {
+	timestamp     time.Time
+	distributions []common.Distribution
+}
+
+func (m *measurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+	for i := range m.distributions {
+		m.distributions[i].Advance()
+	}
+}
+
+// ReadingsMeasurement simulates the GPS position and movement of a vehicle
+type ReadingsMeasurement struct {
+	*measurement
+}
+
+// NewReadingsMeasurement creates the readings of a vehicle of the given model,
+// starting somewhere in the continental US
+func NewReadingsMeasurement(start time.Time, m *model) *ReadingsMeasurement {
+	return &ReadingsMeasurement{&measurement{
+		timestamp: start,
+		distributions: []common.Distribution{
+			common.CWD(common.ND(0, 0.001), -90, 90, 25+rand.Float64()*24),
+			common.CWD(common.ND(0, 0.001), -180, 180, -124+rand.Float64()*57),
+			common.CWD(common.ND(0, 5), 0, 3000, rand.Float64()*500),
+			common.CWD(common.ND(0, 2), 0, m.MaxVelocity, rand.Float64()*m.MaxVelocity),
+			common.CWD(common.ND(0, 5), 0, 360, rand.Float64()*360),
+			common.CWD(common.ND(0, 0.5), -15, 15, 0),
+			common.CWD(common.ND(0, 1), 0, 2*m.FuelConsumption, m.FuelConsumption),
+		},
+	}}
+}
+
+// ToPoint fills in the readings of the vehicle
+func (m *ReadingsMeasurement) ToPoint(p *serialize.Point) {
+	p.SetMeasurementName(labelReadings)
+	p.SetTimestamp(&m.timestamp)
+	for i, d := range m.distributions {
+		p.AppendField(ReadingsFields[i], d.Get())
+	}
+}
+
+// DiagnosticsMeasurement simulates the engine and load state of a vehicle
+type DiagnosticsMeasurement struct {
+	*measurement
+}
+
+// NewDiagnosticsMeasurement creates the diagnostics of a vehicle
+func NewDiagnosticsMeasurement(start time.Time) *DiagnosticsMeasurement {
+	return &DiagnosticsMeasurement{&measurement{
+		timestamp: start,
+		distributions: []common.Distribution{
+			&tankDistribution{Step: common.ND(0, 0.2), Refill: 10, State: 10 + rand.Float64()*90},
+			common.CWD(common.ND(0, 1), 0, 100, rand.Float64()*100),
+			common.CWD(common.ND(0, 50), 600, 4500, 800+rand.Float64()*1000),
+			common.CWD(common.ND(0, 0.5), 60, 120, 85),
+			common.CWD(common.ND(0, 0.5), 0, 5, 0),
+		},
+	}}
+}
+
+// ToPoint fills in the diagnostics of the vehicle, the status being an integer
+func (m *DiagnosticsMeasurement) ToPoint(p *serialize.Point) {
+	p.SetMeasurementName(labelDiagnostics)
+	p.SetTimestamp(&m.timestamp)
+	last := len(m.distributions) - 1
+	for i, d := range m.distributions[:last] {
+		p.AppendField(DiagnosticsFields[i], d.Get())
+	}
+	p.AppendField(DiagnosticsFields[last], int64(m.distributions[last].Get()))
+}
+
+// tankDistribution models a fuel tank, in percent: it only goes down by the
+// absolute value of Step, and is filled up again once below Refill.
+type tankDistribution struct {
+	Step   common.Distribution
+	Refill float64
+	State  float64
+}
+
+// Advance uses up some fuel, refilling the tank if needed
+func (d *tankDistribution) Advance() {
+	d.Step.Advance()
+	d.State -= math.Abs(d.Step.Get())
+	if d.State < d.Refill {
+		d.State = 100
+	}
+}
+
+// Get returns the fuel left in the tank
+func (d *tankDistribution) Get() float64 {
+	return d.State
+}
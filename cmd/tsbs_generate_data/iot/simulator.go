@@ -0,0 +1,134 @@
+package iot
+
+import (
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// Simulator generates telemetry for a fleet of vehicles, with each device
+// reporting its readings and diagnostics every interval unless it is offline.
+// It fulfills the Simulator interface.
+type Simulator struct {
+	madePoints uint64
+	maxPoints  uint64
+
+	deviceIndex               uint64
+	simulatedMeasurementIndex int
+	devices                   []Device
+
+	epoch        uint64
+	epochs       uint64
+	epochDevices uint64
+	initDevices  uint64
+
+	interval time.Duration
+}
+
+// Finished tells whether we have simulated all the necessary points
+func (s *Simulator) Finished() bool {
+	return s.madePoints >= s.maxPoints
+}
+
+// Fields returns a map of measurements to the fields they hold
+func (s *Simulator) Fields() map[string][][]byte {
+	data := make(map[string][][]byte)
+	for _, sm := range s.devices[0].SimulatedMeasurements {
+		point := serialize.NewPoint()
+		sm.ToPoint(point)
+		data[string(point.MeasurementName())] = point.FieldKeys()
+	}
+	return data
+}
+
+// TagKeys returns the keys of the tags of every device
+func (s *Simulator) TagKeys() [][]byte {
+	return DeviceTagKeys
+}
+
+// Next advances a Point to the next state in the generator. It returns
+// false for points of devices that are offline or not reporting yet, which
+// should not be written.
+func (s *Simulator) Next(p *serialize.Point) bool {
+	// switch to the next measurement if needed
+	if s.deviceIndex == uint64(len(s.devices)) {
+		s.deviceIndex = 0
+		s.simulatedMeasurementIndex++
+	}
+
+	if s.simulatedMeasurementIndex == len(s.devices[0].SimulatedMeasurements) {
+		s.simulatedMeasurementIndex = 0
+
+		for i := 0; i < len(s.devices); i++ {
+			s.devices[i].TickAll(s.interval)
+		}
+
+		s.adjustNumDevicesForEpoch()
+	}
+
+	device := &s.devices[s.deviceIndex]
+
+	// Populate device-specific tags:
+	p.AppendTag(DeviceTagKeys[0], device.Name)
+	p.AppendTag(DeviceTagKeys[1], device.Fleet)
+	p.AppendTag(DeviceTagKeys[2], device.Driver)
+	p.AppendTag(DeviceTagKeys[3], device.Model)
+	p.AppendTag(DeviceTagKeys[4], device.DeviceVersion)
+
+	// Populate measurement-specific fields:
+	device.SimulatedMeasurements[s.simulatedMeasurementIndex].ToPoint(p)
+
+	ret := s.deviceIndex < s.epochDevices && !device.Offline()
+	s.madePoints++
+	s.deviceIndex++
+	return ret
+}
+
+// adjustNumDevicesForEpoch scales up the number of reporting devices from
+// the initial count to the total count over the epochs, the same way
+// devops does for hosts
+func (s *Simulator) adjustNumDevicesForEpoch() {
+	s.epoch++
+	if s.epochs < 2 {
+		return
+	}
+	missingScale := float64(uint64(len(s.devices)) - s.initDevices)
+	s.epochDevices = s.initDevices + uint64(missingScale*float64(s.epoch)/float64(s.epochs-1))
+}
+
+// SimulatorConfig is used to create an IoT Simulator.
+type SimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	// InitDeviceCount is the number of devices reporting in the first reporting period
+	InitDeviceCount uint64
+	// DeviceCount is the total number of devices reporting in the last reporting period
+	DeviceCount       uint64
+	DeviceConstructor func(i int, start time.Time) Device
+}
+
+// ToSimulator produces a Simulator that conforms to the given SimulatorConfig over the specified interval
+func (c *SimulatorConfig) ToSimulator(interval time.Duration) common.Simulator {
+	devices := make([]Device, c.DeviceCount)
+	for i := 0; i < len(devices); i++ {
+		devices[i] = c.DeviceConstructor(i, c.Start)
+	}
+
+	epochs := uint64(c.End.Sub(c.Start).Nanoseconds() / interval.Nanoseconds())
+	maxPoints := epochs * c.DeviceCount * uint64(len(devices[0].SimulatedMeasurements))
+	return &Simulator{
+		madePoints: 0,
+		maxPoints:  maxPoints,
+
+		deviceIndex: 0,
+		devices:     devices,
+
+		epoch:        0,
+		epochs:       epochs,
+		epochDevices: c.InitDeviceCount,
+		initDevices:  c.InitDeviceCount,
+		interval:     interval,
+	}
+}
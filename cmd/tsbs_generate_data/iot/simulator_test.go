@@ -0,0 +1,129 @@
+package iot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func testConfig(initDevices, devices uint64) *SimulatorConfig {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &SimulatorConfig{
+		Start:             start,
+		End:               start.Add(time.Hour),
+		InitDeviceCount:   initDevices,
+		DeviceCount:       devices,
+		DeviceConstructor: NewDevice,
+	}
+}
+
+func TestSimulatorNext(t *testing.T) {
+	const devices = 10
+	sim := testConfig(devices, devices).ToSimulator(time.Minute)
+
+	made, written := 0, 0
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if sim.Next(p) {
+			written++
+		}
+		if got := len(p.FieldKeys()); got == 0 {
+			t.Fatalf("point has no fields")
+		}
+		p.Reset()
+		made++
+	}
+	if want := 60 * devices * 2; made != want {
+		t.Errorf("incorrect number of points made: got %d want %d", made, want)
+	}
+	if written > made {
+		t.Errorf("more points written than made: %d > %d", written, made)
+	}
+}
+
+func TestSimulatorNextOffline(t *testing.T) {
+	sim := testConfig(1, 1).ToSimulator(time.Minute).(*Simulator)
+	sim.devices[0].offlineIntervals = 1000
+
+	p := serialize.NewPoint()
+	for i := 0; i < 10; i++ {
+		if sim.Next(p) {
+			t.Fatalf("point of an offline device should not be written")
+		}
+		p.Reset()
+	}
+}
+
+func TestSimulatorTags(t *testing.T) {
+	sim := testConfig(1, 1).ToSimulator(time.Minute)
+	p := serialize.NewPoint()
+	sim.Next(p)
+
+	var buf bytes.Buffer
+	if err := (&serialize.InfluxSerializer{}).Serialize(p, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	line := buf.String()
+	if !bytes.HasPrefix([]byte(line), []byte("readings,name=truck_0,fleet=")) {
+		t.Errorf("unexpected first point: %s", line)
+	}
+	for _, k := range sim.TagKeys() {
+		if !bytes.Contains([]byte(line), append(k, '=')) {
+			t.Errorf("point is missing tag %s: %s", k, line)
+		}
+	}
+}
+
+func TestSimulatorFields(t *testing.T) {
+	fields := testConfig(1, 1).ToSimulator(time.Minute).Fields()
+	if got := len(fields); got != 2 {
+		t.Fatalf("incorrect number of measurements: got %d want %d", got, 2)
+	}
+	if got := len(fields["readings"]); got != len(ReadingsFields) {
+		t.Errorf("incorrect number of readings fields: got %d want %d", got, len(ReadingsFields))
+	}
+	if got := len(fields["diagnostics"]); got != len(DiagnosticsFields) {
+		t.Errorf("incorrect number of diagnostics fields: got %d want %d", got, len(DiagnosticsFields))
+	}
+}
+
+func TestDeviceTickAll(t *testing.T) {
+	d := NewDevice(0, time.Now())
+	d.offlineIntervals = 2
+	d.TickAll(time.Second)
+	if !d.Offline() {
+		t.Errorf("device should still be offline")
+	}
+	d.TickAll(time.Second)
+	if d.Offline() {
+		t.Errorf("device should be back online")
+	}
+}
+
+func TestDiagnosticsStatusIsInt(t *testing.T) {
+	m := NewDiagnosticsMeasurement(time.Now())
+	p := serialize.NewPoint()
+	m.ToPoint(p)
+	var buf bytes.Buffer
+	if err := (&serialize.InfluxSerializer{}).Serialize(p, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("status=0i")) {
+		t.Errorf("status is not serialized as an integer: %s", buf.String())
+	}
+}
+
+func TestTankDistribution(t *testing.T) {
+	d := &tankDistribution{Step: &common.ConstantDistribution{State: -3}, Refill: 10, State: 15}
+	d.Advance()
+	if got := d.Get(); got != 12 {
+		t.Errorf("incorrect fuel after advancing: got %v want %v", got, 12)
+	}
+	d.Advance()
+	if got := d.Get(); got != 100 {
+		t.Errorf("tank not refilled: got %v want %v", got, 100)
+	}
+}
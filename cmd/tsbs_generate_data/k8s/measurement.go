@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+var (
+	labelContainerCPU     = []byte("container_cpu")
+	labelContainerMemory  = []byte("container_memory")
+	labelContainerNetwork = []byte("container_network")
+	labelKubePodContainer = []byte("kube_pod_container")
+
+	// ContainerCPUFields are the cAdvisor counters of the CPU time used by
+	// containers, in seconds
+	ContainerCPUFields = [][]byte{
+		[]byte("usage_seconds_total"),
+		[]byte("user_seconds_total"),
+		[]byte("system_seconds_total"),
+		[]byte("cfs_throttled_seconds_total"),
+	}
+	// ContainerMemoryFields are the cAdvisor gauges of the memory used by
+	// containers, in bytes
+	ContainerMemoryFields = [][]byte{
+		[]byte("usage_bytes"),
+		[]byte("working_set_bytes"),
+		[]byte("rss"),
+		[]byte("cache"),
+	}
+	// ContainerNetworkFields are the cAdvisor counters of the network traffic
+	// of pods
+	ContainerNetworkFields = [][]byte{
+		[]byte("receive_bytes_total"),
+		[]byte("transmit_bytes_total"),
+		[]byte("receive_packets_total"),
+		[]byte("transmit_packets_total"),
+	}
+	// KubePodContainerFields are the kube-state-metrics series of containers
+	KubePodContainerFields = [][]byte{
+		[]byte("status_restarts_total"),
+		[]byte("status_ready"),
+		[]byte("resource_requests_cpu_cores"),
+		[]byte("resource_limits_cpu_cores"),
+		[]byte("resource_requests_memory_bytes"),
+		[]byte("resource_limits_memory_bytes"),
+	}
+)
+
+// measurementCount is the number of measurements of every pod
+const measurementCount = 4
+
+// toPoint fills point with the i-th measurement of the pod at its current
+// time
+func (p *Pod) toPoint(i int, point *serialize.Point) {
+	point.SetTimestamp(&p.timestamp)
+	switch i {
+	case 0:
+		point.SetMeasurementName(labelContainerCPU)
+		point.AppendField(ContainerCPUFields[0], p.cpuSeconds)
+		point.AppendField(ContainerCPUFields[1], 0.8*p.cpuSeconds)
+		point.AppendField(ContainerCPUFields[2], 0.2*p.cpuSeconds)
+		point.AppendField(ContainerCPUFields[3], p.throttledSeconds)
+	case 1:
+		workingSet, cache := p.memory.Get(), p.cache.Get()
+		point.SetMeasurementName(labelContainerMemory)
+		point.AppendField(ContainerMemoryFields[0], int64(workingSet+cache))
+		point.AppendField(ContainerMemoryFields[1], int64(workingSet))
+		point.AppendField(ContainerMemoryFields[2], int64(0.85*workingSet))
+		point.AppendField(ContainerMemoryFields[3], int64(cache))
+	case 2:
+		point.SetMeasurementName(labelContainerNetwork)
+		point.AppendField(ContainerNetworkFields[0], int64(p.rxBytes))
+		point.AppendField(ContainerNetworkFields[1], int64(p.txBytes))
+		point.AppendField(ContainerNetworkFields[2], int64(p.rxPackets))
+		point.AppendField(ContainerNetworkFields[3], int64(p.txPackets))
+	case 3:
+		ready := int64(0)
+		if p.Ready() {
+			ready = 1
+		}
+		point.SetMeasurementName(labelKubePodContainer)
+		point.AppendField(KubePodContainerFields[0], int64(p.restarts))
+		point.AppendField(KubePodContainerFields[1], ready)
+		point.AppendField(KubePodContainerFields[2], p.cpuRequest)
+		point.AppendField(KubePodContainerFields[3], p.cpuLimit)
+		point.AppendField(KubePodContainerFields[4], int64(p.memRequest))
+		point.AppendField(KubePodContainerFields[5], int64(p.memLimit))
+	}
+}
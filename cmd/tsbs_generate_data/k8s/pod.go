@@ -0,0 +1,277 @@
+package k8s
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+)
+
+const (
+	// DefaultPodsPerNode is the default number of pods scheduled on a node
+	DefaultPodsPerNode = 10
+	// NodesPerCluster is the number of nodes of every cluster
+	NodesPerCluster = 50
+
+	// restartProbability is the chance of a container crashing and being
+	// restarted at each interval
+	restartProbability = 0.0005
+	// crashLoopFraction is the fraction of pods whose container keeps
+	// crashing, with crashLoopProbability at each interval
+	crashLoopFraction    = 0.02
+	crashLoopProbability = 0.05
+	// unreadyIntervals is the number of intervals a restarted container
+	// takes to become ready again
+	unreadyIntervals = 3
+	// rolloutProbability is the chance of a replaced pod running a new
+	// version of its deployment's image
+	rolloutProbability = 0.3
+	// packetSize is the mean size of network packets, in bytes
+	packetSize = 800
+
+	mebibyte = 1 << 20
+)
+
+// Namespace is a namespace of every simulated cluster, with the deployments
+// running in it
+type Namespace struct {
+	Name        string
+	Team        string
+	Tier        string
+	Deployments []string
+}
+
+var (
+	// Namespaces are the namespaces of every simulated cluster
+	Namespaces = []Namespace{
+		{"kube-system", "platform", "system", []string{"coredns", "kube-proxy", "metrics-server"}},
+		{"monitoring", "observability", "system", []string{"prometheus", "grafana", "alertmanager"}},
+		{"ingress", "platform", "edge", []string{"ingress-nginx"}},
+		{"payments", "payments", "backend", []string{"payments-api", "payments-worker", "ledger"}},
+		{"checkout", "checkout", "frontend", []string{"storefront", "cart", "checkout-api"}},
+		{"search", "search", "backend", []string{"indexer", "search-api"}},
+		{"identity", "identity", "backend", []string{"auth", "users"}},
+		{"data", "data", "data", []string{"kafka", "redis", "postgres"}},
+	}
+
+	zoneChoices         = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+	instanceTypeChoices = []string{"m5.xlarge", "m5.2xlarge", "c5.2xlarge", "r5.xlarge"}
+	cpuRequestChoices   = []float64{0.1, 0.25, 0.5, 1, 2}
+	memRequestChoices   = []float64{128 * mebibyte, 256 * mebibyte, 512 * mebibyte, 1024 * mebibyte, 2048 * mebibyte}
+
+	// PodTagKeys are the labels of every pod, as exported by cAdvisor and
+	// kube-state-metrics once joined with the labels of their nodes
+	PodTagKeys = [][]byte{
+		[]byte("cluster"),
+		[]byte("zone"),
+		[]byte("node"),
+		[]byte("instance_type"),
+		[]byte("namespace"),
+		[]byte("team"),
+		[]byte("deployment"),
+		[]byte("tier"),
+		[]byte("pod"),
+		[]byte("pod_template_hash"),
+		[]byte("container"),
+		[]byte("image"),
+		[]byte("qos_class"),
+		[]byte("uid"),
+	}
+)
+
+// Node is a node of a cluster, running a fixed number of pods
+type Node struct {
+	Cluster, Name, Zone, InstanceType []byte
+	Pods                              []Pod
+}
+
+// NewNode creates the i-th node of the simulated clusters, with pods pods
+// running on it since before start
+func NewNode(i, pods int, start time.Time, podLifetime time.Duration) *Node {
+	n := &Node{
+		Cluster:      []byte(fmt.Sprintf("cluster-%d", i/NodesPerCluster)),
+		Name:         []byte(fmt.Sprintf("node-%d", i)),
+		Zone:         []byte(zoneChoices[rand.Intn(len(zoneChoices))]),
+		InstanceType: []byte(instanceTypeChoices[rand.Intn(len(instanceTypeChoices))]),
+		Pods:         make([]Pod, pods),
+	}
+	for j := range n.Pods {
+		ns := &Namespaces[rand.Intn(len(Namespaces))]
+		n.Pods[j] = Pod{
+			node:         n,
+			namespace:    ns,
+			deployment:   ns.Deployments[rand.Intn(len(ns.Deployments))],
+			version:      1 + rand.Intn(9),
+			lifetime:     podLifetime,
+			crashLooping: rand.Float64() < crashLoopFraction,
+		}
+		n.Pods[j].schedule(start)
+	}
+	return n
+}
+
+// Pod models a pod running a single container. When its lifetime is over,
+// it is deleted and replaced by a new pod of the same deployment on the same
+// node, with a new name.
+type Pod struct {
+	// These are assigned when the pod is scheduled:
+	Cluster, Zone, Node, InstanceType        []byte
+	Namespace, Team, Deployment, Tier        []byte
+	Name, TemplateHash, Image, QoSClass, UID []byte
+
+	node       *Node
+	namespace  *Namespace
+	deployment string
+	version    int
+	// lifetime is the mean lifetime of pods, or 0 if they are never replaced
+	lifetime time.Duration
+	expires  time.Time
+
+	crashLooping bool
+
+	timestamp time.Time
+
+	cpuRequest, cpuLimit float64 // cores, 0 if not set
+	memRequest, memLimit float64 // bytes, 0 if not set
+
+	cpu    common.Distribution // cores in use
+	memory common.Distribution // working set, in bytes
+	cache  common.Distribution // page cache, in bytes
+	rx, tx common.Distribution // network traffic, in bytes per second
+
+	cpuSeconds, throttledSeconds float64
+	rxBytes, txBytes             float64
+	rxPackets, txPackets         float64
+
+	restarts uint64
+	// unready is the number of intervals before the container is ready
+	unready int
+}
+
+// schedule replaces the pod by a new one started at t
+func (p *Pod) schedule(t time.Time) {
+	if p.Name != nil && rand.Float64() < rolloutProbability {
+		p.version++
+	}
+	n := p.node
+	p.Cluster, p.Zone, p.Node, p.InstanceType = n.Cluster, n.Zone, n.Name, n.InstanceType
+	p.Namespace = []byte(p.namespace.Name)
+	p.Team = []byte(p.namespace.Team)
+	p.Tier = []byte(p.namespace.Tier)
+	p.Deployment = []byte(p.deployment)
+	p.Image = []byte(fmt.Sprintf("registry.local/%s:1.%d.0", p.deployment, p.version))
+	p.TemplateHash = []byte(templateHash(p.deployment, p.version))
+	p.Name = []byte(fmt.Sprintf("%s-%s-%s", p.deployment, p.TemplateHash, randString(5)))
+	p.UID = []byte(fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", rand.Uint32(), rand.Intn(1<<16), rand.Intn(1<<16), rand.Intn(1<<16), rand.Int63n(1<<48)))
+
+	switch r := rand.Float64(); {
+	case r < 0.1:
+		p.QoSClass = []byte("BestEffort")
+		p.cpuRequest, p.cpuLimit, p.memRequest, p.memLimit = 0, 0, 0, 0
+	case r < 0.3:
+		p.QoSClass = []byte("Guaranteed")
+		p.cpuRequest = cpuRequestChoices[rand.Intn(len(cpuRequestChoices))]
+		p.memRequest = memRequestChoices[rand.Intn(len(memRequestChoices))]
+		p.cpuLimit, p.memLimit = p.cpuRequest, p.memRequest
+	default:
+		p.QoSClass = []byte("Burstable")
+		p.cpuRequest = cpuRequestChoices[rand.Intn(len(cpuRequestChoices))]
+		p.memRequest = memRequestChoices[rand.Intn(len(memRequestChoices))]
+		p.cpuLimit, p.memLimit = 2*p.cpuRequest, 2*p.memRequest
+	}
+
+	p.timestamp = t
+	p.expires = time.Time{}
+	if p.lifetime > 0 {
+		p.expires = t.Add(time.Duration(rand.ExpFloat64() * float64(p.lifetime)))
+	}
+
+	// pod-level counters, unlike container-level ones, survive restarts
+	traffic := 1000 + rand.Float64()*100000
+	p.rx = common.CWD(common.ND(0, traffic/20), 0, 10*traffic, traffic)
+	p.tx = common.CWD(common.ND(0, traffic/20), 0, 10*traffic, traffic/2)
+	p.rxBytes, p.txBytes, p.rxPackets, p.txPackets = 0, 0, 0, 0
+	p.restarts = 0
+	p.startContainer()
+}
+
+// startContainer (re)starts the container of the pod, whose counters start
+// from zero
+func (p *Pod) startContainer() {
+	cpu, maxCPU := p.cpuRequest, 1.2*p.cpuLimit
+	if cpu == 0 {
+		cpu, maxCPU = 0.1, 1
+	}
+	mem, maxMem := p.memRequest, p.memLimit
+	if mem == 0 {
+		mem, maxMem = 256*mebibyte, 1024*mebibyte
+	}
+	p.cpu = common.CWD(common.ND(0, cpu/20), 0, maxCPU, cpu*rand.Float64())
+	p.memory = common.CWD(common.ND(0, mem/200), mem/10, maxMem, mem/2)
+	p.cache = common.CWD(common.ND(0, mem/100), 0, mem, mem/10)
+	p.cpuSeconds, p.throttledSeconds = 0, 0
+	p.unready = unreadyIntervals
+}
+
+// Tick advances the pod by d, restarting its container if it crashed or ran
+// out of memory, and replacing the pod once its lifetime is over.
+func (p *Pod) Tick(d time.Duration) {
+	p.timestamp = p.timestamp.Add(d)
+	if !p.expires.IsZero() && !p.timestamp.Before(p.expires) {
+		p.schedule(p.timestamp)
+		return
+	}
+
+	for _, dist := range []common.Distribution{p.cpu, p.memory, p.cache, p.rx, p.tx} {
+		dist.Advance()
+	}
+	secs := d.Seconds()
+	cores := p.cpu.Get()
+	if p.cpuLimit > 0 && cores > p.cpuLimit {
+		p.throttledSeconds += (cores - p.cpuLimit) * secs
+		cores = p.cpuLimit
+	}
+	p.cpuSeconds += cores * secs
+	rx, tx := p.rx.Get()*secs, p.tx.Get()*secs
+	p.rxBytes += rx
+	p.txBytes += tx
+	p.rxPackets += rx / packetSize
+	p.txPackets += tx / packetSize
+
+	if p.unready > 0 {
+		p.unready--
+	}
+	crashProbability := restartProbability
+	if p.crashLooping {
+		crashProbability = crashLoopProbability
+	}
+	oom := p.memLimit > 0 && p.memory.Get() >= p.memLimit
+	if oom || rand.Float64() < crashProbability {
+		p.restarts++
+		p.startContainer()
+	}
+}
+
+// Ready tells whether the container of the pod is ready
+func (p *Pod) Ready() bool {
+	return p.unready == 0
+}
+
+// templateHash returns the pod-template-hash of a version of a deployment
+func templateHash(deployment string, version int) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", deployment, version)
+	return fmt.Sprintf("%08x", h.Sum32())[:8]
+}
+
+// randString returns a random suffix of n characters, as given to pods
+func randString(n int) string {
+	const alphabet = "bcdfghjklmnpqrstvwxz2456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(b)
+}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// Simulator generates the container metrics of the pods of Kubernetes
+// clusters, with pods being replaced over time and their containers
+// restarting. It fulfills the Simulator interface.
+type Simulator struct {
+	madePoints uint64
+	maxPoints  uint64
+
+	podIndex         uint64
+	measurementIndex int
+	pods             []*Pod
+
+	epoch       uint64
+	epochs      uint64
+	epochPods   uint64
+	initPods    uint64
+	podsPerNode uint64
+
+	interval time.Duration
+}
+
+// Finished tells whether we have simulated all the necessary points
+func (s *Simulator) Finished() bool {
+	return s.madePoints >= s.maxPoints
+}
+
+// Fields returns a map of measurements to the fields they hold
+func (s *Simulator) Fields() map[string][][]byte {
+	data := make(map[string][][]byte)
+	for i := 0; i < measurementCount; i++ {
+		point := serialize.NewPoint()
+		s.pods[0].toPoint(i, point)
+		data[string(point.MeasurementName())] = point.FieldKeys()
+	}
+	return data
+}
+
+// TagKeys returns the keys of the tags of every pod
+func (s *Simulator) TagKeys() [][]byte {
+	return PodTagKeys
+}
+
+// Next advances a Point to the next state in the generator. It returns
+// false for points of pods of nodes not reporting yet, which should not be
+// written.
+func (s *Simulator) Next(p *serialize.Point) bool {
+	// switch to the next measurement if needed
+	if s.podIndex == uint64(len(s.pods)) {
+		s.podIndex = 0
+		s.measurementIndex++
+	}
+
+	if s.measurementIndex == measurementCount {
+		s.measurementIndex = 0
+
+		for _, pod := range s.pods {
+			pod.Tick(s.interval)
+		}
+
+		s.adjustNumPodsForEpoch()
+	}
+
+	pod := s.pods[s.podIndex]
+
+	// Populate pod-specific tags:
+	p.AppendTag(PodTagKeys[0], pod.Cluster)
+	p.AppendTag(PodTagKeys[1], pod.Zone)
+	p.AppendTag(PodTagKeys[2], pod.Node)
+	p.AppendTag(PodTagKeys[3], pod.InstanceType)
+	p.AppendTag(PodTagKeys[4], pod.Namespace)
+	p.AppendTag(PodTagKeys[5], pod.Team)
+	p.AppendTag(PodTagKeys[6], pod.Deployment)
+	p.AppendTag(PodTagKeys[7], pod.Tier)
+	p.AppendTag(PodTagKeys[8], pod.Name)
+	p.AppendTag(PodTagKeys[9], pod.TemplateHash)
+	p.AppendTag(PodTagKeys[10], pod.Deployment)
+	p.AppendTag(PodTagKeys[11], pod.Image)
+	p.AppendTag(PodTagKeys[12], pod.QoSClass)
+	p.AppendTag(PodTagKeys[13], pod.UID)
+
+	// Populate measurement-specific fields:
+	pod.toPoint(s.measurementIndex, p)
+
+	ret := s.podIndex < s.epochPods
+	s.madePoints++
+	s.podIndex++
+	return ret
+}
+
+// adjustNumPodsForEpoch scales up the number of reporting nodes from the
+// initial count to the total count over the epochs, the same way devops does
+// for hosts
+func (s *Simulator) adjustNumPodsForEpoch() {
+	s.epoch++
+	if s.epochs < 2 {
+		return
+	}
+	missingScale := float64(uint64(len(s.pods))/s.podsPerNode - s.initPods/s.podsPerNode)
+	nodes := s.initPods/s.podsPerNode + uint64(missingScale*float64(s.epoch)/float64(s.epochs-1))
+	s.epochPods = nodes * s.podsPerNode
+}
+
+// SimulatorConfig is used to create a Kubernetes Simulator.
+type SimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	// InitNodeCount is the number of nodes reporting in the first reporting period
+	InitNodeCount uint64
+	// NodeCount is the total number of nodes reporting in the last reporting period
+	NodeCount uint64
+	// PodsPerNode is the number of pods running on every node
+	PodsPerNode uint64
+	// PodLifetime is the mean lifetime of pods, after which they are
+	// replaced by new pods. 0 never replaces pods.
+	PodLifetime time.Duration
+}
+
+// ToSimulator produces a Simulator that conforms to the given SimulatorConfig over the specified interval
+func (c *SimulatorConfig) ToSimulator(interval time.Duration) common.Simulator {
+	podsPerNode := c.PodsPerNode
+	if podsPerNode == 0 {
+		podsPerNode = DefaultPodsPerNode
+	}
+	pods := make([]*Pod, 0, c.NodeCount*podsPerNode)
+	for i := 0; i < int(c.NodeCount); i++ {
+		n := NewNode(i, int(podsPerNode), c.Start, c.PodLifetime)
+		for j := range n.Pods {
+			pods = append(pods, &n.Pods[j])
+		}
+	}
+
+	epochs := uint64(c.End.Sub(c.Start).Nanoseconds() / interval.Nanoseconds())
+	maxPoints := epochs * uint64(len(pods)) * measurementCount
+	return &Simulator{
+		madePoints: 0,
+		maxPoints:  maxPoints,
+
+		podIndex: 0,
+		pods:     pods,
+
+		epoch:       0,
+		epochs:      epochs,
+		epochPods:   c.InitNodeCount * podsPerNode,
+		initPods:    c.InitNodeCount * podsPerNode,
+		podsPerNode: podsPerNode,
+		interval:    interval,
+	}
+}
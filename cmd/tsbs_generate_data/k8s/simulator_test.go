@@ -0,0 +1,138 @@
+package k8s
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func testConfig(initNodes, nodes uint64, podLifetime time.Duration) *SimulatorConfig {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &SimulatorConfig{
+		Start:         start,
+		End:           start.Add(time.Hour),
+		InitNodeCount: initNodes,
+		NodeCount:     nodes,
+		PodsPerNode:   4,
+		PodLifetime:   podLifetime,
+	}
+}
+
+func TestSimulatorNext(t *testing.T) {
+	const nodes = 3
+	sim := testConfig(nodes, nodes, 0).ToSimulator(time.Minute)
+
+	made, written := 0, 0
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if sim.Next(p) {
+			written++
+		}
+		if got := len(p.TagKeys()); got != len(PodTagKeys) {
+			t.Fatalf("incorrect number of tags: got %d want %d", got, len(PodTagKeys))
+		}
+		p.Reset()
+		made++
+	}
+	if want := 60 * nodes * 4 * measurementCount; made != want || written != want {
+		t.Errorf("incorrect number of points: got %d made and %d written, want %d", made, written, want)
+	}
+}
+
+func TestSimulatorScaleUp(t *testing.T) {
+	sim := testConfig(1, 3, 0).ToSimulator(time.Minute)
+
+	written := 0
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if sim.Next(p) {
+			written++
+		}
+		p.Reset()
+	}
+	if min, max := 60*4*measurementCount, 60*3*4*measurementCount; written <= min || written >= max {
+		t.Errorf("incorrect number of points written: got %d, want between %d and %d", written, min, max)
+	}
+}
+
+func TestSimulatorPodChurn(t *testing.T) {
+	sim := testConfig(1, 1, 10*time.Minute).ToSimulator(time.Minute)
+
+	pods := map[string]bool{}
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		sim.Next(p)
+		pods[string(p.TagValues()[8])] = true
+		p.Reset()
+	}
+	// 4 pods living 10 minutes on average are replaced about 24 times in an hour
+	if len(pods) <= 4 {
+		t.Errorf("pods were not replaced: got %d pods", len(pods))
+	}
+
+	sim = testConfig(1, 1, 0).ToSimulator(time.Minute)
+	pods = map[string]bool{}
+	for !sim.Finished() {
+		sim.Next(p)
+		pods[string(p.TagValues()[8])] = true
+		p.Reset()
+	}
+	if len(pods) != 4 {
+		t.Errorf("pods should never be replaced: got %d pods want %d", len(pods), 4)
+	}
+}
+
+func TestSimulatorFields(t *testing.T) {
+	fields := testConfig(1, 1, 0).ToSimulator(time.Minute).Fields()
+	want := map[string][][]byte{
+		"container_cpu":      ContainerCPUFields,
+		"container_memory":   ContainerMemoryFields,
+		"container_network":  ContainerNetworkFields,
+		"kube_pod_container": KubePodContainerFields,
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("incorrect number of measurements: got %d want %d", len(fields), len(want))
+	}
+	for name, keys := range want {
+		if got := len(fields[name]); got != len(keys) {
+			t.Errorf("incorrect number of %s fields: got %d want %d", name, got, len(keys))
+		}
+	}
+}
+
+func TestPodName(t *testing.T) {
+	n := NewNode(51, 1, time.Now(), 0)
+	if got := string(n.Cluster); got != "cluster-1" {
+		t.Errorf("incorrect cluster: got %s want %s", got, "cluster-1")
+	}
+	pod := &n.Pods[0]
+	prefix := append(append(append([]byte{}, pod.Deployment...), '-'), pod.TemplateHash...)
+	if !bytes.HasPrefix(pod.Name, prefix) || len(pod.Name) != len(prefix)+6 {
+		t.Errorf("incorrect pod name %s for deployment %s", pod.Name, pod.Deployment)
+	}
+}
+
+func TestPodRestart(t *testing.T) {
+	n := NewNode(0, 1, time.Now(), 0)
+	pod := &n.Pods[0]
+	pod.crashLooping = true
+	for i := 0; i < 1000 && pod.restarts == 0; i++ {
+		pod.Tick(time.Minute)
+	}
+	if pod.restarts == 0 {
+		t.Fatalf("crashlooping container never restarted")
+	}
+	if pod.Ready() || pod.cpuSeconds != 0 {
+		t.Errorf("restarted container should be unready with its counters reset")
+	}
+	pod.crashLooping = false
+	restarts := pod.restarts
+	for i := 0; i < unreadyIntervals; i++ {
+		pod.Tick(time.Minute)
+	}
+	if !pod.Ready() && pod.restarts == restarts {
+		t.Errorf("container should be ready %d intervals after restarting", unreadyIntervals)
+	}
+}
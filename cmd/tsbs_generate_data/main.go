@@ -10,12 +10,18 @@
 // devops: scale-var is the number of hosts to simulate, with log messages
 //         every log-interval seconds.
 // cpu-only: same as `devops` but only generate metrics for CPU
+// iot: scale-var is the number of vehicles to simulate, which report their
+//      position and diagnostics and go offline from time to time.
+//
+// Custom use cases can be described in a YAML or JSON scenario file instead
+// (-scenario), in which case scale-var is the number of series to simulate.
 package main
 
 import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"os"
@@ -27,35 +33,46 @@ import (
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/iot"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/k8s"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/scenario"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
 )
 
 const (
 	// Output data format choices (alphabetical order)
 	formatCassandra   = "cassandra"
+	formatClickHouse  = "clickhouse"
+	formatElastic     = "elasticsearch"
+	formatGraphite    = "graphite"
 	formatInflux      = "influx"
 	formatMongo       = "mongo"
+	formatOpenTSDB    = "opentsdb"
 	formatTimescaleDB = "timescaledb"
-	formatPrometheus = "prometheus"
+	formatPrometheus  = "prometheus"
 
 	// Use case choices (make sure to update TestGetConfig if adding a new one)
 	useCaseCPUOnly   = "cpu-only"
 	useCaseCPUSingle = "cpu-single"
 	useCaseDevops    = "devops"
+	useCaseIoT       = "iot"
+	useCaseK8s       = "k8s"
 )
 
 // semi-constants
 var (
-	formatChoices = []string{formatCassandra, formatInflux, formatMongo, formatTimescaleDB, formatPrometheus}
+	formatChoices = []string{formatCassandra, formatClickHouse, formatElastic, formatGraphite, formatInflux, formatMongo, formatOpenTSDB, formatTimescaleDB, formatPrometheus}
 	// allows for testing
 	fatal = log.Fatalf
 )
 
 // Program option vars:
 var (
-	format      string
-	useCase     string
-	profileFile string
+	format       string
+	useCase      string
+	scenarioFile string
+	profileFile  string
+	manifestFile string
 
 	initScaleVar uint64
 	scaleVar     uint64
@@ -68,19 +85,31 @@ var (
 	interleavedGenerationGroupID uint
 	interleavedGenerationGroups  uint
 
+	workers int
+
 	logInterval time.Duration
+
+	late         common.LateConfig
+	sparse       common.SparseConfig
+	tags         *devops.TagsConfig
+	realistic    bool
+	hostLifetime time.Duration
+	podLifetime  time.Duration
 )
 
 // Parse args:
 func init() {
 	var timestampStartStr string
 	var timestampEndStr string
+	var nullableFields string
+	var extraTags, uniqueTag string
 	flag.StringVar(&format, "format", "", fmt.Sprintf("Format to emit. (choices: %s)", strings.Join(formatChoices, ", ")))
 
-	flag.StringVar(&useCase, "use-case", "", "Use case to model. (choices: devops, cpu-only)")
+	flag.StringVar(&useCase, "use-case", "", "Use case to model. (choices: devops, cpu-only, cpu-single, iot, k8s)")
+	flag.StringVar(&scenarioFile, "scenario", "", "YAML or JSON file describing a custom use case to model instead of -use-case.")
 
-	flag.Uint64Var(&initScaleVar, "initial-scale-var", 0, "Initial scaling variable specific to the use case (e.g., devices in 'devops'). 0 means to use -scale-var value")
-	flag.Uint64Var(&scaleVar, "scale-var", 1, "Scaling variable specific to the use case (e.g., devices in 'devops').")
+	flag.Uint64Var(&initScaleVar, "initial-scale-var", 0, "Initial scaling variable specific to the use case (e.g., hosts in 'devops', vehicles in 'iot', nodes in 'k8s'). 0 means to use -scale-var value")
+	flag.Uint64Var(&scaleVar, "scale-var", 1, "Scaling variable specific to the use case (e.g., hosts in 'devops', vehicles in 'iot', nodes in 'k8s').")
 
 	flag.StringVar(&timestampStartStr, "timestamp-start", "2016-01-01T00:00:00Z", "Beginning timestamp (RFC3339).")
 	flag.StringVar(&timestampEndStr, "timestamp-end", "2016-01-02T06:00:00Z", "Ending timestamp (RFC3339).")
@@ -90,14 +119,36 @@ func init() {
 
 	flag.UintVar(&interleavedGenerationGroupID, "interleaved-generation-group-id", 0, "Group (0-indexed) to perform round-robin serialization within. Use this to scale up data generation to multiple processes.")
 	flag.UintVar(&interleavedGenerationGroups, "interleaved-generation-groups", 1, "The number of round-robin serialization groups. Use this to scale up data generation to multiple processes.")
+	flag.IntVar(&workers, "workers", 1, "Number of goroutines to serialize data with, among which the devops use cases also split the simulation of hosts.")
 	flag.StringVar(&profileFile, "profile-file", "", "File to which to write go profiling data")
+	flag.StringVar(&manifestFile, "manifest", "", "JSON file to which to write the manifest of the dataset, for loaders and query generation to check against. Empty means no manifest.")
 
 	flag.DurationVar(&logInterval, "log-interval", 10*time.Second, "Duration between host data points")
+
+	flag.Float64Var(&late.Fraction, "late-fraction", 0, "Fraction of points to deliver late, out of time order (0 to 1).")
+	flag.DurationVar(&late.Delay, "late-delay", time.Minute, "Maximum delay of late points with the uniform distribution, minimum delay with the pareto one.")
+	flag.StringVar(&late.Distribution, "late-distribution", common.LateDistributionUniform, fmt.Sprintf("Distribution of the delays of late points. (choices: %s, %s)", common.LateDistributionUniform, common.LateDistributionPareto))
+	flag.Float64Var(&late.BackfillFraction, "backfill-fraction", 0, "Fraction of -backfill-window windows whose points are held back and replayed after the rest of the data (0 to 1).")
+	flag.DurationVar(&late.BackfillWindow, "backfill-window", time.Hour, "Size of the windows considered for backfill.")
+
+	flag.Float64Var(&sparse.FieldFraction, "missing-field-fraction", 0, "Fraction of field values to leave missing (0 to 1).")
+	flag.StringVar(&nullableFields, "nullable-fields", "", "Comma-separated fields whose values can be missing. Empty means all fields.")
+	flag.Float64Var(&sparse.SilenceProbability, "silence-probability", 0, "Probability for a host or device to go silent at each reading interval (0 to 1).")
+	flag.DurationVar(&sparse.SilenceDuration, "silence-duration", 10*time.Minute, "Mean duration of the silences of hosts or devices.")
+
+	flag.StringVar(&extraTags, "extra-tags", "", "Devops only: comma-separated tags to add to every point, as key:cardinality[:distribution] with distribution uniform (default) or zipf, e.g. pod:1000:zipf,zone:10.")
+	flag.StringVar(&uniqueTag, "unique-tag", "", "Devops only: key of a tag whose value is unique to every point, e.g. request_id.")
+	flag.BoolVar(&realistic, "realistic-values", false, "Devops only: make values follow daily and weekly cycles, with spikes, level shifts, bursts and counter resets, instead of pure random walks.")
+	flag.DurationVar(&hostLifetime, "host-lifetime", 0, "Devops only: lifetime of hosts, after which they are decommissioned and replaced by hosts with new hostnames. 0 keeps the same hosts.")
+	flag.DurationVar(&podLifetime, "pod-lifetime", time.Hour, "K8s only: mean lifetime of pods, after which they are replaced by pods with new names. 0 keeps the same pods.")
 	flag.Parse()
 
 	if !(interleavedGenerationGroupID < interleavedGenerationGroups) {
 		log.Fatal("incorrect interleaved groups configuration")
 	}
+	if workers < 1 {
+		log.Fatal("workers must be at least 1")
+	}
 
 	if initScaleVar == 0 {
 		initScaleVar = scaleVar
@@ -108,6 +159,40 @@ func init() {
 		seed = int64(time.Now().Nanosecond())
 	}
 	fmt.Fprintf(os.Stderr, "using random seed %d\n", seed)
+	late.Seed = seed
+	if err := late.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if len(nullableFields) > 0 {
+		sparse.NullableFields = strings.Split(nullableFields, ",")
+	}
+	sparse.Seed = seed
+	if err := sparse.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(extraTags) > 0 || len(uniqueTag) > 0 {
+		if !isDevops(useCase) || len(scenarioFile) > 0 {
+			log.Fatal("extra tags are only supported by the devops use cases")
+		}
+		extra, err := devops.ParseExtraTags(extraTags)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tags = &devops.TagsConfig{Extra: extra, UniqueKey: []byte(uniqueTag), Seed: seed}
+	}
+	if realistic && (!isDevops(useCase) || len(scenarioFile) > 0) {
+		log.Fatal("realistic values are only supported by the devops use cases")
+	}
+	if hostLifetime < 0 {
+		log.Fatal("host lifetime cannot be negative")
+	}
+	if hostLifetime > 0 && (!isDevops(useCase) || len(scenarioFile) > 0) {
+		log.Fatal("host churn is only supported by the devops use cases")
+	}
+	if podLifetime < 0 {
+		log.Fatal("pod lifetime cannot be negative")
+	}
 
 	// Parse timestamps:
 	var err error
@@ -136,10 +221,63 @@ func main() {
 	out := bufio.NewWriterSize(os.Stdout, 4<<20)
 	defer out.Flush()
 
-	cfg := getConfig(useCase)
-	sim := cfg.ToSimulator(logInterval)
+	var cfg common.SimulatorConfig
+	if len(scenarioFile) > 0 {
+		cfg = getScenarioConfig(scenarioFile)
+	} else {
+		cfg = getConfig(useCase)
+	}
+	var sim common.Simulator
+	if sc, ok := cfg.(common.ShardableSimulatorConfig); ok && workers > 1 {
+		sim = common.NewShardedSimulator(sc.ToShards(logInterval, workers, seed))
+	} else {
+		sim = cfg.ToSimulator(logInterval)
+	}
+	if sparse.Enabled() {
+		sim = common.NewSparseSimulator(sim, &sparse)
+	}
+	if late.Enabled() {
+		sim = common.NewLateSimulator(sim, &late)
+	}
 	serializer := getSerializer(sim, format, out)
+	if len(manifestFile) > 0 {
+		interval := logInterval
+		if sc, ok := cfg.(*scenario.SimulatorConfig); ok && sc.Scenario.Interval > 0 {
+			interval = sc.Scenario.Interval
+		}
+		recorder = newManifestRecorder(sim, interval)
+	}
+
+	// serializers that flush keep state between points, so they cannot be
+	// shared between workers
+	flusher, ok := serializer.(serialize.Flusher)
+	if workers > 1 && !ok {
+		writeParallel(sim, serializer, out, workers)
+	} else {
+		write(sim, serializer, out)
+	}
+
+	if ok {
+		if err := flusher.Flush(); err != nil {
+			log.Fatalf("unable to flush serializer %q: %s", format, err)
+		}
+	}
+
+	err := out.Flush()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
+	if recorder != nil {
+		if err := recorder.write(manifestFile); err != nil {
+			log.Fatalf("unable to write manifest %s: %v", manifestFile, err)
+		}
+	}
+}
+
+// write serializes the points of sim that belong to the interleaved
+// generation group to out.
+func write(sim common.Simulator, serializer serialize.PointSerializer, out io.Writer) {
 	currentInterleavedGroup := uint(0)
 	point := serialize.NewPoint()
 	for !sim.Finished() {
@@ -155,6 +293,9 @@ func main() {
 			if err != nil {
 				log.Fatal(err)
 			}
+			if recorder != nil {
+				recorder.record(point)
+			}
 		}
 		point.Reset()
 
@@ -163,18 +304,6 @@ func main() {
 			currentInterleavedGroup = 0
 		}
 	}
-
-	flusher, ok := serializer.(serialize.Flusher)
-	if ok {
-		if err := flusher.Flush(); err != nil {
-			log.Fatalf("unable to flush serializer %q: %s", format, err)
-		}
-	}
-
-	err := out.Flush()
-	if err != nil {
-		log.Fatal(err.Error())
-	}
 }
 
 func validateFormat(format string) bool {
@@ -196,6 +325,9 @@ func getConfig(useCase string) common.SimulatorConfig {
 			InitHostCount:   initScaleVar,
 			HostCount:       scaleVar,
 			HostConstructor: devops.NewHost,
+			Tags:            tags,
+			Realistic:       realistic,
+			HostLifetime:    hostLifetime,
 		}
 	case useCaseCPUOnly:
 		return &devops.CPUOnlySimulatorConfig{
@@ -205,6 +337,9 @@ func getConfig(useCase string) common.SimulatorConfig {
 			InitHostCount:   initScaleVar,
 			HostCount:       scaleVar,
 			HostConstructor: devops.NewHostCPUOnly,
+			Tags:            tags,
+			Realistic:       realistic,
+			HostLifetime:    hostLifetime,
 		}
 	case useCaseCPUSingle:
 		return &devops.CPUOnlySimulatorConfig{
@@ -214,6 +349,28 @@ func getConfig(useCase string) common.SimulatorConfig {
 			InitHostCount:   initScaleVar,
 			HostCount:       scaleVar,
 			HostConstructor: devops.NewHostCPUSingle,
+			Tags:            tags,
+			Realistic:       realistic,
+			HostLifetime:    hostLifetime,
+		}
+	case useCaseIoT:
+		return &iot.SimulatorConfig{
+			Start: timestampStart,
+			End:   timestampEnd,
+
+			InitDeviceCount:   initScaleVar,
+			DeviceCount:       scaleVar,
+			DeviceConstructor: iot.NewDevice,
+		}
+	case useCaseK8s:
+		return &k8s.SimulatorConfig{
+			Start: timestampStart,
+			End:   timestampEnd,
+
+			InitNodeCount: initScaleVar,
+			NodeCount:     scaleVar,
+			PodsPerNode:   k8s.DefaultPodsPerNode,
+			PodLifetime:   podLifetime,
 		}
 	default:
 		fatal("unknown use case: '%s'", useCase)
@@ -221,41 +378,48 @@ func getConfig(useCase string) common.SimulatorConfig {
 	}
 }
 
+// isDevops tells whether useCase is one of the devops use cases
+func isDevops(useCase string) bool {
+	return useCase == useCaseDevops || useCase == useCaseCPUOnly || useCase == useCaseCPUSingle
+}
+
+func getScenarioConfig(path string) common.SimulatorConfig {
+	sc, err := scenario.Load(path)
+	if err != nil {
+		fatal("cannot load scenario %s: %v", path, err)
+		return nil
+	}
+	return &scenario.SimulatorConfig{
+		Start: timestampStart,
+		End:   timestampEnd,
+
+		SeriesCount: scaleVar,
+		Scenario:    sc,
+	}
+}
+
 func getSerializer(sim common.Simulator, format string, out *bufio.Writer) serialize.PointSerializer {
 	switch format {
 	case formatCassandra:
 		return &serialize.CassandraSerializer{}
+	case formatClickHouse:
+		writeTableHeader(sim, out)
+		return &serialize.ClickHouseSerializer{}
+	case formatElastic:
+		writeTableHeader(sim, out)
+		return &serialize.ElasticsearchSerializer{}
+	case formatGraphite:
+		return &serialize.GraphiteSerializer{}
 	case formatInflux:
 		return &serialize.InfluxSerializer{}
 	case formatMongo:
 		return &serialize.MongoSerializer{}
+	case formatOpenTSDB:
+		return &serialize.OpenTSDBSerializer{}
 	case formatPrometheus:
 		return &serialize.PrometheusSerializer{}
 	case formatTimescaleDB:
-		out.WriteString("tags")
-		for _, key := range devops.MachineTagKeys {
-			out.WriteString(",")
-			out.Write(key)
-		}
-		out.WriteString("\n")
-		// sort the keys so the header is deterministic
-		keys := make([]string, 0)
-		fields := sim.Fields()
-		for k := range fields {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, measurementName := range keys {
-			out.WriteString(measurementName)
-			for _, field := range fields[measurementName] {
-				out.WriteString(",")
-				out.Write(field)
-
-			}
-			out.WriteString("\n")
-		}
-		out.WriteString("\n")
-
+		writeTableHeader(sim, out)
 		return &serialize.TimescaleDBSerializer{}
 	default:
 		fatal("unknown format: '%s'", format)
@@ -263,6 +427,34 @@ func getSerializer(sim common.Simulator, format string, out *bufio.Writer) seria
 	}
 }
 
+// writeTableHeader writes the header of the formats loaded into tables: a
+// first line with the tag keys, then a line per measurement with its fields,
+// and a blank line
+func writeTableHeader(sim common.Simulator, out *bufio.Writer) {
+	out.WriteString("tags")
+	for _, key := range sim.TagKeys() {
+		out.WriteString(",")
+		out.Write(key)
+	}
+	out.WriteString("\n")
+	// sort the keys so the header is deterministic
+	keys := make([]string, 0)
+	fields := sim.Fields()
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, measurementName := range keys {
+		out.WriteString(measurementName)
+		for _, field := range fields[measurementName] {
+			out.WriteString(",")
+			out.Write(field)
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("\n")
+}
+
 // startMemoryProfile sets up memory profiling to be written to profileFile. It
 // returns a function to cleanup/write that should be deferred by the caller
 func startMemoryProfile(profileFile string) func() {
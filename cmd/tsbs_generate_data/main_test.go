@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/iot"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/k8s"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
 )
 
@@ -42,6 +44,20 @@ func TestGetConfig(t *testing.T) {
 		t.Errorf("use case '%s' does not run the right type: got %T", useCaseDevops, got)
 	}
 
+	cfg = getConfig(useCaseIoT)
+	switch got := cfg.(type) {
+	case *iot.SimulatorConfig:
+	default:
+		t.Errorf("use case '%s' does not run the right type: got %T", useCaseIoT, got)
+	}
+
+	cfg = getConfig(useCaseK8s)
+	switch got := cfg.(type) {
+	case *k8s.SimulatorConfig:
+	default:
+		t.Errorf("use case '%s' does not run the right type: got %T", useCaseK8s, got)
+	}
+
 	fatalCalled := false
 	fatal = func(f string, args ...interface{}) {
 		fatalCalled = true
@@ -69,6 +85,27 @@ func TestGetSerializer(t *testing.T) {
 		t.Errorf("format '%s' does not run the right serializer: got %T", formatCassandra, got)
 	}
 
+	s = getSerializer(sim, formatClickHouse, out)
+	switch got := s.(type) {
+	case *serialize.ClickHouseSerializer:
+	default:
+		t.Errorf("format '%s' does not run the right serializer: got %T", formatClickHouse, got)
+	}
+
+	s = getSerializer(sim, formatElastic, out)
+	switch got := s.(type) {
+	case *serialize.ElasticsearchSerializer:
+	default:
+		t.Errorf("format '%s' does not run the right serializer: got %T", formatElastic, got)
+	}
+
+	s = getSerializer(sim, formatGraphite, out)
+	switch got := s.(type) {
+	case *serialize.GraphiteSerializer:
+	default:
+		t.Errorf("format '%s' does not run the right serializer: got %T", formatGraphite, got)
+	}
+
 	s = getSerializer(sim, formatInflux, out)
 	switch got := s.(type) {
 	case *serialize.InfluxSerializer:
@@ -83,6 +120,13 @@ func TestGetSerializer(t *testing.T) {
 		t.Errorf("format '%s' does not run the right serializer: got %T", formatMongo, got)
 	}
 
+	s = getSerializer(sim, formatOpenTSDB, out)
+	switch got := s.(type) {
+	case *serialize.OpenTSDBSerializer:
+	default:
+		t.Errorf("format '%s' does not run the right serializer: got %T", formatOpenTSDB, got)
+	}
+
 	s = getSerializer(sim, formatTimescaleDB, out)
 	switch got := s.(type) {
 	case *serialize.TimescaleDBSerializer:
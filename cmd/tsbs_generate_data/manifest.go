@@ -0,0 +1,106 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+	"github.com/hagen1778/tsbs/manifest"
+)
+
+// recorder collects the manifest of the dataset, if -manifest is set
+var recorder *manifestRecorder
+
+// manifestRecorder collects the manifest of the points written
+type manifestRecorder struct {
+	m *manifest.Manifest
+	// tagValues are the distinct values of every tag
+	tagValues map[string]map[string]struct{}
+}
+
+// newManifestRecorder returns a manifestRecorder for the points of sim,
+// generated every interval
+func newManifestRecorder(sim common.Simulator, interval time.Duration) *manifestRecorder {
+	m := &manifest.Manifest{
+		Format:             format,
+		UseCase:            useCase,
+		Scenario:           scenarioFile,
+		InitialScale:       initScaleVar,
+		Scale:              scaleVar,
+		Seed:               seed,
+		Start:              timestampStart,
+		End:                timestampEnd,
+		Interval:           manifest.Duration(interval),
+		HostLifetime:       manifest.Duration(hostLifetime),
+		InterleavedGroupID: interleavedGenerationGroupID,
+		InterleavedGroups:  interleavedGenerationGroups,
+		Measurements:       map[string]*manifest.Measurement{},
+		TagCardinalities:   map[string]int{},
+		Hosts:              map[string]*manifest.TimeRange{},
+	}
+	if useCase == useCaseK8s {
+		m.PodLifetime = manifest.Duration(podLifetime)
+	}
+	if len(scenarioFile) > 0 {
+		m.UseCase = ""
+	}
+	for name, keys := range sim.Fields() {
+		fields := make([]string, len(keys))
+		for i, k := range keys {
+			fields[i] = string(k)
+		}
+		m.Measurements[name] = &manifest.Measurement{Fields: fields}
+	}
+	return &manifestRecorder{m: m, tagValues: map[string]map[string]struct{}{}}
+}
+
+// record adds p, once written, to the manifest
+func (r *manifestRecorder) record(p *serialize.Point) {
+	metrics := uint64(0)
+	for _, v := range p.FieldValues() {
+		if v != nil {
+			metrics++
+		}
+	}
+	r.m.Points++
+	r.m.Metrics += metrics
+	r.m.MissingValues += uint64(len(p.FieldValues())) - metrics
+	if metrics == 0 {
+		r.m.EmptyPoints++
+	}
+
+	name := string(p.MeasurementName())
+	mm, ok := r.m.Measurements[name]
+	if !ok {
+		mm = &manifest.Measurement{}
+		r.m.Measurements[name] = mm
+	}
+	mm.Points++
+	mm.Metrics += metrics
+
+	keys, values := p.TagKeys(), p.TagValues()
+	for i, k := range keys {
+		vs, ok := r.tagValues[string(k)]
+		if !ok {
+			vs = map[string]struct{}{}
+			r.tagValues[string(k)] = vs
+		}
+		vs[string(values[i])] = struct{}{}
+	}
+	if len(values) > 0 {
+		ts := *p.Timestamp()
+		if h, ok := r.m.Hosts[string(values[0])]; ok {
+			h.Add(ts)
+		} else {
+			r.m.Hosts[string(values[0])] = &manifest.TimeRange{First: ts, Last: ts}
+		}
+	}
+}
+
+// write writes the manifest of the points recorded to the file at path
+func (r *manifestRecorder) write(path string) error {
+	for k, vs := range r.tagValues {
+		r.m.TagCardinalities[k] = len(vs)
+	}
+	return r.m.Write(path)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+	"github.com/hagen1778/tsbs/manifest"
+)
+
+func TestManifestRecorder(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &devops.CPUOnlySimulatorConfig{
+		Start:           start,
+		End:             start.Add(time.Minute),
+		InitHostCount:   3,
+		HostCount:       3,
+		HostConstructor: devops.NewHostCPUOnly,
+	}
+	sim := cfg.ToSimulator(10 * time.Second)
+	r := newManifestRecorder(sim, 10*time.Second)
+
+	p := serialize.NewPoint()
+	for !sim.Finished() {
+		if sim.Next(p) {
+			// leave a value and then all of the values of a point missing
+			switch r.m.Points {
+			case 0:
+				p.SetFieldValue(0, nil)
+			case 1:
+				for i := range p.FieldValues() {
+					p.SetFieldValue(i, nil)
+				}
+			}
+			r.record(p)
+		}
+		p.Reset()
+	}
+
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.json")
+	if err := r.write(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := time.Duration(m.Interval); got != 10*time.Second {
+		t.Errorf("incorrect interval: got %v want %v", got, 10*time.Second)
+	}
+	if m.Points != 18 || m.EmptyPoints != 1 {
+		t.Errorf("incorrect points: got %d (%d empty) want 18 (1 empty)", m.Points, m.EmptyPoints)
+	}
+	if m.Metrics != 18*10-11 || m.MissingValues != 11 {
+		t.Errorf("incorrect metrics: got %d (%d missing) want %d (11 missing)", m.Metrics, m.MissingValues, 18*10-11)
+	}
+	cpu, ok := m.Measurements["cpu"]
+	if !ok {
+		t.Fatalf("no cpu measurement")
+	}
+	if len(cpu.Fields) != 10 || cpu.Points != 18 || cpu.Metrics != m.Metrics {
+		t.Errorf("incorrect cpu measurement: %+v", cpu)
+	}
+	if got := m.TagCardinalities["hostname"]; got != 3 {
+		t.Errorf("incorrect hostname cardinality: got %d want %d", got, 3)
+	}
+	if len(m.Hosts) != 3 {
+		t.Fatalf("incorrect number of hosts: got %d want %d", len(m.Hosts), 3)
+	}
+	h := m.Hosts["host_2"]
+	if h == nil || !h.First.Equal(start) || !h.Last.Equal(start.Add(50*time.Second)) {
+		t.Errorf("incorrect time range of host_2: %+v", h)
+	}
+}
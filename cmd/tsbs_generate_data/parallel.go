@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// pointBatchSize is the number of points serialized at once by a worker
+const pointBatchSize = 1024
+
+// pointBatch is a batch of points serialized by a worker into buf
+type pointBatch struct {
+	points []*serialize.Point
+	buf    bytes.Buffer
+	done   chan struct{}
+}
+
+// writeParallel does what write does, serializing batches of points with
+// workers goroutines. Batches are written to out in the order sim makes
+// their points, so the output is the same as write's.
+func writeParallel(sim common.Simulator, serializer serialize.PointSerializer, out io.Writer, workers int) {
+	free := make(chan *pointBatch, 2*workers)
+	for i := 0; i < cap(free); i++ {
+		b := &pointBatch{points: make([]*serialize.Point, pointBatchSize)}
+		for j := range b.points {
+			b.points[j] = serialize.NewPoint()
+		}
+		b.points = b.points[:0]
+		free <- b
+	}
+
+	todo := make(chan *pointBatch, cap(free))
+	for i := 0; i < workers; i++ {
+		go func() {
+			for b := range todo {
+				for _, p := range b.points {
+					if err := serializer.Serialize(p, &b.buf); err != nil {
+						log.Fatal(err)
+					}
+				}
+				close(b.done)
+			}
+		}()
+	}
+
+	ordered := make(chan *pointBatch, cap(free))
+	written := make(chan struct{})
+	go func() {
+		for b := range ordered {
+			<-b.done
+			if _, err := out.Write(b.buf.Bytes()); err != nil {
+				log.Fatal(err)
+			}
+			b.buf.Reset()
+			for _, p := range b.points {
+				p.Reset()
+			}
+			b.points = b.points[:0]
+			free <- b
+		}
+		close(written)
+	}()
+
+	send := func(b *pointBatch) {
+		b.done = make(chan struct{})
+		ordered <- b
+		todo <- b
+	}
+
+	currentInterleavedGroup := uint(0)
+	point := serialize.NewPoint()
+	var b *pointBatch
+	for !sim.Finished() {
+		write := sim.Next(point)
+		if !write {
+			point.Reset()
+			continue
+		}
+
+		// in the default case this is always true
+		if currentInterleavedGroup == interleavedGenerationGroupID {
+			if recorder != nil {
+				recorder.record(point)
+			}
+			if b == nil {
+				b = <-free
+			}
+			// copied, as sim can change the point once it is made
+			b.points = b.points[:len(b.points)+1]
+			b.points[len(b.points)-1].Copy(point)
+			if len(b.points) == cap(b.points) {
+				send(b)
+				b = nil
+			}
+		}
+		point.Reset()
+
+		currentInterleavedGroup++
+		if currentInterleavedGroup == interleavedGenerationGroups {
+			currentInterleavedGroup = 0
+		}
+	}
+	if b != nil {
+		send(b)
+	}
+	close(todo)
+	close(ordered)
+	<-written
+}
@@ -0,0 +1,287 @@
+// Package scenario compiles declarative descriptions of workloads, read from
+// YAML or JSON files, into simulators for tsbs_generate_data.
+package scenario
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"gopkg.in/yaml.v2"
+)
+
+// Distribution types of DistributionSpec, named after their constructors in
+// the common package
+const (
+	DistributionND       = "nd"
+	DistributionUD       = "ud"
+	DistributionWD       = "wd"
+	DistributionCWD      = "cwd"
+	DistributionMWD      = "mwd"
+	DistributionConstant = "constant"
+	DistributionPareto   = "pareto"
+	DistributionZipf     = "zipf"
+	DistributionSeasonal = "seasonal"
+	DistributionAnomaly  = "anomaly"
+	DistributionReset    = "reset"
+	DistributionClamp    = "clamp"
+)
+
+// Field types of FieldSpec
+const (
+	FieldTypeFloat = "float"
+	FieldTypeInt   = "int"
+)
+
+// Scenario describes a workload: a number of series, each with a set of tags,
+// reporting measurements at regular intervals.
+type Scenario struct {
+	// Interval between two readings. If zero, the interval given to the
+	// simulator (i.e. -log-interval) is used.
+	Interval time.Duration `yaml:"interval"`
+	// Tags of every series, in order. The first is usually the one
+	// identifying a series.
+	Tags []TagSpec `yaml:"tags"`
+	// Measurements reported by every series
+	Measurements []MeasurementSpec `yaml:"measurements"`
+}
+
+// TagSpec describes a tag of the series. Each series gets a value drawn once
+// among Values, or among Cardinality values of the form <key>_<n>. A tag with
+// neither identifies the series: the i-th one gets the value <key>_<i>.
+type TagSpec struct {
+	Key         string   `yaml:"key"`
+	Values      []string `yaml:"values"`
+	Cardinality int      `yaml:"cardinality"`
+}
+
+// MeasurementSpec describes a measurement reported by every series.
+type MeasurementSpec struct {
+	Name string `yaml:"name"`
+	// Interval between two readings of the measurement, a multiple of the
+	// Scenario's. If zero, the measurement is read at every interval.
+	Interval time.Duration `yaml:"interval"`
+	Fields   []FieldSpec   `yaml:"fields"`
+}
+
+// FieldSpec describes a field of a measurement and the distribution of its
+// values.
+type FieldSpec struct {
+	Name string `yaml:"name"`
+	// Type of the values, FieldTypeFloat (default) or FieldTypeInt
+	Type         string           `yaml:"type"`
+	Distribution DistributionSpec `yaml:"distribution"`
+}
+
+// DistributionSpec describes one of the distributions of the common package.
+// Which parameters are used depends on the Type:
+//
+// nd: Mean, StdDev
+// ud: Low, High
+// wd: Step, State
+// cwd: Step, Min, Max, State
+// mwd: Step, State
+// constant: State
+// pareto: Scale, Shape
+// zipf: S, V, Max
+// seasonal: Base, Daily, Weekly, Peak, Trend
+// anomaly: Base, SpikeProbability, SpikeSize, SpikeLength, ShiftProbability, ShiftSize
+// reset: Base, Probability
+// clamp: Base, Min, Max
+type DistributionSpec struct {
+	Type   string            `yaml:"type"`
+	Mean   float64           `yaml:"mean"`
+	StdDev float64           `yaml:"stddev"`
+	Low    float64           `yaml:"low"`
+	High   float64           `yaml:"high"`
+	Min    float64           `yaml:"min"`
+	Max    float64           `yaml:"max"`
+	State  float64           `yaml:"state"`
+	Step   *DistributionSpec `yaml:"step"`
+
+	Scale float64 `yaml:"scale"`
+	Shape float64 `yaml:"shape"`
+	S     float64 `yaml:"s"`
+	V     float64 `yaml:"v"`
+
+	// Base is the distribution modified by seasonal, anomaly, reset and
+	// clamp distributions
+	Base             *DistributionSpec `yaml:"base"`
+	Daily            float64           `yaml:"daily"`
+	Weekly           float64           `yaml:"weekly"`
+	Peak             time.Duration     `yaml:"peak"`
+	Trend            float64           `yaml:"trend"`
+	SpikeProbability float64           `yaml:"spike_probability"`
+	SpikeSize        float64           `yaml:"spike_size"`
+	SpikeLength      int               `yaml:"spike_length"`
+	ShiftProbability float64           `yaml:"shift_probability"`
+	ShiftSize        float64           `yaml:"shift_size"`
+	Probability      float64           `yaml:"probability"`
+}
+
+// Load reads and validates the Scenario in the YAML or JSON file at path.
+func Load(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates a Scenario from YAML or JSON data.
+func Parse(data []byte) (*Scenario, error) {
+	s := &Scenario{}
+	// JSON is a subset of YAML, so both are parsed the same way
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, fmt.Errorf("cannot parse scenario: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate returns an error if s cannot be simulated.
+func (s *Scenario) Validate() error {
+	if s.Interval < 0 {
+		return fmt.Errorf("negative interval: %v", s.Interval)
+	}
+	if len(s.Measurements) == 0 {
+		return fmt.Errorf("scenario has no measurements")
+	}
+
+	keys := map[string]bool{}
+	for _, t := range s.Tags {
+		if len(t.Key) == 0 {
+			return fmt.Errorf("tag has no key")
+		}
+		if keys[t.Key] {
+			return fmt.Errorf("duplicate tag '%s'", t.Key)
+		}
+		keys[t.Key] = true
+		if t.Cardinality < 0 {
+			return fmt.Errorf("tag '%s': negative cardinality %d", t.Key, t.Cardinality)
+		}
+		if t.Cardinality > 0 && len(t.Values) > 0 {
+			return fmt.Errorf("tag '%s': both values and cardinality are set", t.Key)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, m := range s.Measurements {
+		if len(m.Name) == 0 {
+			return fmt.Errorf("measurement has no name")
+		}
+		if names[m.Name] {
+			return fmt.Errorf("duplicate measurement '%s'", m.Name)
+		}
+		names[m.Name] = true
+		if m.Interval < 0 {
+			return fmt.Errorf("measurement '%s': negative interval %v", m.Name, m.Interval)
+		}
+		if len(m.Fields) == 0 {
+			return fmt.Errorf("measurement '%s' has no fields", m.Name)
+		}
+		for _, f := range m.Fields {
+			if len(f.Name) == 0 {
+				return fmt.Errorf("measurement '%s': field has no name", m.Name)
+			}
+			switch f.Type {
+			case "", FieldTypeFloat, FieldTypeInt:
+			default:
+				return fmt.Errorf("field '%s.%s': unknown type '%s'", m.Name, f.Name, f.Type)
+			}
+			if _, err := f.Distribution.Build(); err != nil {
+				return fmt.Errorf("field '%s.%s': %v", m.Name, f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Build returns a new Distribution as described by d.
+func (d *DistributionSpec) Build() (common.Distribution, error) {
+	var step common.Distribution
+	switch d.Type {
+	case DistributionWD, DistributionCWD, DistributionMWD:
+		if d.Step == nil {
+			return nil, fmt.Errorf("distribution '%s' needs a step", d.Type)
+		}
+		var err error
+		if step, err = d.Step.Build(); err != nil {
+			return nil, fmt.Errorf("step of '%s': %v", d.Type, err)
+		}
+	}
+
+	var base common.Distribution
+	switch d.Type {
+	case DistributionSeasonal, DistributionAnomaly, DistributionReset, DistributionClamp:
+		if d.Base == nil {
+			return nil, fmt.Errorf("distribution '%s' needs a base", d.Type)
+		}
+		var err error
+		if base, err = d.Base.Build(); err != nil {
+			return nil, fmt.Errorf("base of '%s': %v", d.Type, err)
+		}
+	}
+
+	switch d.Type {
+	case DistributionND:
+		return common.ND(d.Mean, d.StdDev), nil
+	case DistributionUD:
+		if d.High < d.Low {
+			return nil, fmt.Errorf("distribution 'ud' has high < low")
+		}
+		return common.UD(d.Low, d.High), nil
+	case DistributionWD:
+		return common.WD(step, d.State), nil
+	case DistributionCWD:
+		if d.Max < d.Min {
+			return nil, fmt.Errorf("distribution 'cwd' has max < min")
+		}
+		return common.CWD(step, d.Min, d.Max, d.State), nil
+	case DistributionMWD:
+		return common.MWD(step, d.State), nil
+	case DistributionConstant:
+		return &common.ConstantDistribution{State: d.State}, nil
+	case DistributionPareto:
+		if d.Scale <= 0 || d.Shape <= 0 {
+			return nil, fmt.Errorf("distribution 'pareto' needs a positive scale and shape")
+		}
+		return common.PD(d.Scale, d.Shape), nil
+	case DistributionZipf:
+		if d.S <= 1 || d.V < 1 || d.Max < 0 {
+			return nil, fmt.Errorf("distribution 'zipf' needs s > 1, v >= 1 and max >= 0")
+		}
+		return common.ZD(d.S, d.V, uint64(d.Max)), nil
+	case DistributionSeasonal:
+		sd := common.Seasonal(base, d.Daily, d.Weekly, d.Peak)
+		sd.Trend = d.Trend
+		return sd, nil
+	case DistributionAnomaly:
+		if !isProbability(d.SpikeProbability) || !isProbability(d.ShiftProbability) {
+			return nil, fmt.Errorf("distribution 'anomaly' has probabilities not between 0 and 1")
+		}
+		if d.SpikeLength < 0 {
+			return nil, fmt.Errorf("distribution 'anomaly' has a negative spike length")
+		}
+		return common.Anomalies(base, d.SpikeProbability, d.SpikeSize, d.SpikeLength, d.ShiftProbability, d.ShiftSize), nil
+	case DistributionReset:
+		if !isProbability(d.Probability) {
+			return nil, fmt.Errorf("distribution 'reset' has a probability not between 0 and 1")
+		}
+		return common.Resets(base, d.Probability), nil
+	case DistributionClamp:
+		if d.Max < d.Min {
+			return nil, fmt.Errorf("distribution 'clamp' has max < min")
+		}
+		return common.Clamp(base, d.Min, d.Max), nil
+	default:
+		return nil, fmt.Errorf("unknown distribution type '%s'", d.Type)
+	}
+}
+
+func isProbability(p float64) bool {
+	return p >= 0 && p <= 1
+}
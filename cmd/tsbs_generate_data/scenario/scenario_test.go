@@ -0,0 +1,168 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load("testdata/example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Interval != 10*time.Second {
+		t.Errorf("incorrect interval: got %v want %v", s.Interval, 10*time.Second)
+	}
+	if got := len(s.Tags); got != 3 {
+		t.Errorf("incorrect number of tags: got %d want %d", got, 3)
+	}
+	if got := len(s.Measurements); got != 2 {
+		t.Fatalf("incorrect number of measurements: got %d want %d", got, 2)
+	}
+	if got := s.Measurements[1].Interval; got != time.Minute {
+		t.Errorf("incorrect measurement interval: got %v want %v", got, time.Minute)
+	}
+	if got := s.Measurements[0].Fields[1].Distribution.Step.StdDev; got != 5 {
+		t.Errorf("incorrect step stddev: got %v want %v", got, 5)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	data := `{
+		"interval": "1m",
+		"tags": [{"key": "name"}],
+		"measurements": [
+			{"name": "m", "fields": [{"name": "f", "distribution": {"type": "ud", "low": 0, "high": 1}}]}
+		]
+	}`
+	s, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Interval != time.Minute {
+		t.Errorf("incorrect interval: got %v want %v", s.Interval, time.Minute)
+	}
+	if got := s.Measurements[0].Fields[0].Distribution.High; got != 1 {
+		t.Errorf("incorrect distribution high: got %v want %v", got, 1)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	field := `measurements: [{name: m, fields: [{name: f, distribution: %s}]}]`
+	cases := []struct {
+		desc string
+		data string
+		want string
+	}{
+		{
+			desc: "unknown key",
+			data: "foo: 1\n" + strings.Replace(field, "%s", "{type: nd}", 1),
+			want: "cannot parse scenario",
+		},
+		{
+			desc: "no measurements",
+			data: "interval: 10s",
+			want: "no measurements",
+		},
+		{
+			desc: "no fields",
+			data: "measurements: [{name: m}]",
+			want: "has no fields",
+		},
+		{
+			desc: "duplicate tag",
+			data: "tags: [{key: a}, {key: a}]\n" + strings.Replace(field, "%s", "{type: nd}", 1),
+			want: "duplicate tag",
+		},
+		{
+			desc: "values and cardinality",
+			data: "tags: [{key: a, values: [x], cardinality: 2}]\n" + strings.Replace(field, "%s", "{type: nd}", 1),
+			want: "both values and cardinality",
+		},
+		{
+			desc: "unknown distribution",
+			data: strings.Replace(field, "%s", "{type: foo}", 1),
+			want: "unknown distribution type 'foo'",
+		},
+		{
+			desc: "random walk without step",
+			data: strings.Replace(field, "%s", "{type: cwd, min: 0, max: 1}", 1),
+			want: "needs a step",
+		},
+		{
+			desc: "modifier without base",
+			data: strings.Replace(field, "%s", "{type: seasonal, daily: 1}", 1),
+			want: "needs a base",
+		},
+		{
+			desc: "bad probability",
+			data: strings.Replace(field, "%s", "{type: reset, probability: 2, base: {type: nd}}", 1),
+			want: "probability not between 0 and 1",
+		},
+		{
+			desc: "bad zipf",
+			data: strings.Replace(field, "%s", "{type: zipf, s: 1, v: 1}", 1),
+			want: "needs s > 1",
+		},
+		{
+			desc: "bad step",
+			data: strings.Replace(field, "%s", "{type: wd, step: {type: ud, low: 1, high: 0}}", 1),
+			want: "step of 'wd'",
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Parse([]byte(c.data))
+		if err == nil {
+			t.Errorf("%s: expected an error", c.desc)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: incorrect error: got %v want %s", c.desc, err, c.want)
+		}
+	}
+}
+
+func TestDistributionSpecBuild(t *testing.T) {
+	cases := []struct {
+		spec DistributionSpec
+		want common.Distribution
+	}{
+		{DistributionSpec{Type: DistributionND, Mean: 1, StdDev: 2}, &common.NormalDistribution{}},
+		{DistributionSpec{Type: DistributionUD, Low: 1, High: 2}, &common.UniformDistribution{}},
+		{DistributionSpec{Type: DistributionWD, Step: &DistributionSpec{Type: DistributionND}}, &common.RandomWalkDistribution{}},
+		{DistributionSpec{Type: DistributionCWD, Max: 1, Step: &DistributionSpec{Type: DistributionND}}, &common.ClampedRandomWalkDistribution{}},
+		{DistributionSpec{Type: DistributionMWD, Step: &DistributionSpec{Type: DistributionND}}, &common.MonotonicRandomWalkDistribution{}},
+		{DistributionSpec{Type: DistributionConstant, State: 3}, &common.ConstantDistribution{}},
+		{DistributionSpec{Type: DistributionPareto, Scale: 1, Shape: 1.5}, &common.ParetoDistribution{}},
+		{DistributionSpec{Type: DistributionZipf, S: 1.1, V: 1, Max: 10}, &common.ZipfDistribution{}},
+		{DistributionSpec{Type: DistributionSeasonal, Base: &DistributionSpec{Type: DistributionND}, Daily: 1, Peak: time.Hour}, &common.SeasonalDistribution{}},
+		{DistributionSpec{Type: DistributionAnomaly, Base: &DistributionSpec{Type: DistributionND}, SpikeProbability: 0.1, SpikeLength: 2}, &common.AnomalyDistribution{}},
+		{DistributionSpec{Type: DistributionReset, Base: &DistributionSpec{Type: DistributionND}, Probability: 0.1}, &common.ResetDistribution{}},
+		{DistributionSpec{Type: DistributionClamp, Base: &DistributionSpec{Type: DistributionND}, Max: 1}, &common.ClampedDistribution{}},
+	}
+	for _, c := range cases {
+		d, err := c.spec.Build()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.spec.Type, err)
+			continue
+		}
+		if got, want := typeName(d), typeName(c.want); got != want {
+			t.Errorf("%s: incorrect distribution: got %s want %s", c.spec.Type, got, want)
+		}
+	}
+
+	d, _ := (&DistributionSpec{Type: DistributionConstant, State: 3}).Build()
+	d.Advance()
+	if got := d.Get(); got != 3 {
+		t.Errorf("incorrect constant: got %v want %v", got, 3)
+	}
+}
+
+func typeName(d common.Distribution) string {
+	return fmt.Sprintf("%T", d)
+}
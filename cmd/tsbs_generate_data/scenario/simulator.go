@@ -0,0 +1,178 @@
+package scenario
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+// SimulatorConfig is used to create a Simulator for a Scenario.
+type SimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	// SeriesCount is the number of series to simulate
+	SeriesCount uint64
+	Scenario    *Scenario
+}
+
+// ToSimulator produces a Simulator of the Scenario over the specified
+// interval, unless the Scenario sets its own.
+func (c *SimulatorConfig) ToSimulator(interval time.Duration) common.Simulator {
+	if c.Scenario.Interval > 0 {
+		interval = c.Scenario.Interval
+	}
+
+	s := &Simulator{
+		fields:    make(map[string][][]byte),
+		interval:  interval,
+		start:     c.Start,
+		timestamp: c.Start,
+	}
+	if c.SeriesCount > 0 {
+		s.ticks = uint64(c.End.Sub(c.Start).Nanoseconds() / interval.Nanoseconds())
+	}
+
+	for _, t := range c.Scenario.Tags {
+		s.tagKeys = append(s.tagKeys, []byte(t.Key))
+	}
+	for _, ms := range c.Scenario.Measurements {
+		m := measurement{name: []byte(ms.Name), every: 1}
+		// measurements are read every so many intervals, at least one
+		if n := uint64(ms.Interval / interval); n > 1 {
+			m.every = n
+		}
+		for _, f := range ms.Fields {
+			m.fieldKeys = append(m.fieldKeys, []byte(f.Name))
+			m.ints = append(m.ints, f.Type == FieldTypeInt)
+		}
+		s.measurements = append(s.measurements, m)
+		s.fields[ms.Name] = m.fieldKeys
+	}
+
+	s.series = make([]series, c.SeriesCount)
+	for i := range s.series {
+		s.series[i] = newSeries(i, c.Scenario)
+	}
+	return s
+}
+
+// Simulator simulates the series of a Scenario. At every interval, each
+// measurement that is due is read for every series in turn.
+type Simulator struct {
+	tagKeys      [][]byte
+	fields       map[string][][]byte
+	measurements []measurement
+	series       []series
+
+	interval time.Duration
+	start    time.Time
+	ticks    uint64
+
+	tick           uint64
+	timestamp      time.Time
+	measurementIdx int
+	seriesIdx      int
+}
+
+type measurement struct {
+	name []byte
+	// every is the number of intervals between two readings
+	every     uint64
+	fieldKeys [][]byte
+	ints      []bool
+}
+
+type series struct {
+	tagValues [][]byte
+	// distributions of the fields of every measurement
+	distributions [][]common.Distribution
+}
+
+func newSeries(i int, sc *Scenario) series {
+	s := series{}
+	for _, t := range sc.Tags {
+		var v string
+		if len(t.Values) > 0 {
+			v = t.Values[rand.Intn(len(t.Values))]
+		} else if t.Cardinality > 0 {
+			v = fmt.Sprintf("%s_%d", t.Key, rand.Intn(t.Cardinality))
+		} else {
+			v = fmt.Sprintf("%s_%d", t.Key, i)
+		}
+		s.tagValues = append(s.tagValues, []byte(v))
+	}
+	for _, m := range sc.Measurements {
+		dists := make([]common.Distribution, len(m.Fields))
+		for j, f := range m.Fields {
+			// the Scenario is validated, so this cannot fail
+			dists[j], _ = f.Distribution.Build()
+		}
+		s.distributions = append(s.distributions, dists)
+	}
+	return s
+}
+
+// Finished tells whether we have simulated all the necessary points
+func (s *Simulator) Finished() bool {
+	return s.tick >= s.ticks
+}
+
+// Fields returns the field keys of every measurement
+func (s *Simulator) Fields() map[string][][]byte {
+	return s.fields
+}
+
+// TagKeys returns the keys of the tags of every series
+func (s *Simulator) TagKeys() [][]byte {
+	return s.tagKeys
+}
+
+// Next advances a Point to the next state in the generator.
+func (s *Simulator) Next(p *serialize.Point) bool {
+	for !s.due() {
+		s.advance()
+		if s.Finished() {
+			return false
+		}
+	}
+
+	m := &s.measurements[s.measurementIdx]
+	sr := &s.series[s.seriesIdx]
+	for i, k := range s.tagKeys {
+		p.AppendTag(k, sr.tagValues[i])
+	}
+	p.SetMeasurementName(m.name)
+	p.SetTimestamp(&s.timestamp)
+	for i, d := range sr.distributions[s.measurementIdx] {
+		common.AdvanceAt(d, s.timestamp)
+		if m.ints[i] {
+			p.AppendField(m.fieldKeys[i], int64(d.Get()))
+		} else {
+			p.AppendField(m.fieldKeys[i], d.Get())
+		}
+	}
+
+	s.seriesIdx++
+	return true
+}
+
+// due returns whether the current series should read the current measurement
+func (s *Simulator) due() bool {
+	return s.seriesIdx < len(s.series) && s.tick%s.measurements[s.measurementIdx].every == 0
+}
+
+// advance moves on to the next measurement, and to the next interval once
+// all measurements have been read
+func (s *Simulator) advance() {
+	s.seriesIdx = 0
+	s.measurementIdx++
+	if s.measurementIdx == len(s.measurements) {
+		s.measurementIdx = 0
+		s.tick++
+		s.timestamp = s.start.Add(time.Duration(s.tick) * s.interval)
+	}
+}
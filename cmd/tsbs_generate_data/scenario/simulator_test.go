@@ -0,0 +1,76 @@
+package scenario
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestSimulator(t *testing.T) {
+	sc, err := Load("testdata/example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Unix(0, 0).UTC()
+	c := &SimulatorConfig{
+		Start:       start,
+		End:         start.Add(10 * time.Minute),
+		SeriesCount: 5,
+		Scenario:    sc,
+	}
+	// the scenario interval takes precedence over the one given
+	s := c.ToSimulator(time.Hour)
+
+	if got := len(s.TagKeys()); got != 3 {
+		t.Errorf("incorrect number of tag keys: got %d want %d", got, 3)
+	}
+	fields := s.Fields()
+	if got := len(fields["requests"]); got != 2 {
+		t.Errorf("incorrect number of requests fields: got %d want %d", got, 2)
+	}
+	if got := len(fields["memory"]); got != 2 {
+		t.Errorf("incorrect number of memory fields: got %d want %d", got, 2)
+	}
+
+	counts := map[string]int{}
+	hosts := map[string]bool{}
+	last := start
+	p := serialize.NewPoint()
+	for !s.Finished() {
+		if !s.Next(p) {
+			break
+		}
+		name := string(p.MeasurementName())
+		counts[name]++
+		if p.Timestamp().Before(last) {
+			t.Errorf("timestamps out of order: %v before %v", p.Timestamp(), last)
+		}
+		last = *p.Timestamp()
+
+		tags := p.TagValues()
+		hosts[string(tags[0])] = true
+		if !bytes.HasPrefix(tags[2], []byte("service_")) {
+			t.Errorf("incorrect service tag: %s", tags[2])
+		}
+		if _, ok := p.FieldValues()[0].(int64); name == "requests" && !ok {
+			t.Errorf("requests count is not an int64: %T", p.FieldValues()[0])
+		}
+		if _, ok := p.FieldValues()[0].(float64); name == "memory" && !ok {
+			t.Errorf("memory used_percent is not a float64: %T", p.FieldValues()[0])
+		}
+		p.Reset()
+	}
+
+	// 60 intervals of 10s, memory being read every sixth
+	if got := counts["requests"]; got != 60*5 {
+		t.Errorf("incorrect number of requests points: got %d want %d", got, 60*5)
+	}
+	if got := counts["memory"]; got != 10*5 {
+		t.Errorf("incorrect number of memory points: got %d want %d", got, 10*5)
+	}
+	if got := len(hosts); got != 5 {
+		t.Errorf("incorrect number of hosts: got %d want %d", got, 5)
+	}
+}
@@ -31,6 +31,10 @@ func (s *CassandraSerializer) Serialize(p *Point, w io.Writer) (err error) {
 
 	for fieldID := 0; fieldID < len(p.fieldKeys); fieldID++ {
 		value := p.fieldValues[fieldID]
+		// series only have rows for the values they have
+		if value == nil {
+			continue
+		}
 		tableName := fmt.Sprintf("series_%s", typeNameForCassandra(value))
 
 		buf := make([]byte, 0, 256)
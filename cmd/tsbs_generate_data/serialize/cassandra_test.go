@@ -16,6 +16,11 @@ func TestCassandraSerializerSerialize(t *testing.T) {
 			inputPoint: testPointInt,
 			output:     "series_bigint,cpu,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b,usage_guest,2016-01-01,1451606400000000000,38\n",
 		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "series_bigint,cpu,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b,usage_guest,2016-01-01,1451606400000000000,38\n",
+		},
 		{
 			desc:       "a Point with no tags",
 			inputPoint: testPointNoTags,
@@ -0,0 +1,65 @@
+package serialize
+
+import (
+	"io"
+	"strconv"
+)
+
+// ClickHouseSerializer writes a Point in a serialized form for ClickHouse
+type ClickHouseSerializer struct{}
+
+// Serialize writes Point p to the given Writer w, so it can be
+// loaded by the ClickHouse loader. The format is a single TabSeparated row
+// per Point, prefixed with the name of the table it belongs to:
+//
+// e.g.,
+// <measurement>\t<timestamp>\t<tag1>\t<tag2>\t...\t<field1>\t<field2>\t...
+//
+// Tag values are written without their keys, in the order of the header of
+// the dataset. Missing fields are written as \N, which ClickHouse reads as
+// NULL.
+func (s *ClickHouseSerializer) Serialize(p *Point, w io.Writer) error {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, p.measurementName...)
+	buf = append(buf, '\t')
+	buf = strconv.AppendInt(buf, p.timestamp.UTC().UnixNano(), 10)
+
+	for _, v := range p.tagValues {
+		buf = append(buf, '\t')
+		buf = appendTSVEscaped(buf, v)
+	}
+	for _, v := range p.fieldValues {
+		buf = append(buf, '\t')
+		switch v := v.(type) {
+		case nil:
+			buf = append(buf, `\N`...)
+		case []byte:
+			buf = appendTSVEscaped(buf, v)
+		case string:
+			buf = appendTSVEscaped(buf, []byte(v))
+		default:
+			buf = fastFormatAppend(v, buf)
+		}
+	}
+	buf = append(buf, '\n')
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendTSVEscaped appends v to buf, escaping the characters that have a
+// meaning in the TabSeparated format
+func appendTSVEscaped(buf, v []byte) []byte {
+	for _, c := range v {
+		switch c {
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\\':
+			buf = append(buf, '\\', '\\')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,56 @@
+package serialize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClickHouseSerializerSerialize(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point",
+			inputPoint: testPointDefault,
+			output:     "cpu\t1451606400000000000\thost_0\teu-west-1\teu-west-1b\t38.24311829\n",
+		},
+		{
+			desc:       "a regular Point using int as value",
+			inputPoint: testPointInt,
+			output:     "cpu\t1451606400000000000\thost_0\teu-west-1\teu-west-1b\t38\n",
+		},
+		{
+			desc:       "a regular Point with multiple fields",
+			inputPoint: testPointMultiField,
+			output:     "cpu\t1451606400000000000\thost_0\teu-west-1\teu-west-1b\t5000000000\t38\t38.24311829\n",
+		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "cpu\t1451606400000000000\thost_0\teu-west-1\teu-west-1b\t\\N\t38\t\\N\n",
+		},
+		{
+			desc:       "a Point with no tags",
+			inputPoint: testPointNoTags,
+			output:     "cpu\t1451606400000000000\t38.24311829\n",
+		},
+	}
+
+	testSerializer(t, cases, &ClickHouseSerializer{})
+}
+
+func TestClickHouseSerializerEscapes(t *testing.T) {
+	ts := time.Unix(0, 0)
+	p := NewPoint()
+	p.SetMeasurementName([]byte("log"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("path"), []byte(`C:\tmp`))
+	p.AppendField([]byte("message"), []byte("a\tb\nc"))
+	cases := []serializeCase{
+		{
+			desc:       "a Point with tabs, newlines and backslashes",
+			inputPoint: p,
+			output:     "log\t0\tC:\\\\tmp\ta\\tb\\nc\n",
+		},
+	}
+
+	testSerializer(t, cases, &ClickHouseSerializer{})
+}
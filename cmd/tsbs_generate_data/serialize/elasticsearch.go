@@ -0,0 +1,84 @@
+package serialize
+
+import (
+	"io"
+	"strconv"
+)
+
+// ElasticsearchSerializer writes a Point in a serialized form for
+// Elasticsearch
+type ElasticsearchSerializer struct{}
+
+// Serialize writes Point data to the given writer, as the two NDJSON lines
+// of an index action of the _bulk API: the action, naming the daily index of
+// the measurement, and the document, with the timestamp in milliseconds, the
+// tags and the fields:
+//
+// {"index":{"_index":"cpu-2016.01.01"}}
+// {"@timestamp":1451606400000,"hostname":"host_0","region":"eu-west-1","usage_user":58.13}
+//
+// The loader prefixes the index names with the database name. Missing fields
+// are not written.
+func (s *ElasticsearchSerializer) Serialize(p *Point, w io.Writer) error {
+	ts := p.timestamp.UTC()
+	index := make([]byte, 0, len(p.measurementName)+11)
+	index = append(index, p.measurementName...)
+	index = append(index, '-')
+	index = ts.AppendFormat(index, "2006.01.02")
+
+	buf := make([]byte, 0, 512)
+	buf = append(buf, `{"index":{"_index":`...)
+	buf = appendJSONString(buf, index)
+	buf = append(buf, "}}\n"...)
+
+	buf = append(buf, `{"@timestamp":`...)
+	buf = strconv.AppendInt(buf, ts.UnixNano()/1e6, 10)
+	for i, v := range p.tagValues {
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, p.tagKeys[i])
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, v)
+	}
+	for i, v := range p.fieldValues {
+		if v == nil {
+			continue
+		}
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, p.fieldKeys[i])
+		buf = append(buf, ':')
+		switch v := v.(type) {
+		case []byte:
+			buf = appendJSONString(buf, v)
+		case string:
+			buf = appendJSONString(buf, []byte(v))
+		default:
+			buf = fastFormatAppend(v, buf)
+		}
+	}
+	buf = append(buf, "}\n"...)
+	_, err := w.Write(buf)
+	return err
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends v to buf as a JSON string, escaping quotes,
+// backslashes and control characters
+func appendJSONString(buf, v []byte) []byte {
+	buf = append(buf, '"')
+	for _, c := range v {
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '"')
+}
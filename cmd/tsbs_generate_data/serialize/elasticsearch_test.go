@@ -0,0 +1,60 @@
+package serialize
+
+import (
+	"testing"
+	"time"
+)
+
+const esAction = "{\"index\":{\"_index\":\"cpu-2016.01.01\"}}\n"
+
+func TestElasticsearchSerializerSerialize(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point",
+			inputPoint: testPointDefault,
+			output:     esAction + `{"@timestamp":1451606400000,"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b","usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			desc:       "a regular Point using int as value",
+			inputPoint: testPointInt,
+			output:     esAction + `{"@timestamp":1451606400000,"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b","usage_guest":38}` + "\n",
+		},
+		{
+			desc:       "a regular Point with multiple fields",
+			inputPoint: testPointMultiField,
+			output:     esAction + `{"@timestamp":1451606400000,"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b","big_usage_guest":5000000000,"usage_guest":38,"usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     esAction + `{"@timestamp":1451606400000,"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b","usage_guest":38}` + "\n",
+		},
+		{
+			desc:       "a Point with no tags",
+			inputPoint: testPointNoTags,
+			output:     esAction + `{"@timestamp":1451606400000,"usage_guest_nice":38.24311829}` + "\n",
+		},
+	}
+
+	testSerializer(t, cases, &ElasticsearchSerializer{})
+}
+
+func TestElasticsearchSerializerEscapes(t *testing.T) {
+	ts := time.Unix(86399, 999e6)
+	p := NewPoint()
+	p.SetMeasurementName([]byte("log"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("path"), []byte(`C:\tmp "a"`))
+	p.AppendField([]byte("message"), []byte("a\tb\nc\x01"))
+	p.AppendField([]byte("ok"), true)
+	cases := []serializeCase{
+		{
+			desc:       "a Point with quotes, backslashes, control characters and a bool",
+			inputPoint: p,
+			output: "{\"index\":{\"_index\":\"log-1970.01.01\"}}\n" +
+				`{"@timestamp":86399999,"path":"C:\\tmp \"a\"","message":"a\tb\nc\u0001","ok":true}` + "\n",
+		},
+	}
+
+	testSerializer(t, cases, &ElasticsearchSerializer{})
+}
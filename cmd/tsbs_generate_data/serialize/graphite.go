@@ -0,0 +1,51 @@
+package serialize
+
+import (
+	"io"
+	"strconv"
+)
+
+// GraphiteSerializer writes a Point in a serialized form for Graphite
+type GraphiteSerializer struct{}
+
+// Serialize writes Point data to the given writer, as lines of the Graphite
+// plaintext protocol. Each field is a separate series whose dotted path is
+// made of the measurement and the field, with the tags of the Point appended
+// as Graphite tags and a timestamp in seconds:
+//
+// cpu.usage_user;hostname=host_0;region=eu-west-1 58.13 1451606400
+//
+// Missing fields are not written, nor are tags with empty values since
+// Graphite does not allow them.
+func (s *GraphiteSerializer) Serialize(p *Point, w io.Writer) error {
+	tags := make([]byte, 0, 256)
+	for i, v := range p.tagValues {
+		if len(v) == 0 {
+			continue
+		}
+		tags = append(tags, ';')
+		tags = appendSanitized(tags, p.tagKeys[i])
+		tags = append(tags, '=')
+		tags = appendSanitized(tags, v)
+	}
+	suffix := make([]byte, 0, 16)
+	suffix = append(suffix, ' ')
+	suffix = strconv.AppendInt(suffix, p.timestamp.UTC().Unix(), 10)
+	suffix = append(suffix, '\n')
+
+	buf := make([]byte, 0, 512)
+	for i, v := range p.fieldValues {
+		if v == nil {
+			continue
+		}
+		buf = appendSanitized(buf, p.measurementName)
+		buf = append(buf, '.')
+		buf = appendSanitized(buf, p.fieldKeys[i])
+		buf = append(buf, tags...)
+		buf = append(buf, ' ')
+		buf = appendNumber(v, buf)
+		buf = append(buf, suffix...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,54 @@
+package serialize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGraphiteSerializerSerialize(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point",
+			inputPoint: testPointDefault,
+			output:     "cpu.usage_guest_nice;hostname=host_0;region=eu-west-1;datacenter=eu-west-1b 38.24311829 1451606400\n",
+		},
+		{
+			desc:       "a regular Point with multiple fields",
+			inputPoint: testPointMultiField,
+			output: "cpu.big_usage_guest;hostname=host_0;region=eu-west-1;datacenter=eu-west-1b 5000000000 1451606400\n" +
+				"cpu.usage_guest;hostname=host_0;region=eu-west-1;datacenter=eu-west-1b 38 1451606400\n" +
+				"cpu.usage_guest_nice;hostname=host_0;region=eu-west-1;datacenter=eu-west-1b 38.24311829 1451606400\n",
+		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "cpu.usage_guest;hostname=host_0;region=eu-west-1;datacenter=eu-west-1b 38 1451606400\n",
+		},
+		{
+			desc:       "a Point with no tags",
+			inputPoint: testPointNoTags,
+			output:     "cpu.usage_guest_nice 38.24311829 1451606400\n",
+		},
+	}
+
+	testSerializer(t, cases, &GraphiteSerializer{})
+}
+
+func TestGraphiteSerializerSanitizes(t *testing.T) {
+	ts := time.Unix(1, 5e6)
+	p := NewPoint()
+	p.SetMeasurementName([]byte("disk io"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("path"), []byte("a;b=c"))
+	p.AppendTag([]byte("empty"), []byte(""))
+	p.AppendField([]byte("ok"), false)
+	cases := []serializeCase{
+		{
+			desc:       "a Point with invalid characters, an empty tag and a bool",
+			inputPoint: p,
+			output:     "disk_io.ok;path=a_b_c 0 1\n",
+		},
+	}
+
+	testSerializer(t, cases, &GraphiteSerializer{})
+}
@@ -13,7 +13,14 @@ type InfluxSerializer struct{}
 //
 // For example:
 // foo,tag0=bar baz=-1.0 100\n
+//
+// Missing fields are left out, and a Point with only missing fields is not
+// written at all since a line needs at least one field.
 func (s *InfluxSerializer) Serialize(p *Point, w io.Writer) (err error) {
+	if !hasFieldValues(p) {
+		return nil
+	}
+
 	buf := scratchBufPool.Get().([]byte)
 	buf = append(buf, p.measurementName...)
 
@@ -24,15 +31,17 @@ func (s *InfluxSerializer) Serialize(p *Point, w io.Writer) (err error) {
 		buf = append(buf, p.tagValues[i]...)
 	}
 
-	if len(p.fieldKeys) > 0 {
-		buf = append(buf, ' ')
-	}
-
+	sep := byte(' ')
 	for i := 0; i < len(p.fieldKeys); i++ {
+		v := p.fieldValues[i]
+		if v == nil {
+			continue
+		}
+		buf = append(buf, sep)
+		sep = ','
+
 		buf = append(buf, p.fieldKeys[i]...)
 		buf = append(buf, '=')
-
-		v := p.fieldValues[i]
 		buf = fastFormatAppend(v, buf)
 
 		// Influx uses 'i' to indicate integers:
@@ -40,10 +49,6 @@ func (s *InfluxSerializer) Serialize(p *Point, w io.Writer) (err error) {
 		case int, int64:
 			buf = append(buf, 'i')
 		}
-
-		if i+1 < len(p.fieldKeys) {
-			buf = append(buf, ',')
-		}
 	}
 
 	buf = append(buf, ' ')
@@ -21,6 +21,16 @@ func TestInfluxSerializerSerialize(t *testing.T) {
 			inputPoint: testPointMultiField,
 			output:     "cpu,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b big_usage_guest=5000000000i,usage_guest=38i,usage_guest_nice=38.24311829 1451606400000000000\n",
 		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "cpu,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b usage_guest=38i 1451606400000000000\n",
+		},
+		{
+			desc:       "a Point with only missing fields",
+			inputPoint: testPointAllMissing,
+			output:     "",
+		},
 		{
 			desc:       "a Point with no tags",
 			inputPoint: testPointNoTags,
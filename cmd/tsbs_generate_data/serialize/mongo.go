@@ -50,10 +50,14 @@ func (s *MongoSerializer) Serialize(p *Point, w io.Writer) (err error) {
 	// to go in reverse order since we are prepending rather than appending.
 	for i := len(p.fieldKeys); i > 0; i-- {
 		k := string(p.fieldKeys[i-1])
+		v := fieldsMap[k]
+		// missing fields are left out of the document
+		if v == nil {
+			continue
+		}
 		key := b.CreateString(k)
 		MongoReadingStart(b)
 		MongoReadingAddKey(b, key)
-		v := fieldsMap[k]
 		switch val := v.(type) {
 		case float64:
 			MongoReadingAddValue(b, val)
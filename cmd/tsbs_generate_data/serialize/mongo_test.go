@@ -62,6 +62,18 @@ func TestMongoSerializerSerialize(t *testing.T) {
 				readingVals: testPointMultiField.fieldValues,
 			},
 		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			want: output{
+				name:        string(testMeasurement),
+				ts:          testNow.UnixNano(),
+				tagKeys:     testTagKeys,
+				tagVals:     testTagVals,
+				readingKeys: [][]byte{testColInt},
+				readingVals: []interface{}{testInt},
+			},
+		},
 		{
 			desc:       "a Point with no tags",
 			inputPoint: testPointNoTags,
@@ -0,0 +1,51 @@
+package serialize
+
+import (
+	"io"
+	"strconv"
+)
+
+// OpenTSDBSerializer writes a Point in a serialized form for OpenTSDB
+type OpenTSDBSerializer struct{}
+
+// Serialize writes Point data to the given writer, as the lines of the
+// OpenTSDB telnet `put` command. Each field is a separate metric named after
+// the measurement and the field, with the tags of the Point and a timestamp
+// in milliseconds:
+//
+// put cpu.usage_user 1451606400000 58.13 hostname=host_0 region=eu-west-1
+//
+// Missing fields are not written, nor are tags with empty values since
+// OpenTSDB does not allow them.
+func (s *OpenTSDBSerializer) Serialize(p *Point, w io.Writer) error {
+	suffix := make([]byte, 0, 256)
+	for i, v := range p.tagValues {
+		if len(v) == 0 {
+			continue
+		}
+		suffix = append(suffix, ' ')
+		suffix = appendSanitized(suffix, p.tagKeys[i])
+		suffix = append(suffix, '=')
+		suffix = appendSanitized(suffix, v)
+	}
+	suffix = append(suffix, '\n')
+	timestampMillis := p.timestamp.UTC().UnixNano() / 1e6
+
+	buf := make([]byte, 0, 512)
+	for i, v := range p.fieldValues {
+		if v == nil {
+			continue
+		}
+		buf = append(buf, "put "...)
+		buf = appendSanitized(buf, p.measurementName)
+		buf = append(buf, '.')
+		buf = appendSanitized(buf, p.fieldKeys[i])
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, timestampMillis, 10)
+		buf = append(buf, ' ')
+		buf = appendNumber(v, buf)
+		buf = append(buf, suffix...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,54 @@
+package serialize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpenTSDBSerializerSerialize(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point",
+			inputPoint: testPointDefault,
+			output:     "put cpu.usage_guest_nice 1451606400000 38.24311829 hostname=host_0 region=eu-west-1 datacenter=eu-west-1b\n",
+		},
+		{
+			desc:       "a regular Point with multiple fields",
+			inputPoint: testPointMultiField,
+			output: "put cpu.big_usage_guest 1451606400000 5000000000 hostname=host_0 region=eu-west-1 datacenter=eu-west-1b\n" +
+				"put cpu.usage_guest 1451606400000 38 hostname=host_0 region=eu-west-1 datacenter=eu-west-1b\n" +
+				"put cpu.usage_guest_nice 1451606400000 38.24311829 hostname=host_0 region=eu-west-1 datacenter=eu-west-1b\n",
+		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "put cpu.usage_guest 1451606400000 38 hostname=host_0 region=eu-west-1 datacenter=eu-west-1b\n",
+		},
+		{
+			desc:       "a Point with all fields missing",
+			inputPoint: testPointAllMissing,
+			output:     "",
+		},
+	}
+
+	testSerializer(t, cases, &OpenTSDBSerializer{})
+}
+
+func TestOpenTSDBSerializerSanitizes(t *testing.T) {
+	ts := time.Unix(1, 5e6)
+	p := NewPoint()
+	p.SetMeasurementName([]byte("disk io"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("path"), []byte("C:\\tmp"))
+	p.AppendTag([]byte("empty"), []byte(""))
+	p.AppendField([]byte("ok"), true)
+	cases := []serializeCase{
+		{
+			desc:       "a Point with invalid characters, an empty tag and a bool",
+			inputPoint: p,
+			output:     "put disk_io.ok 1005 1 path=C__tmp\n",
+		},
+	}
+
+	testSerializer(t, cases, &OpenTSDBSerializer{})
+}
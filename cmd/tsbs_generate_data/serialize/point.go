@@ -48,6 +48,23 @@ func (p *Point) SetTimestamp(t *time.Time) {
 	p.timestamp = t
 }
 
+// Timestamp returns the Timestamp of this data point
+func (p *Point) Timestamp() *time.Time {
+	return p.timestamp
+}
+
+// Copy overwrites this Point with the data of from. The timestamp is copied
+// by value so that from's can be reused.
+func (p *Point) Copy(from *Point) {
+	p.measurementName = from.measurementName
+	p.tagKeys = append(p.tagKeys[:0], from.tagKeys...)
+	p.tagValues = append(p.tagValues[:0], from.tagValues...)
+	p.fieldKeys = append(p.fieldKeys[:0], from.fieldKeys...)
+	p.fieldValues = append(p.fieldValues[:0], from.fieldValues...)
+	ts := *from.timestamp
+	p.timestamp = &ts
+}
+
 // SetMeasurementName sets the name of the measurement for this data point
 func (p *Point) SetMeasurementName(s []byte) {
 	p.measurementName = s
@@ -63,18 +80,50 @@ func (p *Point) FieldKeys() [][]byte {
 	return p.fieldKeys
 }
 
+// TagKeys returns the Point's tag keys
+func (p *Point) TagKeys() [][]byte {
+	return p.tagKeys
+}
+
+// TagValues returns the Point's tag values
+func (p *Point) TagValues() [][]byte {
+	return p.tagValues
+}
+
+// FieldValues returns the Point's field values
+func (p *Point) FieldValues() []interface{} {
+	return p.fieldValues
+}
+
 // AppendTag adds a tag with a given key and value to this data point
 func (p *Point) AppendTag(key, value []byte) {
 	p.tagKeys = append(p.tagKeys, key)
 	p.tagValues = append(p.tagValues, value)
 }
 
+// SetFieldValue overwrites the value of the i-th field of this data point. A
+// nil value marks the field as missing, which serializers leave out or write
+// as null.
+func (p *Point) SetFieldValue(i int, value interface{}) {
+	p.fieldValues[i] = value
+}
+
 // AppendField adds a field with a given key and value to this data point
 func (p *Point) AppendField(key []byte, value interface{}) {
 	p.fieldKeys = append(p.fieldKeys, key)
 	p.fieldValues = append(p.fieldValues, value)
 }
 
+// hasFieldValues returns whether p has at least one field that is not missing
+func hasFieldValues(p *Point) bool {
+	for _, v := range p.fieldValues {
+		if v != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // PointSerializer serializes a Point for writing
 type PointSerializer interface {
 	Serialize(p *Point, w io.Writer) error
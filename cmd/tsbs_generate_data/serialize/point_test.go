@@ -49,6 +49,24 @@ var testPointInt = &Point{
 	fieldValues:     []interface{}{testInt},
 }
 
+var testPointMissingField = &Point{
+	measurementName: testMeasurement,
+	tagKeys:         testTagKeys,
+	tagValues:       testTagVals,
+	timestamp:       &testNow,
+	fieldKeys:       [][]byte{testColInt64, testColInt, testColFloat},
+	fieldValues:     []interface{}{nil, testInt, nil},
+}
+
+var testPointAllMissing = &Point{
+	measurementName: testMeasurement,
+	tagKeys:         testTagKeys,
+	tagValues:       testTagVals,
+	timestamp:       &testNow,
+	fieldKeys:       [][]byte{testColInt64, testColFloat},
+	fieldValues:     []interface{}{nil, nil},
+}
+
 var testPointNoTags = &Point{
 	measurementName: testMeasurement,
 	tagKeys:         [][]byte{},
@@ -74,3 +92,22 @@ func testSerializer(t *testing.T, cases []serializeCase, ps PointSerializer) {
 		}
 	}
 }
+
+func TestPointCopy(t *testing.T) {
+	p := NewPoint()
+	p.Copy(testPointMultiField)
+	b := new(bytes.Buffer)
+	(&InfluxSerializer{}).Serialize(p, b)
+	want := new(bytes.Buffer)
+	(&InfluxSerializer{}).Serialize(testPointMultiField, want)
+	if got := b.String(); got != want.String() {
+		t.Errorf("copied point serialized differently: got %s want %s", got, want.String())
+	}
+
+	if p.Timestamp() == testPointMultiField.Timestamp() {
+		t.Errorf("copied point shares the timestamp of the original")
+	}
+	if !p.Timestamp().Equal(testNow) {
+		t.Errorf("incorrect timestamp: got %v want %v", p.Timestamp(), testNow)
+	}
+}
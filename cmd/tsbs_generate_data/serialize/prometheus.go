@@ -78,6 +78,10 @@ func (s *PrometheusSerializer) Serialize(p *Point, w io.Writer) (err error) {
 	}
 	prefix := string(p.measurementName)
 	for i := 0; i < len(p.fieldKeys); i++ {
+		// series only have samples for the values they have
+		if p.fieldValues[i] == nil {
+			continue
+		}
 		ts := &prompb.TimeSeries{
 			Labels:  labels,
 			Samples: make([]prompb.Sample, 1),
@@ -0,0 +1,95 @@
+package serialize
+
+import (
+	"io"
+	"strconv"
+)
+
+// PrometheusTextSerializer writes a Point in the text exposition format of
+// Prometheus, as served to scrapers
+type PrometheusTextSerializer struct {
+	// Timestamps makes the samples carry the timestamp of the Point, in
+	// milliseconds, instead of leaving it to the scraper
+	Timestamps bool
+}
+
+// Serialize writes Point data to the given writer, as a sample per field
+// named after the measurement and the field, labeled with the tags of the
+// Point, the same as the series written by PrometheusSerializer:
+//
+// cpu_usage_user{hostname="host_0",region="eu-west-1"} 58.13
+//
+// Missing fields and fields that are not numbers are not written.
+func (s *PrometheusTextSerializer) Serialize(p *Point, w io.Writer) error {
+	labels := make([]byte, 0, 256)
+	for i, v := range p.tagValues {
+		if i == 0 {
+			labels = append(labels, '{')
+		} else {
+			labels = append(labels, ',')
+		}
+		labels = appendPrometheusName(labels, p.tagKeys[i], false)
+		labels = append(labels, `="`...)
+		labels = appendLabelValue(labels, v)
+		labels = append(labels, '"')
+	}
+	if len(labels) > 0 {
+		labels = append(labels, '}')
+	}
+
+	name := make([]byte, 0, 64)
+	buf := make([]byte, 0, 1024)
+	for i, v := range p.fieldValues {
+		switch v.(type) {
+		case nil, []byte, string:
+			continue
+		}
+		name = append(name[:0], p.measurementName...)
+		name = append(name, '_')
+		name = append(name, p.fieldKeys[i]...)
+		buf = appendPrometheusName(buf, name, true)
+		buf = append(buf, labels...)
+		buf = append(buf, ' ')
+		buf = appendNumber(v, buf)
+		if s.Timestamps {
+			buf = append(buf, ' ')
+			buf = strconv.AppendInt(buf, p.timestamp.UnixNano()/1e6, 10)
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendPrometheusName appends v to buf, replacing the characters that are
+// not allowed in metric names, if metric is set, or label names with
+// underscores
+func appendPrometheusName(buf, v []byte, metric bool) []byte {
+	for i, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == ':' && metric:
+		default:
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendLabelValue appends v to buf, escaping the characters that have a
+// meaning in label values
+func appendLabelValue(buf, v []byte) []byte {
+	for _, c := range v {
+		switch c {
+		case '\\', '"':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,70 @@
+package serialize
+
+import (
+	"testing"
+	"time"
+)
+
+const promLabels = `{hostname="host_0",region="eu-west-1",datacenter="eu-west-1b"}`
+
+func TestPrometheusTextSerializerSerialize(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point",
+			inputPoint: testPointDefault,
+			output:     "cpu_usage_guest_nice" + promLabels + " 38.24311829\n",
+		},
+		{
+			desc:       "a regular Point with multiple fields",
+			inputPoint: testPointMultiField,
+			output: "cpu_big_usage_guest" + promLabels + " 5000000000\n" +
+				"cpu_usage_guest" + promLabels + " 38\n" +
+				"cpu_usage_guest_nice" + promLabels + " 38.24311829\n",
+		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "cpu_usage_guest" + promLabels + " 38\n",
+		},
+		{
+			desc:       "a Point with no tags",
+			inputPoint: testPointNoTags,
+			output:     "cpu_usage_guest_nice 38.24311829\n",
+		},
+	}
+
+	testSerializer(t, cases, &PrometheusTextSerializer{})
+}
+
+func TestPrometheusTextSerializerTimestamps(t *testing.T) {
+	cases := []serializeCase{
+		{
+			desc:       "a regular Point with its timestamp",
+			inputPoint: testPointDefault,
+			output:     "cpu_usage_guest_nice" + promLabels + " 38.24311829 1451606400000\n",
+		},
+	}
+
+	testSerializer(t, cases, &PrometheusTextSerializer{Timestamps: true})
+}
+
+func TestPrometheusTextSerializerEscapes(t *testing.T) {
+	ts := time.Unix(0, 0)
+	p := NewPoint()
+	p.SetMeasurementName([]byte("disk-io"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("0path"), []byte("C:\\tmp \"a\"\n"))
+	p.AppendField([]byte("read.bytes"), 1)
+	p.AppendField([]byte("ok"), true)
+	p.AppendField([]byte("message"), []byte("not a number"))
+	cases := []serializeCase{
+		{
+			desc:       "a Point with invalid characters, a bool and a string",
+			inputPoint: p,
+			output: `disk_io_read_bytes{_path="C:\\tmp \"a\"\n"} 1` + "\n" +
+				`disk_io_ok{_path="C:\\tmp \"a\"\n"} 1` + "\n",
+		},
+	}
+
+	testSerializer(t, cases, &PrometheusTextSerializer{})
+}
@@ -15,6 +15,8 @@ type TimescaleDBSerializer struct{}
 // e.g.,
 // tags,<tag1>,<tag2>,<tag3>,...
 // <measurement>,<timestamp>,<field1>,<field2>,<field3>,...
+//
+// Missing fields are written as empty values, which the loader inserts as NULL.
 func (s *TimescaleDBSerializer) Serialize(p *Point, w io.Writer) error {
 	// Tag row first, prefixed with name 'tags'
 	buf := make([]byte, 0, 256)
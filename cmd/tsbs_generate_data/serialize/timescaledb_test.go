@@ -21,6 +21,11 @@ func TestTimescaleDBSerializerSerialize(t *testing.T) {
 			inputPoint: testPointMultiField,
 			output:     "tags,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b\ncpu,1451606400000000000,5000000000,38,38.24311829\n",
 		},
+		{
+			desc:       "a Point with missing fields",
+			inputPoint: testPointMissingField,
+			output:     "tags,hostname=host_0,region=eu-west-1,datacenter=eu-west-1b\ncpu,1451606400000000000,,38,\n",
+		},
 		{
 			desc:       "a Point with no tags",
 			inputPoint: testPointNoTags,
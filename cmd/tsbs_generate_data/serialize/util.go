@@ -5,9 +5,12 @@ import (
 	"strconv"
 )
 
-// Utility function for appending various data types to a byte string
+// Utility function for appending various data types to a byte string. Missing
+// (nil) values append nothing.
 func fastFormatAppend(v interface{}, buf []byte) []byte {
 	switch v.(type) {
+	case nil:
+		return buf
 	case int:
 		return strconv.AppendInt(buf, int64(v.(int)), 10)
 	case int64:
@@ -32,3 +35,31 @@ func fastFormatAppend(v interface{}, buf []byte) []byte {
 		panic(fmt.Sprintf("unknown field type for %#v", v))
 	}
 }
+
+// appendSanitized appends v to buf, replacing the characters that are not
+// allowed in OpenTSDB metric names and tags, or Graphite paths and tags, with
+// underscores
+func appendSanitized(buf, v []byte) []byte {
+	for _, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == '/':
+		default:
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendNumber appends v to buf like fastFormatAppend, except for booleans
+// which are appended as 1 or 0 for the formats only storing numbers
+func appendNumber(v interface{}, buf []byte) []byte {
+	if b, ok := v.(bool); ok {
+		if b {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	}
+	return fastFormatAppend(v, buf)
+}
@@ -12,6 +12,13 @@ func TestFastFormatAppend(t *testing.T) {
 		output      []byte
 		shouldPanic bool
 	}{
+		{
+			desc:        "fastFormatAppend should append nothing for a missing value",
+			inputString: []byte("values,"),
+			input:       nil,
+			output:      []byte("values,"),
+			shouldPanic: false,
+		},
 		{
 			desc:        "fastFormatAppend should properly append a float64 to a given byte string",
 			inputString: []byte("values,"),
@@ -90,3 +97,20 @@ func TestFastFormatAppend(t *testing.T) {
 		}
 	}
 }
+
+func TestAppendSanitized(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"host_0", "host_0"},
+		{"eu-west-1.b/2", "eu-west-1.b/2"},
+		{"a b;c=d~e", "a_b_c_d_e"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := string(appendSanitized([]byte("x="), []byte(c.input))); got != "x="+c.want {
+			t.Errorf("incorrect output for %q: got %q want %q", c.input, got, "x="+c.want)
+		}
+	}
+}
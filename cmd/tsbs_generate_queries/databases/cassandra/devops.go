@@ -35,8 +35,8 @@ func (d *Devops) getHostWhereWithHostnames(hostnames []string) []string {
 	return tagSet
 }
 
-func (d *Devops) getHostWhere(nHosts int) []string {
-	hostnames := d.GetRandomHosts(nHosts)
+func (d *Devops) getHostWhere(nHosts int, interval utils.TimeInterval) []string {
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	return d.getHostWhereWithHostnames(hostnames)
 }
 
@@ -52,7 +52,7 @@ func (d *Devops) getHostWhere(nHosts int) []string {
 func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
 	interval := d.Interval.RandWindow(timeRange)
 	metrics := devops.GetCPUMetricsSlice(numMetrics)
-	tagSet := d.getHostWhere(nHosts)
+	tagSet := d.getHostWhere(nHosts, interval)
 
 	tagSets := [][]string{}
 	tagSets = append(tagSets, tagSet)
@@ -109,7 +109,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 // GROUP BY hour ORDER BY hour
 func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 	interval := d.Interval.RandWindow(devops.MaxAllDuration)
-	tagSet := d.getHostWhere(nHosts)
+	tagSet := d.getHostWhere(nHosts, interval)
 
 	tagSets := [][]string{}
 	tagSets = append(tagSets, tagSet)
@@ -141,7 +141,7 @@ func (d *Devops) LastPointPerHost(qi query.Query) {
 // AND (hostname = '$HOST' OR hostname = '$HOST2'...)
 func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	interval := d.Interval.RandWindow(devops.HighCPUDuration)
-	tagSet := d.getHostWhere(nHosts)
+	tagSet := d.getHostWhere(nHosts, interval)
 
 	tagSets := [][]string{}
 	if len(tagSet) > 0 {
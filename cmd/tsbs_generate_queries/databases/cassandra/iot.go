@@ -0,0 +1,84 @@
+package cassandra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// IoT produces Cassandra-specific queries for the iot query types it
+// supports. Cassandra queries cannot group by tag or filter on the time of
+// the last reading, so stale-devices and fleet-averages are not implemented.
+type IoT struct {
+	*iot.Core
+}
+
+// NewIoT makes an IoT object ready to generate Queries.
+func NewIoT(start, end time.Time, scale int) *IoT {
+	return &IoT{iot.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.Cassandra
+func (i *IoT) GenerateEmptyQuery() query.Query {
+	return query.NewCassandra()
+}
+
+func (i *IoT) getDeviceWhere(nDevices int) []string {
+	tagSet := []string{}
+	for _, name := range i.GetRandomDevices(nDevices) {
+		tagSet = append(tagSet, "name="+name)
+	}
+	return tagSet
+}
+
+// LastLocPerDevice finds the last position of every device in the dataset
+func (i *IoT) LastLocPerDevice(qi query.Query) {
+	humanLabel := iot.GetLastLocLabel("Cassandra")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, i.Interval.StartString())
+	i.fillInQuery(qi, humanLabel, humanDesc, "", "readings", []string{"latitude", "longitude"}, i.Interval, nil)
+	q := qi.(*query.Cassandra)
+	q.ForEveryN = []byte("name,1")
+}
+
+// HighLoadDevices populates a query that gets the diagnostics of devices
+// whose load is over the threshold during a time period for a number of
+// devices (if 0, it will search all devices),
+// e.g. in psuedo-SQL:
+//
+// SELECT * FROM diagnostics
+// WHERE current_load > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (name = '$DEVICE' OR name = '$DEVICE2'...)
+func (i *IoT) HighLoadDevices(qi query.Query, nDevices int) {
+	interval := i.Interval.RandWindow(iot.HighLoadDuration)
+	tagSets := [][]string{}
+	if nDevices > 0 {
+		tagSets = append(tagSets, i.getDeviceWhere(nDevices))
+	}
+
+	humanLabel := iot.GetHighLoadLabel("Cassandra", nDevices)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	i.fillInQuery(qi, humanLabel, humanDesc, "", "diagnostics", []string{"current_load"}, interval, tagSets)
+	q := qi.(*query.Cassandra)
+	q.GroupByDuration = time.Hour
+	q.WhereClause = []byte(fmt.Sprintf("current_load,>,%.1f", iot.HighLoadThreshold))
+}
+
+func (i *IoT) fillInQuery(qi query.Query, humanLabel, humanDesc, aggType, measurement string, fields []string, interval utils.TimeInterval, tagSets [][]string) {
+	q := qi.(*query.Cassandra)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+
+	q.AggregationType = []byte(aggType)
+	q.MeasurementName = []byte(measurement)
+	q.FieldName = []byte(strings.Join(fields, ","))
+
+	q.TimeStart = interval.Start
+	q.TimeEnd = interval.End
+
+	q.TagSets = tagSets
+}
@@ -0,0 +1,193 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Devops produces ClickHouse-specific queries for all the devops query types.
+type Devops struct {
+	*devops.Core
+}
+
+// NewDevops makes an Devops object ready to generate Queries.
+func NewDevops(start, end time.Time, scale int) *Devops {
+	return &Devops{devops.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (d *Devops) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+func (d *Devops) getHostWhereWithHostnames(hostnames []string) string {
+	hostnameClauses := make([]string, len(hostnames))
+	for i, s := range hostnames {
+		hostnameClauses[i] = fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("hostname IN (%s)", strings.Join(hostnameClauses, ", "))
+}
+
+func (d *Devops) getHostWhereString(nhosts int, interval utils.TimeInterval) string {
+	hostnames := d.GetRandomHosts(nhosts, interval)
+	return d.getHostWhereWithHostnames(hostnames)
+}
+
+func (d *Devops) getSelectClausesAggMetrics(agg string, metrics []string) []string {
+	selectClauses := make([]string, len(metrics))
+	for i, m := range metrics {
+		selectClauses[i] = fmt.Sprintf("%[1]s(%[2]s) AS %[1]s_%[2]s", agg, m)
+	}
+
+	return selectClauses
+}
+
+// getTimeWhere returns the clause selecting the rows in interval
+func (d *Devops) getTimeWhere(interval utils.TimeInterval) string {
+	return fmt.Sprintf("time >= %s AND time < %s", timeLiteral(interval.Start), timeLiteral(interval.End))
+}
+
+const goTimeFmt = "2006-01-02 15:04:05.999999999"
+
+// timeLiteral returns the DateTime64 literal of t, at the precision of the
+// time column
+func timeLiteral(t time.Time) string {
+	return fmt.Sprintf("toDateTime64('%s', 9, 'UTC')", t.UTC().Format(goTimeFmt))
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g. in psuedo-SQL:
+//
+// SELECT minute, max(metric1), ..., max(metricN)
+// FROM cpu
+// WHERE hostname IN ('$HOSTNAME_1', ..., '$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY minute ORDER BY minute ASC
+func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.RandWindow(timeRange)
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
+
+	sql := fmt.Sprintf(`SELECT toStartOfMinute(time) AS minute, %s FROM cpu WHERE %s AND %s GROUP BY minute ORDER BY minute ASC`,
+		strings.Join(selectClauses, ", "),
+		d.getHostWhereString(nHosts, interval),
+		d.getTimeWhere(interval))
+
+	humanLabel := fmt.Sprintf("ClickHouse %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// GroupByOrderByLimit populates a query.Query that has a time WHERE clause, that groups by a truncated date, orders by that date, and takes a limit:
+// SELECT toStartOfMinute(time) AS minute, max(usage_user) FROM cpu
+// WHERE time < '$TIME'
+// GROUP BY minute ORDER BY minute DESC
+// LIMIT $LIMIT
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.RandWindow(time.Hour)
+
+	sql := fmt.Sprintf(`SELECT toStartOfMinute(time) AS minute, max(usage_user) FROM cpu WHERE time < %s GROUP BY minute ORDER BY minute DESC LIMIT 5`,
+		timeLiteral(interval.End))
+
+	humanLabel := "ClickHouse max cpu over last 5 min-intervals (random end)"
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. in psuedo-SQL:
+//
+// SELECT AVG(metric1), ..., AVG(metricN)
+// FROM cpu
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, hostname ORDER BY hour, hostname
+func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	interval := d.Interval.RandWindow(devops.DoubleGroupByDuration)
+
+	selectClauses := make([]string, numMetrics)
+	for i, m := range metrics {
+		selectClauses[i] = fmt.Sprintf("avg(%[1]s) AS mean_%[1]s", m)
+	}
+
+	sql := fmt.Sprintf(`SELECT toStartOfHour(time) AS hour, hostname, %s FROM cpu WHERE %s GROUP BY hour, hostname ORDER BY hour, hostname`,
+		strings.Join(selectClauses, ", "),
+		d.getTimeWhere(interval))
+
+	humanLabel := devops.GetDoubleGroupByLabel("ClickHouse", numMetrics)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in psuedo-SQL:
+//
+// SELECT MAX(metric1), ..., MAX(metricN)
+// FROM cpu WHERE hostname IN ('$HOSTNAME_1', ..., '$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour ORDER BY hour
+func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.MaxAllDuration)
+	metrics := devops.GetAllCPUMetrics()
+	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
+
+	sql := fmt.Sprintf(`SELECT toStartOfHour(time) AS hour, %s FROM cpu WHERE %s AND %s GROUP BY hour ORDER BY hour`,
+		strings.Join(selectClauses, ", "),
+		d.getHostWhereString(nHosts, interval),
+		d.getTimeWhere(interval))
+
+	humanLabel := devops.GetMaxAllLabel("ClickHouse", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// LastPointPerHost finds the last row for every host in the dataset
+func (d *Devops) LastPointPerHost(qi query.Query) {
+	sql := `SELECT * FROM cpu ORDER BY hostname, time DESC LIMIT 1 BY hostname`
+
+	humanLabel := "ClickHouse last row per host"
+	humanDesc := humanLabel
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// HighCPUForHosts populates a query that gets CPU metrics when the CPU has high
+// usage between a time period for a number of hosts (if 0, it will search all hosts),
+// e.g. in psuedo-SQL:
+//
+// SELECT * FROM cpu
+// WHERE usage_user > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND hostname IN ('$HOST', '$HOST2', ...)
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.HighCPUDuration)
+	var hostWhereClause string
+	if nHosts == 0 {
+		hostWhereClause = ""
+	} else {
+		hostWhereClause = fmt.Sprintf(" AND %s", d.getHostWhereString(nHosts, interval))
+	}
+
+	sql := fmt.Sprintf(`SELECT * FROM cpu WHERE usage_user > 90.0 AND %s%s`,
+		d.getTimeWhere(interval), hostWhereClause)
+
+	humanLabel := devops.GetHighCPULabel("ClickHouse", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, sql)
+}
+
+// fillInQuery fills in a query sent to the HTTP interface, with the SQL as
+// the body of the request
+func (d *Devops) fillInQuery(qi query.Query, humanLabel, humanDesc, sql string) {
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("POST")
+	q.Path = []byte("/")
+	q.Body = []byte(sql)
+}
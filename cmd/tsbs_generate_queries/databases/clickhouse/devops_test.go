@@ -0,0 +1,73 @@
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestDevopsGetHostWhereWithHostnames(t *testing.T) {
+	cases := []struct {
+		desc      string
+		hostnames []string
+		want      string
+	}{
+		{
+			desc:      "single host",
+			hostnames: []string{"foo1"},
+			want:      "hostname IN ('foo1')",
+		},
+		{
+			desc:      "multi host",
+			hostnames: []string{"foo1", "foo2"},
+			want:      "hostname IN ('foo1', 'foo2')",
+		},
+	}
+
+	for _, c := range cases {
+		d := NewDevops(time.Now(), time.Now(), 10)
+		if got := d.getHostWhereWithHostnames(c.hostnames); got != c.want {
+			t.Errorf("%s: incorrect output: got %s want %s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestDevopsGetSelectClausesAggMetrics(t *testing.T) {
+	d := NewDevops(time.Now(), time.Now(), 10)
+	got := strings.Join(d.getSelectClausesAggMetrics("max", []string{"foo", "bar"}), ",")
+	if want := "max(foo) AS max_foo,max(bar) AS max_bar"; got != want {
+		t.Errorf("incorrect output: got %s want %s", got, want)
+	}
+}
+
+func TestDevopsGetTimeWhere(t *testing.T) {
+	start := time.Date(2016, 1, 1, 1, 2, 3, 500, time.FixedZone("CET", 3600))
+	interval := utils.NewTimeInterval(start, start.Add(time.Hour))
+	d := NewDevops(time.Now(), time.Now(), 10)
+	want := "time >= toDateTime64('2016-01-01 00:02:03.0000005', 9, 'UTC') AND time < toDateTime64('2016-01-01 01:02:03.0000005', 9, 'UTC')"
+	if got := d.getTimeWhere(interval); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDevopsFillInQuery(t *testing.T) {
+	d := NewDevops(time.Now(), time.Now(), 10)
+	q := d.GenerateEmptyQuery()
+	d.fillInQuery(q, "label", "desc", "SELECT 1")
+	hq := q.(*query.HTTP)
+	if got := string(hq.Method); got != "POST" {
+		t.Errorf("incorrect method: got %s want POST", got)
+	}
+	if got := string(hq.Path); got != "/" {
+		t.Errorf("incorrect path: got %s want /", got)
+	}
+	if got := string(hq.Body); got != "SELECT 1" {
+		t.Errorf("incorrect body: got %s want SELECT 1", got)
+	}
+	if got := string(hq.HumanLabel); got != "label" {
+		t.Errorf("incorrect label: got %s want label", got)
+	}
+}
@@ -0,0 +1,243 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// maxResultWindow is the most documents a search returns by default
+const maxResultWindow = 10000
+
+// object is a JSON object of a search request
+type object map[string]interface{}
+
+// Devops produces Elasticsearch-specific queries for all the devops query
+// types. Each cpu reading is a document of the cpu indices, with the hostname
+// and the other tags of the host as keyword fields.
+type Devops struct {
+	*devops.Core
+}
+
+// NewDevops makes an Devops object ready to generate Queries.
+func NewDevops(start, end time.Time, scale int) *Devops {
+	return &Devops{devops.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (d *Devops) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// hostBuckets returns the number of terms buckets needed for a bucket per
+// host of the dataset, including the hosts replaced by new ones if any
+func (d *Devops) hostBuckets() int {
+	if d.HostLifetime <= 0 {
+		return d.Scale
+	}
+	return d.Scale * (int(d.Interval.Duration()/d.HostLifetime) + 2)
+}
+
+// getTimeFilter returns the filter selecting the documents in interval
+func getTimeFilter(interval utils.TimeInterval) object {
+	return object{"range": object{"@timestamp": object{
+		"gte":    interval.StartUnixNano() / 1e6,
+		"lt":     interval.EndUnixNano() / 1e6,
+		"format": "epoch_millis",
+	}}}
+}
+
+// getHostsFilter returns the filter selecting the documents of hostnames
+func getHostsFilter(hostnames []string) object {
+	return object{"terms": object{"hostname": hostnames}}
+}
+
+// getMetricAggs returns the aggregations computing agg of each metric, named
+// e.g. max_usage_user
+func getMetricAggs(agg string, metrics []string) object {
+	aggs := object{}
+	for _, m := range metrics {
+		aggs[agg+"_"+m] = object{agg: object{"field": m}}
+	}
+	return aggs
+}
+
+// getDateHistogram returns the aggregation bucketing documents by interval of
+// their timestamp, computing aggs in each bucket
+func getDateHistogram(interval string, aggs object) object {
+	return object{
+		"date_histogram": object{"field": "@timestamp", "fixed_interval": interval},
+		"aggs":           aggs,
+	}
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g.:
+//
+// {"size": 0, "query": {"bool": {"filter": [{"terms": {"hostname": [...]}}, {"range": {"@timestamp": ...}}]}},
+// "aggs": {"minute": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1m"},
+// "aggs": {"max_metric1": {"max": {"field": "metric1"}}, ...}}}}
+func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.RandWindow(timeRange)
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	body := object{
+		"size": 0,
+		"query": object{"bool": object{"filter": []object{
+			getHostsFilter(hostnames),
+			getTimeFilter(interval),
+		}}},
+		"aggs": object{"minute": getDateHistogram("1m", getMetricAggs("max", metrics))},
+	}
+
+	humanLabel := fmt.Sprintf("Elasticsearch %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// GroupByOrderByLimit selects the MAX of usage_user per minute before a
+// random end time, keeping the last 5 minutes with a bucket_sort:
+//
+// {"size": 0, "query": {"range": {"@timestamp": {"lt": ...}}},
+// "aggs": {"minute": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1m"},
+// "aggs": {"max_usage_user": {"max": {"field": "usage_user"}},
+// "limit": {"bucket_sort": {"sort": [{"_key": {"order": "desc"}}], "size": 5}}}}}}
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.RandWindow(time.Hour)
+
+	aggs := getMetricAggs("max", []string{"usage_user"})
+	aggs["limit"] = object{"bucket_sort": object{
+		"sort": []object{{"_key": object{"order": "desc"}}},
+		"size": 5,
+	}}
+	body := object{
+		"size": 0,
+		"query": object{"range": object{"@timestamp": object{
+			"lt":     interval.EndUnixNano() / 1e6,
+			"format": "epoch_millis",
+		}}},
+		"aggs": object{"minute": getDateHistogram("1m", aggs)},
+	}
+
+	humanLabel := "Elasticsearch max cpu over last 5 min-intervals (random end)"
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g.:
+//
+// {"size": 0, "query": {"range": {"@timestamp": ...}},
+// "aggs": {"hour": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1h"},
+// "aggs": {"hostname": {"terms": {"field": "hostname", "size": $SCALE},
+// "aggs": {"avg_metric1": {"avg": {"field": "metric1"}}, ...}}}}}}
+func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	interval := d.Interval.RandWindow(devops.DoubleGroupByDuration)
+
+	hosts := object{
+		"terms": object{"field": "hostname", "size": d.hostBuckets()},
+		"aggs":  getMetricAggs("avg", metrics),
+	}
+	body := object{
+		"size":  0,
+		"query": getTimeFilter(interval),
+		"aggs":  object{"hour": getDateHistogram("1h", object{"hostname": hosts})},
+	}
+
+	humanLabel := devops.GetDoubleGroupByLabel("Elasticsearch", numMetrics)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g.:
+//
+// {"size": 0, "query": {"bool": {"filter": [{"terms": {"hostname": [...]}}, {"range": {"@timestamp": ...}}]}},
+// "aggs": {"hour": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1h"},
+// "aggs": {"max_metric1": {"max": {"field": "metric1"}}, ...}}}}
+func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.MaxAllDuration)
+	metrics := devops.GetAllCPUMetrics()
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	body := object{
+		"size": 0,
+		"query": object{"bool": object{"filter": []object{
+			getHostsFilter(hostnames),
+			getTimeFilter(interval),
+		}}},
+		"aggs": object{"hour": getDateHistogram("1h", getMetricAggs("max", metrics))},
+	}
+
+	humanLabel := devops.GetMaxAllLabel("Elasticsearch", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// LastPointPerHost finds the last document for every host in the dataset:
+//
+// {"size": 0, "aggs": {"hostname": {"terms": {"field": "hostname", "size": $SCALE},
+// "aggs": {"last": {"top_hits": {"size": 1, "sort": [{"@timestamp": {"order": "desc"}}]}}}}}}
+func (d *Devops) LastPointPerHost(qi query.Query) {
+	body := object{
+		"size": 0,
+		"aggs": object{"hostname": object{
+			"terms": object{"field": "hostname", "size": d.hostBuckets()},
+			"aggs": object{"last": object{"top_hits": object{
+				"size": 1,
+				"sort": []object{{"@timestamp": object{"order": "desc"}}},
+			}}},
+		}},
+	}
+
+	humanLabel := "Elasticsearch last row per host"
+	humanDesc := humanLabel
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// HighCPUForHosts populates a query that gets CPU metrics when the CPU has high
+// usage between a time period for a number of hosts (if 0, it will search all hosts),
+// returning up to the 10000 documents a search can return by default:
+//
+// {"size": 10000, "query": {"bool": {"filter": [{"range": {"usage_user": {"gt": 90}}},
+// {"range": {"@timestamp": ...}}, {"terms": {"hostname": [...]}}]}}}
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.HighCPUDuration)
+	filters := []object{
+		{"range": object{"usage_user": object{"gt": 90.0}}},
+		getTimeFilter(interval),
+	}
+	if nHosts > 0 {
+		filters = append(filters, getHostsFilter(d.GetRandomHosts(nHosts, interval)))
+	}
+	body := object{
+		"size":  maxResultWindow,
+		"query": object{"bool": object{"filter": filters}},
+	}
+
+	humanLabel := devops.GetHighCPULabel("Elasticsearch", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, body)
+}
+
+// fillInQuery fills in a search of the cpu indices, with body as the body of
+// the request
+func (d *Devops) fillInQuery(qi query.Query, humanLabel, humanDesc string, body object) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal query: %v", err))
+	}
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("POST")
+	q.Path = []byte("/cpu-*/_search")
+	q.Body = b
+}
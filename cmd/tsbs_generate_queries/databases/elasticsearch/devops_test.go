@@ -0,0 +1,77 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+func marshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("could not marshal %v: %v", v, err)
+	}
+	return string(b)
+}
+
+func TestGetTimeFilter(t *testing.T) {
+	start := time.Date(2016, 1, 1, 1, 0, 0, 5e5, time.FixedZone("CET", 3600))
+	interval := utils.NewTimeInterval(start, start.Add(time.Hour))
+	want := `{"range":{"@timestamp":{"format":"epoch_millis","gte":1451606400000,"lt":1451610000000}}}`
+	if got := marshal(t, getTimeFilter(interval)); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGetMetricAggs(t *testing.T) {
+	want := `{"max_bar":{"max":{"field":"bar"}},"max_foo":{"max":{"field":"foo"}}}`
+	if got := marshal(t, getMetricAggs("max", []string{"foo", "bar"})); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDevopsHostBuckets(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDevops(start, start.Add(24*time.Hour), 10)
+	if got := d.hostBuckets(); got != 10 {
+		t.Errorf("incorrect buckets without churn: got %d want 10", got)
+	}
+	d.SetHostLifetime(6 * time.Hour)
+	if got := d.hostBuckets(); got != 60 {
+		t.Errorf("incorrect buckets with churn: got %d want 60", got)
+	}
+}
+
+func TestDevopsGroupByOrderByLimit(t *testing.T) {
+	d := NewDevops(time.Now(), time.Now().Add(2*time.Hour), 10)
+	q := d.GenerateEmptyQuery()
+	d.GroupByOrderByLimit(q)
+	hq := q.(*query.HTTP)
+	if got := string(hq.Method); got != "POST" {
+		t.Errorf("incorrect method: got %s want POST", got)
+	}
+	if got := string(hq.Path); got != "/cpu-*/_search" {
+		t.Errorf("incorrect path: got %s want /cpu-*/_search", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(hq.Body, &body); err != nil {
+		t.Fatalf("could not unmarshal body %s: %v", hq.Body, err)
+	}
+	want := `{"minute":{"aggs":{"limit":{"bucket_sort":{"size":5,"sort":[{"_key":{"order":"desc"}}]}},"max_usage_user":{"max":{"field":"usage_user"}}},"date_histogram":{"field":"@timestamp","fixed_interval":"1m"}}}`
+	if got := marshal(t, body["aggs"]); got != want {
+		t.Errorf("incorrect aggs:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDevopsLastPointPerHost(t *testing.T) {
+	d := NewDevops(time.Now(), time.Now().Add(time.Hour), 10)
+	q := d.GenerateEmptyQuery()
+	d.LastPointPerHost(q)
+	want := `{"aggs":{"hostname":{"aggs":{"last":{"top_hits":{"size":1,"sort":[{"@timestamp":{"order":"desc"}}]}}},"terms":{"field":"hostname","size":10}}},"size":0}`
+	if got := string(q.(*query.HTTP).Body); got != want {
+		t.Errorf("incorrect body:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package graphite
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Devops produces Graphite-specific queries for the devops query types. Each
+// cpu field is a separate tagged series, e.g. cpu.usage_user, with the
+// hostname and the other tags of the host as its tags. Graphite has no way
+// to fetch the last point of each series, so there is no lastpoint query.
+type Devops struct {
+	*devops.Core
+}
+
+// NewDevops makes an Devops object ready to generate Queries.
+func NewDevops(start, end time.Time, scale int) *Devops {
+	return &Devops{devops.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (d *Devops) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// getSeriesByTag returns the seriesByTag call selecting the series of the
+// given cpu metrics for the given hosts, or for every host if there are none
+func getSeriesByTag(metrics, hostnames []string) string {
+	if len(metrics) == 0 {
+		panic("BUG: must be at least one metric name in clause")
+	}
+	exprs := []string{}
+	if len(metrics) == 1 {
+		exprs = append(exprs, fmt.Sprintf("'name=cpu.%s'", metrics[0]))
+	} else {
+		exprs = append(exprs, fmt.Sprintf("'name=~^cpu\\.(%s)$'", strings.Join(metrics, "|")))
+	}
+	if len(hostnames) == 1 {
+		exprs = append(exprs, fmt.Sprintf("'hostname=%s'", hostnames[0]))
+	} else if len(hostnames) > 1 {
+		exprs = append(exprs, fmt.Sprintf("'hostname=~^(%s)$'", strings.Join(hostnames, "|")))
+	}
+	return fmt.Sprintf("seriesByTag(%s)", strings.Join(exprs, ","))
+}
+
+// getRollup returns the target aggregating the series of each metric, or of
+// each metric and host if groupByHost is set, with agg in buckets of bucket
+func getRollup(series, agg, bucket string, groupByHost bool) string {
+	tags := "'name'"
+	if groupByHost {
+		tags += ",'hostname'"
+	}
+	return fmt.Sprintf("summarize(groupByTags(%s,'%s',%s),'%s','%s')", series, agg, tags, bucket, agg)
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g.:
+//
+// summarize(groupByTags(seriesByTag('name=~^cpu\.(metric1|...|metricN)$','hostname=~^(host_1|...|host_N)$'),'max','name'),'1min','max')
+func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.RandWindow(timeRange)
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	humanLabel := fmt.Sprintf("Graphite %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	target := getRollup(getSeriesByTag(metrics, hostnames), "max", "1min", false)
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, target)
+}
+
+// GroupByOrderByLimit selects the MAX of usage_user per minute of all hosts,
+// for the last 5 minutes before a random end time. Graphite cannot order or
+// limit, so the 5 minutes are the range of the query:
+//
+// summarize(groupByTags(seriesByTag('name=cpu.usage_user'),'max','name'),'1min','max')
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.RandWindow(time.Hour)
+	interval = utils.NewTimeInterval(interval.End.Add(-5*time.Minute), interval.End)
+
+	humanLabel := "Graphite max cpu over last 5 min-intervals (random end)"
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
+	target := getRollup(getSeriesByTag([]string{"usage_user"}, nil), "max", "1min", false)
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, target)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g.:
+//
+// summarize(groupByTags(seriesByTag('name=~^cpu\.(metric1|...|metricN)$'),'avg','name','hostname'),'1h','avg')
+func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	interval := d.Interval.RandWindow(devops.DoubleGroupByDuration)
+
+	humanLabel := devops.GetDoubleGroupByLabel("Graphite", numMetrics)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	target := getRollup(getSeriesByTag(metrics, nil), "avg", "1h", true)
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, target)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g.:
+//
+// summarize(groupByTags(seriesByTag('name=~^cpu\.(metric1|...|metricN)$','hostname=~^(host_1|...|host_N)$'),'max','name'),'1h','max')
+func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.MaxAllDuration)
+	metrics := devops.GetAllCPUMetrics()
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	humanLabel := devops.GetMaxAllLabel("Graphite", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	target := getRollup(getSeriesByTag(metrics, hostnames), "max", "1h", false)
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, target)
+}
+
+// HighCPUForHosts populates a query that gets the usage_user series whose
+// value went above 90 during a time period for a number of hosts (if 0, it
+// will search all hosts),
+// e.g.:
+//
+// maximumAbove(seriesByTag('name=cpu.usage_user','hostname=~^(host_1|...|host_N)$'),90)
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.HighCPUDuration)
+	var hostnames []string
+	if nHosts > 0 {
+		hostnames = d.GetRandomHosts(nHosts, interval)
+	}
+
+	humanLabel := devops.GetHighCPULabel("Graphite", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	target := fmt.Sprintf("maximumAbove(%s,90)", getSeriesByTag([]string{"usage_user"}, hostnames))
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, target)
+}
+
+func (d *Devops) fillInQuery(qi query.Query, humanLabel, humanDesc string, interval utils.TimeInterval, target string) {
+	v := url.Values{}
+	v.Set("target", target)
+	v.Set("from", strconv.FormatInt(interval.StartUnixNano()/1e9, 10))
+	v.Set("until", strconv.FormatInt(interval.EndUnixNano()/1e9, 10))
+	v.Set("format", "json")
+
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/render?%s", v.Encode()))
+	q.Body = nil
+}
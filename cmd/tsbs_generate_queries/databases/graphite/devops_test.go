@@ -0,0 +1,78 @@
+package graphite
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestGetSeriesByTag(t *testing.T) {
+	cases := []struct {
+		desc      string
+		metrics   []string
+		hostnames []string
+		want      string
+	}{
+		{
+			desc:    "single metric, all hosts",
+			metrics: []string{"usage_user"},
+			want:    "seriesByTag('name=cpu.usage_user')",
+		},
+		{
+			desc:      "single metric, single host",
+			metrics:   []string{"usage_user"},
+			hostnames: []string{"host_1"},
+			want:      "seriesByTag('name=cpu.usage_user','hostname=host_1')",
+		},
+		{
+			desc:      "multiple metrics, multiple hosts",
+			metrics:   []string{"usage_user", "usage_system"},
+			hostnames: []string{"host_1", "host_2"},
+			want:      "seriesByTag('name=~^cpu\\.(usage_user|usage_system)$','hostname=~^(host_1|host_2)$')",
+		},
+	}
+	for _, c := range cases {
+		if got := getSeriesByTag(c.metrics, c.hostnames); got != c.want {
+			t.Errorf("%s: incorrect output:\ngot\n%s\nwant\n%s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestGetRollup(t *testing.T) {
+	want := "summarize(groupByTags(foo,'avg','name','hostname'),'1h','avg')"
+	if got := getRollup("foo", "avg", "1h", true); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+	want = "summarize(groupByTags(foo,'max','name'),'1min','max')"
+	if got := getRollup("foo", "max", "1min", false); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDevopsHighCPUForHosts(t *testing.T) {
+	s := time.Unix(0, 0)
+	d := NewDevops(s, s.Add(24*time.Hour), 10)
+	q := d.GenerateEmptyQuery()
+	d.HighCPUForHosts(q, 0)
+	hq := q.(*query.HTTP)
+	if got := string(hq.Method); got != "GET" {
+		t.Errorf("incorrect method: got %s want GET", got)
+	}
+	path := string(hq.Path)
+	if !strings.HasPrefix(path, "/render?") {
+		t.Fatalf("incorrect path: %s", path)
+	}
+	v, err := url.ParseQuery(strings.TrimPrefix(path, "/render?"))
+	if err != nil {
+		t.Fatalf("could not parse query: %v", err)
+	}
+	if got, want := v.Get("target"), "maximumAbove(seriesByTag('name=cpu.usage_user'),90)"; got != want {
+		t.Errorf("incorrect target: got %s want %s", got, want)
+	}
+	if got := v.Get("format"); got != "json" {
+		t.Errorf("incorrect format: got %s want json", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
 	"github.com/hagen1778/tsbs/query"
 )
 
@@ -35,8 +36,8 @@ func (d *Devops) getHostWhereWithHostnames(hostnames []string) string {
 	return "(" + combinedHostnameClause + ")"
 }
 
-func (d *Devops) getHostWhereString(nHosts int) string {
-	hostnames := d.GetRandomHosts(nHosts)
+func (d *Devops) getHostWhereString(nHosts int, interval utils.TimeInterval) string {
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	return d.getHostWhereWithHostnames(hostnames)
 }
 
@@ -62,7 +63,7 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 	interval := d.Interval.RandWindow(timeRange)
 	metrics := devops.GetCPUMetricsSlice(numMetrics)
 	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
-	whereHosts := d.getHostWhereString(nHosts)
+	whereHosts := d.getHostWhereString(nHosts, interval)
 
 	humanLabel := fmt.Sprintf("Influx %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -112,7 +113,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 // GROUP BY hour ORDER BY hour
 func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 	interval := d.Interval.RandWindow(devops.MaxAllDuration)
-	whereHosts := d.getHostWhereString(nHosts)
+	whereHosts := d.getHostWhereString(nHosts, interval)
 	selectClauses := d.getSelectClausesAggMetrics("max", devops.GetAllCPUMetrics())
 
 	humanLabel := devops.GetMaxAllLabel("Influx", nHosts)
@@ -143,7 +144,7 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	if nHosts == 0 {
 		hostWhereClause = ""
 	} else {
-		hostWhereClause = fmt.Sprintf("and %s", d.getHostWhereString(nHosts))
+		hostWhereClause = fmt.Sprintf("and %s", d.getHostWhereString(nHosts, interval))
 	}
 
 	humanLabel := devops.GetHighCPULabel("Influx", nHosts)
@@ -0,0 +1,112 @@
+package influx
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// IoT produces Influx-specific queries for all the iot query types.
+type IoT struct {
+	*iot.Core
+}
+
+// NewIoT makes an IoT object ready to generate Queries.
+func NewIoT(start, end time.Time, scale int) *IoT {
+	return &IoT{iot.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (i *IoT) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+func (i *IoT) getDeviceWhereString(nDevices int) string {
+	nameClauses := []string{}
+	for _, s := range i.GetRandomDevices(nDevices) {
+		nameClauses = append(nameClauses, fmt.Sprintf("name = '%s'", s))
+	}
+	return "(" + strings.Join(nameClauses, " or ") + ")"
+}
+
+// StaleDevices finds the devices that reported during a random hour, but
+// not during its last minutes,
+// e.g. in psuedo-SQL:
+//
+// SELECT name, last(time) FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY name HAVING last(time) < '$HOUR_END' - '$THRESHOLD'
+func (i *IoT) StaleDevices(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.StaleDevicesDuration)
+	threshold := interval.End.Add(-iot.StaleDevicesThreshold).Format(time.RFC3339)
+
+	humanLabel := iot.GetStaleDevicesLabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT * from (SELECT last(velocity) from readings where time >= '%s' and time < '%s' group by name) where time < '%s'", interval.StartString(), interval.EndString(), threshold)
+	i.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// FleetAverages selects the mean velocity and fuel consumption per fleet
+// per hour, e.g. in psuedo-SQL:
+//
+// SELECT AVG(velocity), AVG(fuel_consumption)
+// FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, fleet ORDER BY hour, fleet
+func (i *IoT) FleetAverages(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.FleetAveragesDuration)
+	selectClauses := make([]string, len(iot.FleetAveragesMetrics))
+	for j, m := range iot.FleetAveragesMetrics {
+		selectClauses[j] = fmt.Sprintf("mean(%s)", m)
+	}
+
+	humanLabel := iot.GetFleetAveragesLabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT %s from readings where time >= '%s' and time < '%s' group by time(1h),fleet", strings.Join(selectClauses, ", "), interval.StartString(), interval.EndString())
+	i.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// LastLocPerDevice finds the last position of every device in the dataset
+func (i *IoT) LastLocPerDevice(qi query.Query) {
+	humanLabel := iot.GetLastLocLabel("Influx")
+	humanDesc := humanLabel + ": readings"
+	influxql := "SELECT latitude, longitude from readings group by \"name\" order by time desc limit 1"
+	i.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// HighLoadDevices populates a query that gets the diagnostics of devices
+// whose load is over the threshold during a time period for a number of
+// devices (if 0, it will search all devices),
+// e.g. in psuedo-SQL:
+//
+// SELECT current_load FROM diagnostics
+// WHERE current_load > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (name = '$DEVICE' OR name = '$DEVICE2'...)
+func (i *IoT) HighLoadDevices(qi query.Query, nDevices int) {
+	interval := i.Interval.RandWindow(iot.HighLoadDuration)
+	var deviceWhereClause string
+	if nDevices > 0 {
+		deviceWhereClause = fmt.Sprintf("and %s", i.getDeviceWhereString(nDevices))
+	}
+
+	humanLabel := iot.GetHighLoadLabel("Influx", nDevices)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT current_load from diagnostics where current_load > %.1f %s and time >= '%s' and time < '%s' group by name", iot.HighLoadThreshold, deviceWhereClause, interval.StartString(), interval.EndString())
+	i.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+func (i *IoT) fillInQuery(qi query.Query, humanLabel, humanDesc, influxql string) {
+	v := url.Values{}
+	v.Set("q", influxql)
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/query?%s", v.Encode()))
+	q.Body = nil
+}
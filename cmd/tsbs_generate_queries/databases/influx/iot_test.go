@@ -0,0 +1,39 @@
+package influx
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestIoTHighLoadDevices(t *testing.T) {
+	cases := []struct {
+		desc      string
+		nDevices  int
+		wantNames bool
+	}{
+		{desc: "all devices", nDevices: 0},
+		{desc: "some devices", nDevices: 2, wantNames: true},
+	}
+
+	for _, c := range cases {
+		i := NewIoT(time.Now(), time.Now().Add(24*time.Hour), 10)
+		q := i.GenerateEmptyQuery()
+		i.HighLoadDevices(q, c.nDevices)
+
+		path, err := url.ParseQuery(strings.TrimPrefix(string(q.(*query.HTTP).Path), "/query?"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		influxql := path.Get("q")
+		if !strings.HasPrefix(influxql, "SELECT current_load from diagnostics where current_load > 90.0 ") {
+			t.Errorf("%s: incorrect query: %s", c.desc, influxql)
+		}
+		if got := strings.Count(influxql, "name = 'truck_"); got != c.nDevices {
+			t.Errorf("%s: incorrect number of devices: got %d want %d", c.desc, got, c.nDevices)
+		}
+	}
+}
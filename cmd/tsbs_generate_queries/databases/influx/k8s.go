@@ -0,0 +1,130 @@
+package influx
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/k8s"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// K8s produces Influx-specific queries for all the k8s query types.
+type K8s struct {
+	*k8s.Core
+}
+
+// NewK8s makes a K8s object ready to generate Queries.
+func NewK8s(start, end time.Time, scale int) *K8s {
+	return &K8s{k8s.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (k *K8s) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// cpuRollup returns the cpu cores used per group every rollup interval,
+// summed over the pods of each group, in the given time range. The rate of
+// the counter of each pod skips the resets of container restarts.
+func (k *K8s) cpuRollup(group, where string, interval utils.TimeInterval) string {
+	bucket := fmt.Sprintf("%dm", int(k8s.RollupInterval.Minutes()))
+	return fmt.Sprintf("SELECT sum(cores) AS cores from (SELECT non_negative_derivative(max(usage_seconds_total), 1s) AS cores from container_cpu where time >= '%s' and time < '%s'%s group by time(%s), %s, pod) group by time(%s), %s",
+		interval.StartString(), interval.EndString(), where, bucket, group, bucket, group)
+}
+
+// NamespaceCPU selects the cpu cores used per namespace every 5 minutes of a
+// random hour, e.g. in psuedo-SQL:
+//
+// SELECT sum(cores) FROM (
+// SELECT non_negative_derivative(max(usage_seconds_total), 1s) AS cores
+// FROM container_cpu WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY time(5m), namespace, pod)
+// GROUP BY time(5m), namespace
+func (k *K8s) NamespaceCPU(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+
+	humanLabel := k8s.GetNamespaceCPULabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := k.cpuRollup("namespace", "", interval)
+	k.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// NamespaceMemory selects the memory working set per namespace every 5
+// minutes of a random hour, e.g. in psuedo-SQL:
+//
+// SELECT sum(working_set) FROM (
+// SELECT mean(working_set_bytes) AS working_set
+// FROM container_memory WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY time(5m), namespace, pod)
+// GROUP BY time(5m), namespace
+func (k *K8s) NamespaceMemory(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	bucket := fmt.Sprintf("%dm", int(k8s.RollupInterval.Minutes()))
+
+	humanLabel := k8s.GetNamespaceMemoryLabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT sum(working_set) AS working_set_bytes from (SELECT mean(working_set_bytes) AS working_set from container_memory where time >= '%s' and time < '%s' group by time(%s), namespace, pod) group by time(%s), namespace",
+		interval.StartString(), interval.EndString(), bucket, bucket)
+	k.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// DeploymentCPU selects the cpu cores used per deployment of a random
+// namespace every 5 minutes of a random hour, as NamespaceCPU does per
+// namespace
+func (k *K8s) DeploymentCPU(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	namespace := k.GetRandomNamespace()
+
+	humanLabel := k8s.GetDeploymentCPULabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s, %s", humanLabel, namespace, interval.StartString())
+	influxql := k.cpuRollup("deployment", fmt.Sprintf(" and namespace = '%s'", namespace), interval)
+	k.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// TopPodsCPU selects the k pods that used the most cpu time during a random
+// hour, e.g. in psuedo-SQL:
+//
+// SELECT top(cpu_seconds, pod, $K) FROM (
+// SELECT spread(usage_seconds_total) AS cpu_seconds
+// FROM container_cpu WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY pod)
+func (k *K8s) TopPodsCPU(qi query.Query, nPods int) {
+	interval := k.Interval.RandWindow(k8s.TopPodsDuration)
+
+	humanLabel := k8s.GetTopPodsCPULabel("Influx", nPods)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT top(cpu_seconds, pod, %d) from (SELECT spread(usage_seconds_total) AS cpu_seconds from container_cpu where time >= '%s' and time < '%s' group by pod)",
+		nPods, interval.StartString(), interval.EndString())
+	k.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+// PodRestarts selects the pods whose container restarted during a random
+// hour, with their number of restarts, e.g. in psuedo-SQL:
+//
+// SELECT * FROM (
+// SELECT spread(status_restarts_total) AS restarts
+// FROM kube_pod_container WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY namespace, pod)
+// WHERE restarts > 0
+func (k *K8s) PodRestarts(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.PodRestartsDuration)
+
+	humanLabel := k8s.GetPodRestartsLabel("Influx")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	influxql := fmt.Sprintf("SELECT * from (SELECT spread(status_restarts_total) AS restarts from kube_pod_container where time >= '%s' and time < '%s' group by namespace, pod) where restarts > 0",
+		interval.StartString(), interval.EndString())
+	k.fillInQuery(qi, humanLabel, humanDesc, influxql)
+}
+
+func (k *K8s) fillInQuery(qi query.Query, humanLabel, humanDesc, influxql string) {
+	v := url.Values{}
+	v.Set("q", influxql)
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/query?%s", v.Encode()))
+	q.Body = nil
+}
@@ -0,0 +1,68 @@
+package influx
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestK8sQueries(t *testing.T) {
+	k := NewK8s(time.Now(), time.Now().Add(24*time.Hour), 10)
+	cases := []struct {
+		desc string
+		fill func(query.Query)
+		want string
+	}{
+		{
+			desc: "namespace-cpu",
+			fill: k.NamespaceCPU,
+			want: "SELECT sum(cores) AS cores from (SELECT non_negative_derivative(max(usage_seconds_total), 1s) AS cores from container_cpu where time >= ",
+		},
+		{
+			desc: "deployment-cpu",
+			fill: k.DeploymentCPU,
+			want: "SELECT sum(cores) AS cores from (SELECT non_negative_derivative(max(usage_seconds_total), 1s) AS cores from container_cpu where time >= ",
+		},
+		{
+			desc: "top-pods-cpu",
+			fill: func(q query.Query) { k.TopPodsCPU(q, 5) },
+			want: "SELECT top(cpu_seconds, pod, 5) from (SELECT spread(usage_seconds_total) AS cpu_seconds from container_cpu where ",
+		},
+		{
+			desc: "pod-restarts",
+			fill: k.PodRestarts,
+			want: "SELECT * from (SELECT spread(status_restarts_total) AS restarts from kube_pod_container where ",
+		},
+	}
+
+	for _, c := range cases {
+		q := k.GenerateEmptyQuery()
+		c.fill(q)
+
+		path, err := url.ParseQuery(strings.TrimPrefix(string(q.(*query.HTTP).Path), "/query?"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		if influxql := path.Get("q"); !strings.HasPrefix(influxql, c.want) {
+			t.Errorf("%s: incorrect query: %s", c.desc, influxql)
+		}
+	}
+}
+
+func TestK8sDeploymentCPUNamespace(t *testing.T) {
+	k := NewK8s(time.Now(), time.Now().Add(24*time.Hour), 10)
+	q := k.GenerateEmptyQuery()
+	k.DeploymentCPU(q)
+
+	path, err := url.ParseQuery(strings.TrimPrefix(string(q.(*query.HTTP).Path), "/query?"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	influxql := path.Get("q")
+	if !strings.Contains(influxql, " and namespace = '") || !strings.HasSuffix(influxql, "group by time(5m), deployment") {
+		t.Errorf("incorrect query: %s", influxql)
+	}
+}
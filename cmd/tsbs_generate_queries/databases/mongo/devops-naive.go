@@ -45,7 +45,7 @@ func (d *NaiveDevops) GenerateEmptyQuery() query.Query {
 // GROUP BY minute ORDER BY minute ASC
 func (d *NaiveDevops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
 	interval := d.Interval.RandWindow(timeRange)
-	hostnames := d.GetRandomHosts(nHosts)
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	metrics := devops.GetCPUMetricsSlice(numMetrics)
 
 	bucketNano := time.Minute.Nanoseconds()
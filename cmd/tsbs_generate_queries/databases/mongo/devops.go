@@ -102,7 +102,7 @@ func getTimeFilterDocs(interval utils.TimeInterval) []interface{} {
 // GROUP BY minute ORDER BY minute ASC
 func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
 	interval := d.Interval.RandWindow(timeRange)
-	hostnames := d.GetRandomHosts(nHosts)
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	metrics := devops.GetCPUMetricsSlice(numMetrics)
 	docs := getTimeFilterDocs(interval)
 	bucketNano := time.Minute.Nanoseconds()
@@ -170,7 +170,7 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 // GROUP BY hour ORDER BY hour
 func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 	interval := d.Interval.RandWindow(devops.MaxAllDuration)
-	hostnames := d.GetRandomHosts(nHosts)
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	docs := getTimeFilterDocs(interval)
 	bucketNano := time.Hour.Nanoseconds()
 	metrics := devops.GetAllCPUMetrics()
@@ -318,7 +318,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 // AND (hostname = '$HOST' OR hostname = '$HOST2'...)
 func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	interval := d.Interval.RandWindow(devops.HighCPUDuration)
-	hostnames := d.GetRandomHosts(nHosts)
+	hostnames := d.GetRandomHosts(nHosts, interval)
 	docs := getTimeFilterDocs(interval)
 
 	pipelineQuery := []bson.M{}
@@ -0,0 +1,182 @@
+package mongo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// IoT produces Mongo-specific queries for the iot use case.
+type IoT struct {
+	*iot.Core
+}
+
+// NewIoT makes an IoT object ready to generate Queries.
+func NewIoT(start, end time.Time, scale int) *IoT {
+	return &IoT{iot.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.Mongo
+func (i *IoT) GenerateEmptyQuery() query.Query {
+	return query.NewMongo()
+}
+
+// getIoTMatchPipeline returns the start of a pipeline that selects the events
+// of the given measurement in the given interval
+func getIoTMatchPipeline(measurement string, interval utils.TimeInterval) []bson.M {
+	pipelineQuery := []bson.M{
+		{
+			"$match": bson.M{
+				"measurement": measurement,
+				"key_id": bson.M{
+					"$in": getTimeFilterDocs(interval),
+				},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":    0,
+				"events": 1,
+				"key_id": 1,
+				"tags":   1,
+			},
+		},
+	}
+	return append(pipelineQuery, getTimeFilterPipeline(interval)...)
+}
+
+// StaleDevices finds the devices that reported during a random hour, but
+// not during its last minutes,
+// e.g. in psuedo-SQL:
+//
+// SELECT name, max(time) FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY name HAVING max(time) < '$HOUR_END' - '$THRESHOLD'
+func (i *IoT) StaleDevices(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.StaleDevicesDuration)
+	threshold := interval.End.Add(-iot.StaleDevicesThreshold).UnixNano()
+
+	pipelineQuery := getIoTMatchPipeline("readings", interval)
+	pipelineQuery = append(pipelineQuery, []bson.M{
+		{
+			"$group": bson.M{
+				"_id":       bson.M{"name": "$tags.name"},
+				"last_time": bson.M{"$max": "$events.timestamp_ns"},
+			},
+		},
+		{"$match": bson.M{"last_time": bson.M{"$lt": threshold}}},
+	}...)
+
+	humanLabel := iot.GetStaleDevicesLabel("Mongo")
+	i.fillInQuery(qi, humanLabel, interval, pipelineQuery)
+}
+
+// FleetAverages selects the mean velocity and fuel consumption per fleet
+// per hour, e.g. in psuedo-SQL:
+//
+// SELECT AVG(velocity), AVG(fuel_consumption)
+// FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, fleet ORDER BY hour, fleet
+func (i *IoT) FleetAverages(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.FleetAveragesDuration)
+	bucketNano := time.Hour.Nanoseconds()
+
+	pipelineQuery := getIoTMatchPipeline("readings", interval)
+	pipelineQuery = append(pipelineQuery, bson.M{
+		"$project": bson.M{
+			"time_bucket": bson.M{
+				"$subtract": []interface{}{
+					"$events.timestamp_ns",
+					bson.M{"$mod": []interface{}{"$events.timestamp_ns", bucketNano}},
+				},
+			},
+			"tags":   "$tags.fleet",
+			"events": 1,
+		},
+	})
+
+	group := bson.M{
+		"$group": bson.M{
+			"_id": bson.M{
+				"time":  "$time_bucket",
+				"fleet": "$tags",
+			},
+		},
+	}
+	resultMap := group["$group"].(bson.M)
+	for _, metric := range iot.FleetAveragesMetrics {
+		resultMap["avg_"+metric] = bson.M{"$avg": "$events." + metric}
+	}
+	pipelineQuery = append(pipelineQuery, group)
+	pipelineQuery = append(pipelineQuery, []bson.M{
+		{"$sort": bson.M{"_id.fleet": 1}},
+		{"$sort": bson.M{"_id.time": 1}},
+	}...)
+
+	humanLabel := iot.GetFleetAveragesLabel("Mongo")
+	i.fillInQuery(qi, humanLabel, interval, pipelineQuery)
+}
+
+// LastLocPerDevice finds the last position of every device in the dataset
+func (i *IoT) LastLocPerDevice(qi query.Query) {
+	pipelineQuery := []bson.M{
+		{"$match": bson.M{"measurement": "readings"}},
+		{"$sort": bson.M{"key_id": 1}},
+		{"$unwind": "$events"},
+		{
+			"$group": bson.M{
+				"_id":       bson.M{"name": "$tags.name"},
+				"time":      bson.M{"$last": "$events.timestamp_ns"},
+				"latitude":  bson.M{"$last": "$events.latitude"},
+				"longitude": bson.M{"$last": "$events.longitude"},
+			},
+		},
+	}
+
+	humanLabel := iot.GetLastLocLabel("Mongo")
+	q := qi.(*query.Mongo)
+	q.HumanLabel = []byte(humanLabel)
+	q.BsonDoc = pipelineQuery
+	q.CollectionName = []byte("point_data")
+	q.HumanDescription = []byte(fmt.Sprintf("%s (%s)", humanLabel, q.CollectionName))
+}
+
+// HighLoadDevices populates a query that gets the diagnostics of devices
+// whose load is over the threshold during a time period for a number of
+// devices (if 0, it will search all devices),
+// e.g. in psuedo-SQL:
+//
+// SELECT * FROM diagnostics
+// WHERE current_load > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (name = '$DEVICE' OR name = '$DEVICE2'...)
+func (i *IoT) HighLoadDevices(qi query.Query, nDevices int) {
+	interval := i.Interval.RandWindow(iot.HighLoadDuration)
+
+	pipelineQuery := getIoTMatchPipeline("diagnostics", interval)
+	if nDevices > 0 {
+		matchMap := pipelineQuery[0]["$match"].(bson.M)
+		matchMap["tags.name"] = bson.M{"$in": i.GetRandomDevices(nDevices)}
+	}
+	pipelineQuery = append(pipelineQuery, bson.M{
+		"$match": bson.M{
+			"events.current_load": bson.M{"$gt": iot.HighLoadThreshold},
+		},
+	})
+
+	humanLabel := iot.GetHighLoadLabel("Mongo", nDevices)
+	i.fillInQuery(qi, humanLabel, interval, pipelineQuery)
+}
+
+func (i *IoT) fillInQuery(qi query.Query, humanLabel string, interval utils.TimeInterval, pipelineQuery []bson.M) {
+	q := qi.(*query.Mongo)
+	q.HumanLabel = []byte(humanLabel)
+	q.BsonDoc = pipelineQuery
+	q.CollectionName = []byte("point_data")
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s (%s)", humanLabel, interval.StartString(), q.CollectionName))
+}
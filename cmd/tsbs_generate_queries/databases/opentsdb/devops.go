@@ -0,0 +1,183 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Devops produces OpenTSDB-specific queries for the devops query types. Each
+// cpu field is a separate metric, e.g. cpu.usage_user, with the hostname and
+// the other tags of the host as its tags. OpenTSDB cannot filter on values,
+// so there is no high-cpu query.
+type Devops struct {
+	*devops.Core
+}
+
+// NewDevops makes an Devops object ready to generate Queries.
+func NewDevops(start, end time.Time, scale int) *Devops {
+	return &Devops{devops.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (d *Devops) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// subQuery is a sub query of a request to /api/query
+type subQuery struct {
+	Aggregator string   `json:"aggregator"`
+	Metric     string   `json:"metric"`
+	Downsample string   `json:"downsample,omitempty"`
+	Filters    []filter `json:"filters,omitempty"`
+}
+
+type filter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+// queryRequest is the body of a request to /api/query
+type queryRequest struct {
+	Start   int64      `json:"start"`
+	End     int64      `json:"end"`
+	Queries []subQuery `json:"queries"`
+}
+
+// lastRequest is the body of a request to /api/query/last
+type lastRequest struct {
+	Queries      []lastSubQuery `json:"queries"`
+	ResolveNames bool           `json:"resolveNames"`
+	BackScan     int            `json:"backScan"`
+}
+
+type lastSubQuery struct {
+	Metric string            `json:"metric"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// getSubQueries returns a sub query per metric, aggregating the series of
+// the given hosts, or of every host if there are none, with agg and
+// downsampling them to downsample
+func getSubQueries(metrics, hostnames []string, agg, downsample string, groupByHost bool) []subQuery {
+	var filters []filter
+	if len(hostnames) > 0 {
+		filters = []filter{{Type: "literal_or", Tagk: "hostname", Filter: strings.Join(hostnames, "|"), GroupBy: groupByHost}}
+	} else if groupByHost {
+		filters = []filter{{Type: "wildcard", Tagk: "hostname", Filter: "*", GroupBy: true}}
+	}
+	queries := make([]subQuery, len(metrics))
+	for i, m := range metrics {
+		queries[i] = subQuery{
+			Aggregator: agg,
+			Metric:     "cpu." + m,
+			Downsample: downsample,
+			Filters:    filters,
+		}
+	}
+	return queries
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g. for each metric:
+//
+// {"aggregator": "max", "metric": "cpu.usage_user", "downsample": "1m-max",
+// "filters": [{"type": "literal_or", "tagk": "hostname", "filter": "host_1|host_2"}]}
+func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.RandWindow(timeRange)
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	humanLabel := fmt.Sprintf("OpenTSDB %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, getSubQueries(metrics, hostnames, "max", "1m-max", false))
+}
+
+// GroupByOrderByLimit selects the MAX of usage_user per minute of all hosts,
+// for the last 5 minutes before a random end time. OpenTSDB cannot order or
+// limit, so the 5 minutes are the range of the query:
+//
+// {"aggregator": "max", "metric": "cpu.usage_user", "downsample": "1m-max"}
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.RandWindow(time.Hour)
+	interval = utils.NewTimeInterval(interval.End.Add(-5*time.Minute), interval.End)
+
+	humanLabel := "OpenTSDB max cpu over last 5 min-intervals (random end)"
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, getSubQueries([]string{"usage_user"}, nil, "max", "1m-max", false))
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. for each metric:
+//
+// {"aggregator": "avg", "metric": "cpu.usage_user", "downsample": "1h-avg",
+// "filters": [{"type": "wildcard", "tagk": "hostname", "filter": "*", "groupBy": true}]}
+func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	metrics := devops.GetCPUMetricsSlice(numMetrics)
+	interval := d.Interval.RandWindow(devops.DoubleGroupByDuration)
+
+	humanLabel := devops.GetDoubleGroupByLabel("OpenTSDB", numMetrics)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, getSubQueries(metrics, nil, "avg", "1h-avg", true))
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. for each metric:
+//
+// {"aggregator": "max", "metric": "cpu.usage_user", "downsample": "1h-max",
+// "filters": [{"type": "literal_or", "tagk": "hostname", "filter": "host_1|host_2"}]}
+func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.MaxAllDuration)
+	metrics := devops.GetAllCPUMetrics()
+	hostnames := d.GetRandomHosts(nHosts, interval)
+
+	humanLabel := devops.GetMaxAllLabel("OpenTSDB", nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, interval, getSubQueries(metrics, hostnames, "max", "1h-max", false))
+}
+
+// LastPointPerHost finds the last value of every cpu metric for every host,
+// scanning back up to a day before the last write:
+//
+// POST /api/query/last {"queries": [{"metric": "cpu.usage_user"}, ...], "backScan": 24}
+func (d *Devops) LastPointPerHost(qi query.Query) {
+	metrics := devops.GetAllCPUMetrics()
+	req := lastRequest{ResolveNames: true, BackScan: 24}
+	for _, m := range metrics {
+		req.Queries = append(req.Queries, lastSubQuery{Metric: "cpu." + m, Tags: map[string]string{}})
+	}
+
+	humanLabel := "OpenTSDB last row per host"
+	humanDesc := humanLabel
+	d.fillInRequest(qi, humanLabel, humanDesc, "/api/query/last", req)
+}
+
+func (d *Devops) fillInQuery(qi query.Query, humanLabel, humanDesc string, interval utils.TimeInterval, queries []subQuery) {
+	req := queryRequest{
+		Start:   interval.StartUnixNano() / 1e6,
+		End:     interval.EndUnixNano() / 1e6,
+		Queries: queries,
+	}
+	d.fillInRequest(qi, humanLabel, humanDesc, "/api/query", req)
+}
+
+func (d *Devops) fillInRequest(qi query.Query, humanLabel, humanDesc, path string, req interface{}) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal query: %v", err))
+	}
+	q := qi.(*query.HTTP)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("POST")
+	q.Path = []byte(path)
+	q.Body = body
+}
@@ -0,0 +1,71 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestGetSubQueries(t *testing.T) {
+	got := getSubQueries([]string{"usage_user", "usage_system"}, []string{"host_1", "host_2"}, "max", "1m-max", false)
+	if len(got) != 2 {
+		t.Fatalf("incorrect number of sub queries: got %d want 2", len(got))
+	}
+	b, _ := json.Marshal(got[1])
+	want := `{"aggregator":"max","metric":"cpu.usage_system","downsample":"1m-max","filters":[{"type":"literal_or","tagk":"hostname","filter":"host_1|host_2","groupBy":false}]}`
+	if string(b) != want {
+		t.Errorf("incorrect sub query:\ngot\n%s\nwant\n%s", b, want)
+	}
+
+	got = getSubQueries([]string{"usage_user"}, nil, "avg", "1h-avg", true)
+	b, _ = json.Marshal(got[0])
+	want = `{"aggregator":"avg","metric":"cpu.usage_user","downsample":"1h-avg","filters":[{"type":"wildcard","tagk":"hostname","filter":"*","groupBy":true}]}`
+	if string(b) != want {
+		t.Errorf("incorrect sub query:\ngot\n%s\nwant\n%s", b, want)
+	}
+
+	got = getSubQueries([]string{"usage_user"}, nil, "max", "1m-max", false)
+	b, _ = json.Marshal(got[0])
+	want = `{"aggregator":"max","metric":"cpu.usage_user","downsample":"1m-max"}`
+	if string(b) != want {
+		t.Errorf("incorrect sub query:\ngot\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestDevopsGroupByOrderByLimit(t *testing.T) {
+	s := time.Unix(0, 0)
+	d := NewDevops(s, s.Add(24*time.Hour), 10)
+	q := d.GenerateEmptyQuery()
+	d.GroupByOrderByLimit(q)
+	hq := q.(*query.HTTP)
+	if got := string(hq.Path); got != "/api/query" {
+		t.Errorf("incorrect path: got %s want /api/query", got)
+	}
+	req := queryRequest{}
+	if err := json.Unmarshal(hq.Body, &req); err != nil {
+		t.Fatalf("could not unmarshal body: %v", err)
+	}
+	if got := req.End - req.Start; got != (5*time.Minute).Nanoseconds()/1e6 {
+		t.Errorf("incorrect range: got %dms want 5m", got)
+	}
+}
+
+func TestDevopsLastPointPerHost(t *testing.T) {
+	s := time.Unix(0, 0)
+	d := NewDevops(s, s.Add(24*time.Hour), 10)
+	q := d.GenerateEmptyQuery()
+	d.LastPointPerHost(q)
+	hq := q.(*query.HTTP)
+	if got := string(hq.Path); got != "/api/query/last" {
+		t.Errorf("incorrect path: got %s want /api/query/last", got)
+	}
+	req := lastRequest{}
+	if err := json.Unmarshal(hq.Body, &req); err != nil {
+		t.Fatalf("could not unmarshal body: %v", err)
+	}
+	if len(req.Queries) != 10 || req.Queries[0].Metric != "cpu.usage_user" {
+		t.Errorf("incorrect queries: %v", req.Queries)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
 	"github.com/hagen1778/tsbs/query"
 )
 
@@ -75,7 +76,8 @@ type queryInfo struct {
 // max(max_over_time({__name__=~"metric1|metric2...|metricN",hostname=~"hostname1|hostname2...|hostnameN"})) by (__name__)
 func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
 	metrics := devops.GetCPUMetricsSlice(numMetrics)
-	hosts := d.GetRandomHosts(nHosts)
+	interval := d.Interval.RandWindow(timeRange)
+	hosts := d.GetRandomHosts(nHosts, interval)
 	selectClause := getSelectClause(metrics, hosts)
 	qi := &queryInfo{
 		query:     fmt.Sprintf("max(max_over_time(%s)) by (__name__)", selectClause),
@@ -83,7 +85,7 @@ func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange t
 		timeRange: timeRange,
 		step:      "60",
 	}
-	d.fillInQuery(qq, qi)
+	d.fillInQuery(qq, qi, interval)
 }
 
 // GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
@@ -99,7 +101,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qq query.Query, numMetrics int) {
 		timeRange: devops.DoubleGroupByDuration,
 		step:      "3600",
 	}
-	d.fillInQuery(qq, qi)
+	d.fillInQuery(qq, qi, d.Interval.RandWindow(qi.timeRange))
 }
 
 // MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
@@ -107,7 +109,8 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qq query.Query, numMetrics int) {
 //
 // max(max_over_time({hostname=~"hostname1|hostname2...|hostnameN"}))
 func (d *Devops) MaxAllCPU(qq query.Query, nHosts int) {
-	hosts := d.GetRandomHosts(nHosts)
+	interval := d.Interval.RandWindow(devops.MaxAllDuration)
+	hosts := d.GetRandomHosts(nHosts, interval)
 	selectClause := getSelectClause(nil, hosts)
 	qi := &queryInfo{
 		query:     fmt.Sprintf("max(max_over_time(%s)) by (__name__)", selectClause),
@@ -115,7 +118,7 @@ func (d *Devops) MaxAllCPU(qq query.Query, nHosts int) {
 		timeRange: devops.MaxAllDuration,
 		step:      "3600",
 	}
-	d.fillInQuery(qq, qi)
+	d.fillInQuery(qq, qi, interval)
 }
 
 // HighCPUForHosts populates a query that gets CPU metrics when the CPU has high
@@ -125,9 +128,10 @@ func (d *Devops) MaxAllCPU(qq query.Query, nHosts int) {
 // max(max_over_time(cpu_usage_user{hostname=~"hostname1|hostname2...|hostnameN"})) by (hostname) > 90
 func (d *Devops) HighCPUForHosts(qq query.Query, nHosts int) {
 	metrics := devops.GetCPUMetricsSlice(1)
+	interval := d.Interval.RandWindow(devops.HighCPUDuration)
 	var hosts []string
 	if nHosts > 0 {
-		hosts = d.GetRandomHosts(nHosts)
+		hosts = d.GetRandomHosts(nHosts, interval)
 	}
 	selectClause := getSelectClause(metrics, hosts)
 	qi := &queryInfo{
@@ -136,11 +140,12 @@ func (d *Devops) HighCPUForHosts(qq query.Query, nHosts int) {
 		timeRange: devops.HighCPUDuration,
 		step:      fmt.Sprintf("%d", devops.HighCPUDuration),
 	}
-	d.fillInQuery(qq, qi)
+	d.fillInQuery(qq, qi, interval)
 }
 
-func (d *Devops) fillInQuery(qq query.Query, qi *queryInfo) {
-	interval := d.Interval.RandWindow(qi.timeRange)
+// fillInQuery fills in qq with qi, queried over interval, a window of
+// qi.timeRange.
+func (d *Devops) fillInQuery(qq query.Query, qi *queryInfo, interval utils.TimeInterval) {
 	humanDesc := fmt.Sprintf("%s: %s", qi.label, interval.StartString())
 
 	v := url.Values{}
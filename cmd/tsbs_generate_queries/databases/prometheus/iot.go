@@ -0,0 +1,117 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// IoT produces Prometheus-specific queries for the iot query types it
+// supports. PromQL has no way to return the samples of every series at a
+// single past instant, so last-loc is not implemented.
+type IoT struct {
+	*iot.Core
+}
+
+// NewIoT makes an IoT object ready to generate Queries.
+func NewIoT(start, end time.Time, scale int) *IoT {
+	return &IoT{iot.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (i *IoT) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+func getNameClause(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	if len(names) == 1 {
+		return fmt.Sprintf("name='%s'", names[0])
+	}
+
+	return fmt.Sprintf("name=~'%s'", strings.Join(names, "|"))
+}
+
+// StaleDevices finds the devices that reported during a random hour, but
+// not during its last minutes,
+// e.g.:
+//
+// count_over_time(readings_velocity[1h]) unless count_over_time(readings_velocity[10m])
+func (i *IoT) StaleDevices(qq query.Query) {
+	qi := &queryInfo{
+		query: fmt.Sprintf("count_over_time(readings_velocity[%s]) unless count_over_time(readings_velocity[%s])",
+			promDuration(iot.StaleDevicesDuration), promDuration(iot.StaleDevicesThreshold)),
+		label:     iot.GetStaleDevicesLabel("Prometheus"),
+		timeRange: iot.StaleDevicesDuration,
+		step:      strconv.Itoa(int(iot.StaleDevicesDuration.Seconds())),
+	}
+	i.fillInQuery(qq, qi)
+}
+
+// FleetAverages selects the mean velocity and fuel consumption per fleet
+// per hour, e.g.:
+//
+// avg(avg_over_time({__name__=~"readings_velocity|readings_fuel_consumption"})) by (__name__, fleet)
+func (i *IoT) FleetAverages(qq query.Query) {
+	metrics := make([]string, len(iot.FleetAveragesMetrics))
+	for j, m := range iot.FleetAveragesMetrics {
+		metrics[j] = "readings_" + m
+	}
+	qi := &queryInfo{
+		query:     fmt.Sprintf("avg(avg_over_time({__name__=~%q})) by (__name__, fleet)", strings.Join(metrics, "|")),
+		label:     iot.GetFleetAveragesLabel("Prometheus"),
+		timeRange: iot.FleetAveragesDuration,
+		step:      "3600",
+	}
+	i.fillInQuery(qq, qi)
+}
+
+// HighLoadDevices populates a query that gets the load of devices whose load
+// is over the threshold during a time period for a number of devices (if 0,
+// it will search all devices),
+// e.g.:
+//
+// max(max_over_time(diagnostics_current_load{name=~"truck_1|truck_2...|truck_N"})) by (name) > 90
+func (i *IoT) HighLoadDevices(qq query.Query, nDevices int) {
+	var names []string
+	if nDevices > 0 {
+		names = i.GetRandomDevices(nDevices)
+	}
+	qi := &queryInfo{
+		query:     fmt.Sprintf("max(max_over_time(diagnostics_current_load{%s})) by (name) > %v", getNameClause(names), iot.HighLoadThreshold),
+		label:     iot.GetHighLoadLabel("Prometheus", nDevices),
+		timeRange: iot.HighLoadDuration,
+		step:      strconv.Itoa(int(iot.HighLoadDuration.Seconds())),
+	}
+	i.fillInQuery(qq, qi)
+}
+
+// promDuration formats d as a PromQL duration in whole minutes
+func promDuration(d time.Duration) string {
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+func (i *IoT) fillInQuery(qq query.Query, qi *queryInfo) {
+	interval := i.Interval.RandWindow(qi.timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", qi.label, interval.StartString())
+
+	v := url.Values{}
+	v.Set("query", qi.query)
+	v.Set("start", strconv.FormatInt(interval.StartUnixNano()/1e9, 10))
+	v.Set("end", strconv.FormatInt(interval.EndUnixNano()/1e9, 10))
+	v.Set("step", qi.step)
+
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(humanDesc)
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/api/v1/query_range?%s", v.Encode()))
+	q.Body = nil
+}
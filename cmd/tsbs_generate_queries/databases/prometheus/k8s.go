@@ -0,0 +1,126 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/k8s"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// K8s produces Prometheus-specific queries for all the k8s query types.
+// Rollups are range queries; top-pods-cpu and pod-restarts rank pods over
+// a whole window, so they are instant queries at its end.
+type K8s struct {
+	*k8s.Core
+}
+
+// NewK8s makes a K8s object ready to generate Queries.
+func NewK8s(start, end time.Time, scale int) *K8s {
+	return &K8s{k8s.NewCore(start, end, scale)}
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP
+func (k *K8s) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// NamespaceCPU selects the cpu cores used per namespace every 5 minutes of a
+// random hour, e.g.:
+//
+// sum(rate(container_cpu_usage_seconds_total[5m])) by (namespace)
+func (k *K8s) NamespaceCPU(qq query.Query) {
+	qi := &queryInfo{
+		query: fmt.Sprintf("sum(rate(container_cpu_usage_seconds_total[%s])) by (namespace)", promDuration(k8s.RollupInterval)),
+		label: k8s.GetNamespaceCPULabel("Prometheus"),
+		step:  strconv.Itoa(int(k8s.RollupInterval.Seconds())),
+	}
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	k.fillInQuery(qq, qi, interval, interval.StartString())
+}
+
+// NamespaceMemory selects the memory working set per namespace every 5
+// minutes of a random hour, e.g.:
+//
+// sum(avg_over_time(container_memory_working_set_bytes[5m])) by (namespace)
+func (k *K8s) NamespaceMemory(qq query.Query) {
+	qi := &queryInfo{
+		query: fmt.Sprintf("sum(avg_over_time(container_memory_working_set_bytes[%s])) by (namespace)", promDuration(k8s.RollupInterval)),
+		label: k8s.GetNamespaceMemoryLabel("Prometheus"),
+		step:  strconv.Itoa(int(k8s.RollupInterval.Seconds())),
+	}
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	k.fillInQuery(qq, qi, interval, interval.StartString())
+}
+
+// DeploymentCPU selects the cpu cores used per deployment of a random
+// namespace every 5 minutes of a random hour, e.g.:
+//
+// sum(rate(container_cpu_usage_seconds_total{namespace="$NAMESPACE"}[5m])) by (deployment)
+func (k *K8s) DeploymentCPU(qq query.Query) {
+	namespace := k.GetRandomNamespace()
+	qi := &queryInfo{
+		query: fmt.Sprintf("sum(rate(container_cpu_usage_seconds_total{namespace=%q}[%s])) by (deployment)", namespace, promDuration(k8s.RollupInterval)),
+		label: k8s.GetDeploymentCPULabel("Prometheus"),
+		step:  strconv.Itoa(int(k8s.RollupInterval.Seconds())),
+	}
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	k.fillInQuery(qq, qi, interval, fmt.Sprintf("%s, %s", namespace, interval.StartString()))
+}
+
+// TopPodsCPU selects the k pods that used the most cpu time during a random
+// hour, e.g.:
+//
+// topk(5, sum(increase(container_cpu_usage_seconds_total[1h])) by (namespace, pod))
+func (k *K8s) TopPodsCPU(qq query.Query, nPods int) {
+	qi := &queryInfo{
+		query: fmt.Sprintf("topk(%d, sum(increase(container_cpu_usage_seconds_total[%s])) by (namespace, pod))", nPods, promDuration(k8s.TopPodsDuration)),
+		label: k8s.GetTopPodsCPULabel("Prometheus", nPods),
+	}
+	k.fillInInstantQuery(qq, qi, k.Interval.RandWindow(k8s.TopPodsDuration))
+}
+
+// PodRestarts selects the pods whose container restarted during a random
+// hour, with their number of restarts, e.g.:
+//
+// sort_desc(sum(increase(kube_pod_container_status_restarts_total[1h])) by (namespace, pod) > 0)
+func (k *K8s) PodRestarts(qq query.Query) {
+	qi := &queryInfo{
+		query: fmt.Sprintf("sort_desc(sum(increase(kube_pod_container_status_restarts_total[%s])) by (namespace, pod) > 0)", promDuration(k8s.PodRestartsDuration)),
+		label: k8s.GetPodRestartsLabel("Prometheus"),
+	}
+	k.fillInInstantQuery(qq, qi, k.Interval.RandWindow(k8s.PodRestartsDuration))
+}
+
+func (k *K8s) fillInQuery(qq query.Query, qi *queryInfo, interval utils.TimeInterval, desc string) {
+	v := url.Values{}
+	v.Set("query", qi.query)
+	v.Set("start", strconv.FormatInt(interval.StartUnixNano()/1e9, 10))
+	v.Set("end", strconv.FormatInt(interval.EndUnixNano()/1e9, 10))
+	v.Set("step", qi.step)
+
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, desc))
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/api/v1/query_range?%s", v.Encode()))
+	q.Body = nil
+}
+
+// fillInInstantQuery fills in a query evaluated once, at the end of interval,
+// over ranges as long as interval
+func (k *K8s) fillInInstantQuery(qq query.Query, qi *queryInfo, interval utils.TimeInterval) {
+	v := url.Values{}
+	v.Set("query", qi.query)
+	v.Set("time", strconv.FormatInt(interval.EndUnixNano()/1e9, 10))
+
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, interval.StartString()))
+	q.Method = []byte("GET")
+	q.Path = []byte(fmt.Sprintf("/api/v1/query?%s", v.Encode()))
+	q.Body = nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
 	"github.com/hagen1778/tsbs/query"
 )
 
@@ -48,8 +49,8 @@ func (d *Devops) getHostWhereWithHostnames(hostnames []string) string {
 	}
 }
 
-func (d *Devops) getHostWhereString(nhosts int) string {
-	hostnames := d.GetRandomHosts(nhosts)
+func (d *Devops) getHostWhereString(nhosts int, interval utils.TimeInterval) string {
+	hostnames := d.GetRandomHosts(nhosts, interval)
 	return d.getHostWhereWithHostnames(hostnames)
 }
 
@@ -84,7 +85,7 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
     WHERE %s AND time >= '%s' AND time < '%s'
     GROUP BY minute ORDER BY minute ASC`,
 		strings.Join(selectClauses, ", "),
-		d.getHostWhereString(nHosts),
+		d.getHostWhereString(nHosts, interval),
 		interval.Start.Format(goTimeFmt),
 		interval.End.Format(goTimeFmt))
 
@@ -178,7 +179,7 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 	WHERE %s AND time >= '%s' AND time < '%s'
     GROUP BY hour ORDER BY hour`,
 		strings.Join(selectClauses, ", "),
-		d.getHostWhereString(nHosts),
+		d.getHostWhereString(nHosts, interval),
 		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt))
 
 	humanLabel := devops.GetMaxAllLabel("TimescaleDB", nHosts)
@@ -211,13 +212,13 @@ func (d *Devops) LastPointPerHost(qi query.Query) {
 // AND time >= '$TIME_START' AND time < '$TIME_END'
 // AND (hostname = '$HOST' OR hostname = '$HOST2'...)
 func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	interval := d.Interval.RandWindow(devops.HighCPUDuration)
 	var hostWhereClause string
 	if nHosts == 0 {
 		hostWhereClause = ""
 	} else {
-		hostWhereClause = fmt.Sprintf("AND %s", d.getHostWhereString(nHosts))
+		hostWhereClause = fmt.Sprintf("AND %s", d.getHostWhereString(nHosts, interval))
 	}
-	interval := d.Interval.RandWindow(devops.HighCPUDuration)
 
 	sql := fmt.Sprintf(`SELECT max(usage_user) FROM cpu WHERE usage_user > 90.0 and time >= '%s' AND time < '%s' %s group by hostname`,
 		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt), hostWhereClause)
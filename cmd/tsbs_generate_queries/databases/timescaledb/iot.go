@@ -0,0 +1,171 @@
+package timescaledb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// IoT produces TimescaleDB-specific queries for all the iot query types.
+type IoT struct {
+	*iot.Core
+	UseJSON bool
+	UseTags bool
+}
+
+// NewIoT makes an IoT object ready to generate Queries.
+func NewIoT(start, end time.Time, scale int) *IoT {
+	return &IoT{iot.NewCore(start, end, scale), false, false}
+}
+
+// GenerateEmptyQuery returns an empty query.TimescaleDB
+func (i *IoT) GenerateEmptyQuery() query.Query {
+	return query.NewTimescaleDB()
+}
+
+// getTagField returns how to refer to a tag once the tags table, if any, is
+// joined to a hypertable
+func (i *IoT) getTagField(tag string) string {
+	if i.UseJSON {
+		return fmt.Sprintf("tags.tagset->>'%s'", tag)
+	} else if i.UseTags {
+		return "tags." + tag
+	}
+	return tag
+}
+
+// getTagsJoin returns the clause joining the tags table to the given
+// hypertable alias, if tags are kept in a separate table
+func (i *IoT) getTagsJoin(alias string) string {
+	if i.UseJSON || i.UseTags {
+		return fmt.Sprintf("JOIN tags ON %s.tags_id = tags.id", alias)
+	}
+	return ""
+}
+
+func (i *IoT) getDeviceWhereWithNames(names []string) string {
+	nameClauses := []string{}
+	if i.UseJSON {
+		for _, s := range names {
+			nameClauses = append(nameClauses, fmt.Sprintf("tagset @> '{\"name\": \"%s\"}'", s))
+		}
+		return fmt.Sprintf("tags_id IN (SELECT id FROM tags WHERE %s)", strings.Join(nameClauses, " OR "))
+	} else if i.UseTags {
+		for _, s := range names {
+			nameClauses = append(nameClauses, fmt.Sprintf("'%s'", s))
+		}
+		return fmt.Sprintf("tags_id IN (SELECT id FROM tags WHERE name IN (%s))", strings.Join(nameClauses, ","))
+	}
+	for _, s := range names {
+		nameClauses = append(nameClauses, fmt.Sprintf("name = '%s'", s))
+	}
+	return "(" + strings.Join(nameClauses, " OR ") + ")"
+}
+
+// StaleDevices finds the devices that reported during a random hour, but
+// not during its last minutes,
+// e.g. in psuedo-SQL:
+//
+// SELECT name, max(time) FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY name HAVING max(time) < '$HOUR_END' - '$THRESHOLD'
+func (i *IoT) StaleDevices(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.StaleDevicesDuration)
+	nameField := i.getTagField("name")
+
+	sql := fmt.Sprintf(`SELECT %[1]s AS name, max(r.time) AS last_time
+    FROM readings r
+    %[2]s
+    WHERE r.time >= '%[3]s' AND r.time < '%[4]s'
+    GROUP BY %[1]s
+    HAVING max(r.time) < '%[5]s'`,
+		nameField, i.getTagsJoin("r"),
+		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt),
+		interval.End.Add(-iot.StaleDevicesThreshold).Format(goTimeFmt))
+
+	humanLabel := iot.GetStaleDevicesLabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	i.fillInQuery(qi, humanLabel, humanDesc, "readings", sql)
+}
+
+// FleetAverages selects the mean velocity and fuel consumption per fleet
+// per hour, e.g. in psuedo-SQL:
+//
+// SELECT AVG(velocity), AVG(fuel_consumption)
+// FROM readings
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, fleet ORDER BY hour, fleet
+func (i *IoT) FleetAverages(qi query.Query) {
+	interval := i.Interval.RandWindow(iot.FleetAveragesDuration)
+	fleetField := i.getTagField("fleet")
+
+	selectClauses := make([]string, len(iot.FleetAveragesMetrics))
+	for j, m := range iot.FleetAveragesMetrics {
+		selectClauses[j] = fmt.Sprintf("avg(r.%[1]s) AS mean_%[1]s", m)
+	}
+
+	sql := fmt.Sprintf(`SELECT time_bucket('1 hour', r.time) AS hour, %[1]s AS fleet,
+    %[2]s
+    FROM readings r
+    %[3]s
+    WHERE r.time >= '%[4]s' AND r.time < '%[5]s'
+    GROUP BY hour, %[1]s
+    ORDER BY hour, %[1]s`,
+		fleetField, strings.Join(selectClauses, ", "), i.getTagsJoin("r"),
+		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt))
+
+	humanLabel := iot.GetFleetAveragesLabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	i.fillInQuery(qi, humanLabel, humanDesc, "readings", sql)
+}
+
+// LastLocPerDevice finds the last position of every device in the dataset
+func (i *IoT) LastLocPerDevice(qi query.Query) {
+	var sql string
+	if i.UseTags {
+		sql = "SELECT DISTINCT ON (t.name) t.name, r.time, r.latitude, r.longitude FROM tags t INNER JOIN LATERAL(SELECT * FROM readings r WHERE r.tags_id = t.id ORDER BY time DESC LIMIT 1) AS r ON true ORDER BY t.name, r.time DESC"
+	} else if i.UseJSON {
+		sql = "SELECT DISTINCT ON (t.tagset->>'name') t.tagset->>'name' AS name, r.time, r.latitude, r.longitude FROM tags t INNER JOIN LATERAL(SELECT * FROM readings r WHERE r.tags_id = t.id ORDER BY time DESC LIMIT 1) AS r ON true ORDER BY t.tagset->>'name', r.time DESC"
+	} else {
+		sql = "SELECT DISTINCT ON (name) name, time, latitude, longitude FROM readings ORDER BY name, time DESC"
+	}
+
+	humanLabel := iot.GetLastLocLabel("TimescaleDB")
+	humanDesc := humanLabel
+	i.fillInQuery(qi, humanLabel, humanDesc, "readings", sql)
+}
+
+// HighLoadDevices populates a query that gets the diagnostics of devices
+// whose load is over the threshold during a time period for a number of
+// devices (if 0, it will search all devices),
+// e.g. in psuedo-SQL:
+//
+// SELECT * FROM diagnostics
+// WHERE current_load > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (name = '$DEVICE' OR name = '$DEVICE2'...)
+func (i *IoT) HighLoadDevices(qi query.Query, nDevices int) {
+	var deviceWhereClause string
+	if nDevices > 0 {
+		deviceWhereClause = fmt.Sprintf("AND %s", i.getDeviceWhereWithNames(i.GetRandomDevices(nDevices)))
+	}
+	interval := i.Interval.RandWindow(iot.HighLoadDuration)
+
+	sql := fmt.Sprintf(`SELECT time, tags_id, current_load FROM diagnostics WHERE current_load > %.1f AND time >= '%s' AND time < '%s' %s`,
+		iot.HighLoadThreshold, interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt), deviceWhereClause)
+
+	humanLabel := iot.GetHighLoadLabel("TimescaleDB", nDevices)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	i.fillInQuery(qi, humanLabel, humanDesc, "diagnostics", sql)
+}
+
+func (i *IoT) fillInQuery(qi query.Query, humanLabel, humanDesc, hypertable, sql string) {
+	q := qi.(*query.TimescaleDB)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Hypertable = []byte(hypertable)
+	q.SqlQuery = []byte(sql)
+}
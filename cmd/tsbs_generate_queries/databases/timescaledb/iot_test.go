@@ -0,0 +1,80 @@
+package timescaledb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestIoTGetDeviceWhereWithNames(t *testing.T) {
+	cases := []struct {
+		desc    string
+		names   []string
+		useJSON bool
+		useTags bool
+		want    string
+	}{
+		{
+			desc:  "multi device - no json or tags",
+			names: []string{"truck_1", "truck_2"},
+			want:  "(name = 'truck_1' OR name = 'truck_2')",
+		},
+		{
+			desc:    "multi device - w/ json",
+			names:   []string{"truck_1", "truck_2"},
+			useJSON: true,
+			want:    "tags_id IN (SELECT id FROM tags WHERE tagset @> '{\"name\": \"truck_1\"}' OR tagset @> '{\"name\": \"truck_2\"}')",
+		},
+		{
+			desc:    "multi device - w/ tags",
+			names:   []string{"truck_1", "truck_2"},
+			useTags: true,
+			want:    "tags_id IN (SELECT id FROM tags WHERE name IN ('truck_1','truck_2'))",
+		},
+	}
+
+	for _, c := range cases {
+		i := NewIoT(time.Now(), time.Now().Add(time.Hour), 10)
+		i.UseJSON = c.useJSON
+		i.UseTags = c.useTags
+
+		if got := i.getDeviceWhereWithNames(c.names); got != c.want {
+			t.Errorf("%s: incorrect output: got %s want %s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestIoTFleetAveragesJoinsTags(t *testing.T) {
+	cases := []struct {
+		desc     string
+		useJSON  bool
+		useTags  bool
+		wantTag  string
+		wantJoin bool
+	}{
+		{desc: "no json or tags", wantTag: "fleet AS fleet"},
+		{desc: "w/ json", useJSON: true, wantTag: "tags.tagset->>'fleet' AS fleet", wantJoin: true},
+		{desc: "w/ tags", useTags: true, wantTag: "tags.fleet AS fleet", wantJoin: true},
+	}
+
+	for _, c := range cases {
+		i := NewIoT(time.Now(), time.Now().Add(24*time.Hour), 10)
+		i.UseJSON = c.useJSON
+		i.UseTags = c.useTags
+		q := i.GenerateEmptyQuery()
+		i.FleetAverages(q)
+
+		sql := string(q.(*query.TimescaleDB).SqlQuery)
+		if !strings.Contains(sql, c.wantTag) {
+			t.Errorf("%s: query does not select %q: %s", c.desc, c.wantTag, sql)
+		}
+		if got := strings.Contains(sql, "JOIN tags"); got != c.wantJoin {
+			t.Errorf("%s: incorrect tags join: got %v want %v", c.desc, got, c.wantJoin)
+		}
+		if got := string(q.(*query.TimescaleDB).Hypertable); got != "readings" {
+			t.Errorf("%s: incorrect hypertable: got %s want readings", c.desc, got)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package timescaledb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/k8s"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// K8s produces TimescaleDB-specific queries for all the k8s query types.
+// The cpu time used by a pod in a window is computed as the difference between
+// the largest and smallest values of its counter, which is only approximate
+// for windows in which its container restarted and the counter was reset.
+type K8s struct {
+	*k8s.Core
+	UseJSON bool
+	UseTags bool
+}
+
+// NewK8s makes a K8s object ready to generate Queries.
+func NewK8s(start, end time.Time, scale int) *K8s {
+	return &K8s{k8s.NewCore(start, end, scale), false, false}
+}
+
+// GenerateEmptyQuery returns an empty query.TimescaleDB
+func (k *K8s) GenerateEmptyQuery() query.Query {
+	return query.NewTimescaleDB()
+}
+
+// getTagField returns how to refer to a tag once the tags table, if any, is
+// joined to a hypertable
+func (k *K8s) getTagField(tag string) string {
+	if k.UseJSON {
+		return fmt.Sprintf("tags.tagset->>'%s'", tag)
+	} else if k.UseTags {
+		return "tags." + tag
+	}
+	return tag
+}
+
+// getTagsJoin returns the clause joining the tags table to the given
+// hypertable alias, if tags are kept in a separate table
+func (k *K8s) getTagsJoin(alias string) string {
+	if k.UseJSON || k.UseTags {
+		return fmt.Sprintf("JOIN tags ON %s.tags_id = tags.id", alias)
+	}
+	return ""
+}
+
+// cpuRollup returns the cpu cores used per group every rollup interval,
+// summed over the pods of each group, in the given time range
+func (k *K8s) cpuRollup(group, where string, start, end time.Time) string {
+	groupField, podField := k.getTagField(group), k.getTagField("pod")
+	return fmt.Sprintf(`SELECT bucket, %[1]s, sum(cores) AS cores
+    FROM (
+        SELECT time_bucket('%[2]d seconds', c.time) AS bucket, %[3]s AS %[1]s,
+        (max(c.usage_seconds_total) - min(c.usage_seconds_total)) / %[2]d AS cores
+        FROM container_cpu c
+        %[4]s
+        WHERE c.time >= '%[5]s' AND c.time < '%[6]s' %[7]s
+        GROUP BY bucket, %[3]s, %[8]s
+    ) AS pods
+    GROUP BY bucket, %[1]s
+    ORDER BY bucket, %[1]s`,
+		group, int(k8s.RollupInterval.Seconds()), groupField, k.getTagsJoin("c"),
+		start.Format(goTimeFmt), end.Format(goTimeFmt), where, podField)
+}
+
+// NamespaceCPU selects the cpu cores used per namespace every 5 minutes of a
+// random hour, e.g. in psuedo-SQL:
+//
+// SELECT bucket, namespace, sum(cores) FROM (
+// SELECT time_bucket('5m'), namespace, pod, (max(usage) - min(usage)) / 300 AS cores
+// FROM container_cpu WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY bucket, namespace, pod)
+// GROUP BY bucket, namespace
+func (k *K8s) NamespaceCPU(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	sql := k.cpuRollup("namespace", "", interval.Start, interval.End)
+
+	humanLabel := k8s.GetNamespaceCPULabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	k.fillInQuery(qi, humanLabel, humanDesc, "container_cpu", sql)
+}
+
+// NamespaceMemory selects the memory working set per namespace every 5
+// minutes of a random hour, e.g. in psuedo-SQL:
+//
+// SELECT bucket, namespace, sum(working_set) FROM (
+// SELECT time_bucket('5m'), namespace, pod, avg(working_set_bytes) AS working_set
+// FROM container_memory WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY bucket, namespace, pod)
+// GROUP BY bucket, namespace
+func (k *K8s) NamespaceMemory(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	namespaceField, podField := k.getTagField("namespace"), k.getTagField("pod")
+
+	sql := fmt.Sprintf(`SELECT bucket, namespace, sum(working_set) AS working_set_bytes
+    FROM (
+        SELECT time_bucket('%[1]d seconds', m.time) AS bucket, %[2]s AS namespace,
+        avg(m.working_set_bytes) AS working_set
+        FROM container_memory m
+        %[3]s
+        WHERE m.time >= '%[4]s' AND m.time < '%[5]s'
+        GROUP BY bucket, %[2]s, %[6]s
+    ) AS pods
+    GROUP BY bucket, namespace
+    ORDER BY bucket, namespace`,
+		int(k8s.RollupInterval.Seconds()), namespaceField, k.getTagsJoin("m"),
+		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt), podField)
+
+	humanLabel := k8s.GetNamespaceMemoryLabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	k.fillInQuery(qi, humanLabel, humanDesc, "container_memory", sql)
+}
+
+// DeploymentCPU selects the cpu cores used per deployment of a random
+// namespace every 5 minutes of a random hour, as NamespaceCPU does per
+// namespace
+func (k *K8s) DeploymentCPU(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.RollupDuration)
+	namespace := k.GetRandomNamespace()
+	where := fmt.Sprintf("AND %s = '%s'", k.getTagField("namespace"), namespace)
+	sql := k.cpuRollup("deployment", where, interval.Start, interval.End)
+
+	humanLabel := k8s.GetDeploymentCPULabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s, %s", humanLabel, namespace, interval.StartString())
+	k.fillInQuery(qi, humanLabel, humanDesc, "container_cpu", sql)
+}
+
+// TopPodsCPU selects the k pods that used the most cpu time during a random
+// hour, e.g. in psuedo-SQL:
+//
+// SELECT namespace, pod, max(usage) - min(usage) AS cpu_seconds
+// FROM container_cpu WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY namespace, pod ORDER BY cpu_seconds DESC LIMIT $K
+func (k *K8s) TopPodsCPU(qi query.Query, nPods int) {
+	interval := k.Interval.RandWindow(k8s.TopPodsDuration)
+	namespaceField, podField := k.getTagField("namespace"), k.getTagField("pod")
+
+	sql := fmt.Sprintf(`SELECT %[1]s AS namespace, %[2]s AS pod,
+    max(c.usage_seconds_total) - min(c.usage_seconds_total) AS cpu_seconds
+    FROM container_cpu c
+    %[3]s
+    WHERE c.time >= '%[4]s' AND c.time < '%[5]s'
+    GROUP BY %[1]s, %[2]s
+    ORDER BY cpu_seconds DESC
+    LIMIT %[6]d`,
+		namespaceField, podField, k.getTagsJoin("c"),
+		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt), nPods)
+
+	humanLabel := k8s.GetTopPodsCPULabel("TimescaleDB", nPods)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	k.fillInQuery(qi, humanLabel, humanDesc, "container_cpu", sql)
+}
+
+// PodRestarts selects the pods whose container restarted during a random
+// hour, with their number of restarts, e.g. in psuedo-SQL:
+//
+// SELECT namespace, pod, max(restarts) - min(restarts) AS restarts
+// FROM kube_pod_container WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY namespace, pod HAVING max(restarts) > min(restarts)
+// ORDER BY restarts DESC
+func (k *K8s) PodRestarts(qi query.Query) {
+	interval := k.Interval.RandWindow(k8s.PodRestartsDuration)
+	namespaceField, podField := k.getTagField("namespace"), k.getTagField("pod")
+
+	sql := fmt.Sprintf(`SELECT %[1]s AS namespace, %[2]s AS pod,
+    max(p.status_restarts_total) - min(p.status_restarts_total) AS restarts
+    FROM kube_pod_container p
+    %[3]s
+    WHERE p.time >= '%[4]s' AND p.time < '%[5]s'
+    GROUP BY %[1]s, %[2]s
+    HAVING max(p.status_restarts_total) > min(p.status_restarts_total)
+    ORDER BY restarts DESC, namespace, pod`,
+		namespaceField, podField, k.getTagsJoin("p"),
+		interval.Start.Format(goTimeFmt), interval.End.Format(goTimeFmt))
+
+	humanLabel := k8s.GetPodRestartsLabel("TimescaleDB")
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	k.fillInQuery(qi, humanLabel, humanDesc, "kube_pod_container", sql)
+}
+
+func (k *K8s) fillInQuery(qi query.Query, humanLabel, humanDesc, hypertable, sql string) {
+	q := qi.(*query.TimescaleDB)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Hypertable = []byte(hypertable)
+	q.SqlQuery = []byte(sql)
+}
@@ -0,0 +1,53 @@
+package timescaledb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestK8sDeploymentCPUJoinsTags(t *testing.T) {
+	cases := []struct {
+		desc      string
+		useJSON   bool
+		useTags   bool
+		wantWhere string
+		wantJoin  bool
+	}{
+		{desc: "no json or tags", wantWhere: "AND namespace = '"},
+		{desc: "w/ json", useJSON: true, wantWhere: "AND tags.tagset->>'namespace' = '", wantJoin: true},
+		{desc: "w/ tags", useTags: true, wantWhere: "AND tags.namespace = '", wantJoin: true},
+	}
+
+	for _, c := range cases {
+		k := NewK8s(time.Now(), time.Now().Add(24*time.Hour), 10)
+		k.UseJSON = c.useJSON
+		k.UseTags = c.useTags
+		q := k.GenerateEmptyQuery()
+		k.DeploymentCPU(q)
+
+		sql := string(q.(*query.TimescaleDB).SqlQuery)
+		if !strings.Contains(sql, c.wantWhere) {
+			t.Errorf("%s: query does not filter on %q: %s", c.desc, c.wantWhere, sql)
+		}
+		if got := strings.Contains(sql, "JOIN tags"); got != c.wantJoin {
+			t.Errorf("%s: incorrect tags join: got %v want %v", c.desc, got, c.wantJoin)
+		}
+		if got := string(q.(*query.TimescaleDB).Hypertable); got != "container_cpu" {
+			t.Errorf("%s: incorrect hypertable: got %s want container_cpu", c.desc, got)
+		}
+	}
+}
+
+func TestK8sTopPodsCPU(t *testing.T) {
+	k := NewK8s(time.Now(), time.Now().Add(24*time.Hour), 10)
+	q := k.GenerateEmptyQuery()
+	k.TopPodsCPU(q, 5)
+
+	sql := string(q.(*query.TimescaleDB).SqlQuery)
+	if !strings.HasSuffix(sql, "ORDER BY cpu_seconds DESC\n    LIMIT 5") {
+		t.Errorf("incorrect query: %s", sql)
+	}
+}
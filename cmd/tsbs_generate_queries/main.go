@@ -11,15 +11,23 @@ import (
 	"math/rand"
 	"os"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/cassandra"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/clickhouse"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/elasticsearch"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/graphite"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/influx"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/mongo"
-	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/timescaledb"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/opentsdb"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/prometheus"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/databases/timescaledb"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/uses/k8s"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/manifest"
 )
 
 var useCaseMatrix = map[string]map[string]utils.QueryFillerMaker{
@@ -40,6 +48,21 @@ var useCaseMatrix = map[string]map[string]utils.QueryFillerMaker{
 		devops.LabelHighCPU + "-1":            devops.NewHighCPU(1),
 		devops.LabelLastpoint:                 devops.NewLastPointPerHost,
 	},
+	"iot": {
+		iot.LabelStaleDevices:      iot.NewStaleDevices,
+		iot.LabelFleetAverages:     iot.NewFleetAverages,
+		iot.LabelLastLoc:           iot.NewLastLoc,
+		iot.LabelHighLoad + "-1":   iot.NewHighLoad(1),
+		iot.LabelHighLoad + "-all": iot.NewHighLoad(0),
+	},
+	"k8s": {
+		k8s.LabelNamespaceCPU:       k8s.NewNamespaceCPU,
+		k8s.LabelNamespaceMemory:    k8s.NewNamespaceMemory,
+		k8s.LabelDeploymentCPU:      k8s.NewDeploymentCPU,
+		k8s.LabelTopPodsCPU + "-5":  k8s.NewTopPodsCPU(5),
+		k8s.LabelTopPodsCPU + "-20": k8s.NewTopPodsCPU(20),
+		k8s.LabelPodRestarts:        k8s.NewPodRestarts,
+	},
 }
 
 // Program option vars:
@@ -52,6 +75,8 @@ var (
 	seed  int64
 	debug int
 
+	hostLifetime time.Duration
+
 	timescaleUseJSON bool
 	timescaleUseTags bool
 
@@ -59,13 +84,28 @@ var (
 	interleavedGenerationGroups  uint
 )
 
-func getGenerator(format string, start, end time.Time, scale int) utils.DevopsGenerator {
+func getGenerator(useCase, format string, start, end time.Time, scale int) utils.DevopsGenerator {
+	if useCase == "iot" {
+		return getIoTGenerator(format, start, end, scale)
+	}
+	if useCase == "k8s" {
+		return getK8sGenerator(format, start, end, scale)
+	}
+
 	if format == "cassandra" {
 		return cassandra.NewDevops(start, end, scale)
+	} else if format == "clickhouse" {
+		return clickhouse.NewDevops(start, end, scale)
+	} else if format == "elasticsearch" {
+		return elasticsearch.NewDevops(start, end, scale)
+	} else if format == "graphite" {
+		return graphite.NewDevops(start, end, scale)
 	} else if format == "influx" {
 		return influx.NewDevops(start, end, scale)
 	} else if format == "mongo" {
 		return mongo.NewDevops(start, end, scale)
+	} else if format == "opentsdb" {
+		return opentsdb.NewDevops(start, end, scale)
 	} else if format == "prometheus" {
 		return prometheus.NewDevops(start, end, scale)
 	} else if format == "mongo-naive" {
@@ -80,6 +120,40 @@ func getGenerator(format string, start, end time.Time, scale int) utils.DevopsGe
 	panic(fmt.Sprintf("no devops generator specified for format '%s'", format))
 }
 
+func getIoTGenerator(format string, start, end time.Time, scale int) utils.DevopsGenerator {
+	if format == "cassandra" {
+		return cassandra.NewIoT(start, end, scale)
+	} else if format == "influx" {
+		return influx.NewIoT(start, end, scale)
+	} else if format == "mongo" {
+		return mongo.NewIoT(start, end, scale)
+	} else if format == "prometheus" {
+		return prometheus.NewIoT(start, end, scale)
+	} else if format == "timescaledb" {
+		tgen := timescaledb.NewIoT(start, end, scale)
+		tgen.UseJSON = timescaleUseJSON
+		tgen.UseTags = timescaleUseTags
+		return tgen
+	}
+
+	panic(fmt.Sprintf("no iot generator specified for format '%s'", format))
+}
+
+func getK8sGenerator(format string, start, end time.Time, scale int) utils.DevopsGenerator {
+	if format == "influx" {
+		return influx.NewK8s(start, end, scale)
+	} else if format == "prometheus" {
+		return prometheus.NewK8s(start, end, scale)
+	} else if format == "timescaledb" {
+		tgen := timescaledb.NewK8s(start, end, scale)
+		tgen.UseJSON = timescaleUseJSON
+		tgen.UseTags = timescaleUseTags
+		return tgen
+	}
+
+	panic(fmt.Sprintf("no k8s generator specified for format '%s'", format))
+}
+
 // Parse args:
 func init() {
 	useCaseMatrix["cpu-only"] = useCaseMatrix["devops"]
@@ -97,7 +171,7 @@ func init() {
 		}
 	}
 
-	var useCase, queryType, format, timestampStartStr, timestampEndStr string
+	var useCase, queryType, format, timestampStartStr, timestampEndStr, manifestFile string
 	var scaleVar int
 
 	flag.StringVar(&format, "format", "", "Format to emit. (Choices are in the use case matrix.)")
@@ -113,6 +187,10 @@ func init() {
 	flag.StringVar(&timestampStartStr, "timestamp-start", "2016-01-01T00:00:00Z", "Beginning timestamp (RFC3339).")
 	flag.StringVar(&timestampEndStr, "timestamp-end", "2016-01-02T06:00:00Z", "Ending timestamp (RFC3339).")
 
+	flag.StringVar(&manifestFile, "manifest", "", "Manifest written by tsbs_generate_data to take -format, -use-case, -scale-var, -timestamp-start, -timestamp-end and -host-lifetime from. Those set anyway must match it.")
+
+	flag.DurationVar(&hostLifetime, "host-lifetime", 0, "Devops only: lifetime of hosts after which they were replaced (must be equal to the one used for data generation, as must -timestamp-start).")
+
 	flag.Int64Var(&seed, "seed", 0, "PRNG seed (default, or 0, uses the current timestamp).")
 	flag.IntVar(&debug, "debug", 0, "Debug printing (choices: 0, 1) (default 0).")
 
@@ -125,6 +203,10 @@ func init() {
 		log.Fatal("incorrect interleaved groups configuration")
 	}
 
+	if len(manifestFile) > 0 {
+		applyManifest(manifestFile)
+	}
+
 	if _, ok := useCaseMatrix[useCase]; !ok {
 		log.Fatalf("invalid use case specifier: '%s'", useCase)
 	}
@@ -153,7 +235,14 @@ func init() {
 	timestampEnd = timestampEnd.UTC()
 
 	// Make the query generator:
-	generator = getGenerator(format, timestampStart, timestampEnd, scaleVar)
+	generator = getGenerator(useCase, format, timestampStart, timestampEnd, scaleVar)
+	if hostLifetime > 0 {
+		churner, ok := generator.(devops.HostChurner)
+		if !ok {
+			log.Fatal("host lifetime is only supported by the devops use case")
+		}
+		churner.SetHostLifetime(hostLifetime)
+	}
 	filler = useCaseMatrix[useCase][queryType](generator)
 }
 
@@ -221,3 +310,56 @@ func main() {
 		}
 	}
 }
+
+// applyManifest sets the flags describing the dataset to the values of the
+// manifest at path. The flags set on the command line must match them.
+func applyManifest(path string) {
+	m, err := manifest.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(m.UseCase) == 0 {
+		log.Fatalf("cannot generate queries for the dataset of scenario %s", m.Scenario)
+	}
+	useCase := m.UseCase
+	if useCase != "iot" && useCase != "k8s" {
+		// cpu-only and cpu-single datasets are queried as devops ones
+		useCase = "devops"
+	}
+
+	// the end of queries is one second after that of the data, so that
+	// queries cover the last points; the end of the data is accepted too
+	end := m.End.Add(time.Second).Format(time.RFC3339)
+	values := []struct {
+		name     string
+		accepted []string
+	}{
+		{"format", []string{m.Format}},
+		{"use-case", []string{useCase}},
+		{"scale-var", []string{strconv.FormatUint(m.Scale, 10)}},
+		{"timestamp-start", []string{m.Start.Format(time.RFC3339)}},
+		{"timestamp-end", []string{end, m.End.Format(time.RFC3339)}},
+		{"host-lifetime", []string{time.Duration(m.HostLifetime).String()}},
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, v := range values {
+		if !set[v.name] {
+			if err := flag.Set(v.name, v.accepted[0]); err != nil {
+				log.Fatalf("invalid -%s in manifest %s: %v", v.name, path, err)
+			}
+			continue
+		}
+		got := flag.Lookup(v.name).Value.String()
+		matches := false
+		for _, a := range v.accepted {
+			matches = matches || got == a
+		}
+		if !matches {
+			log.Fatalf("-%s %s does not match the manifest's %s", v.name, got, v.accepted[0])
+		}
+	}
+}
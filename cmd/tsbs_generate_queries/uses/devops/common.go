@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"time"
 
+	datadevops "github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
 	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
 	"github.com/hagen1778/tsbs/query"
 )
@@ -49,6 +50,9 @@ type Core struct {
 	Interval utils.TimeInterval
 	// Scale is the cardinality of the dataset in terms of devices/hosts
 	Scale int
+	// HostLifetime is the time after which hosts of the dataset were
+	// replaced by new ones, or zero if they never were
+	HostLifetime time.Duration
 }
 
 // NewCore returns a new Core for the given time range and cardinality
@@ -58,12 +62,24 @@ func NewCore(start, end time.Time, scale int) *Core {
 		return nil
 	}
 
-	return &Core{utils.NewTimeInterval(start, end), scale}
+	return &Core{Interval: utils.NewTimeInterval(start, end), Scale: scale}
 }
 
-// GetRandomHosts returns a random set of nHosts from a given Core
-func (d *Core) GetRandomHosts(nHosts int) []string {
-	return getRandomHosts(d.Scale, nHosts)
+// SetHostLifetime makes the Core pick hosts knowing that those of the dataset
+// were replaced by new ones after lifetime.
+func (d *Core) SetHostLifetime(lifetime time.Duration) {
+	d.HostLifetime = lifetime
+}
+
+// GetRandomHosts returns a random set of nHosts from a given Core, among those
+// alive in the middle of interval
+func (d *Core) GetRandomHosts(nHosts int, interval utils.TimeInterval) []string {
+	var churn *datadevops.HostChurn
+	if d.HostLifetime > 0 {
+		churn = &datadevops.HostChurn{Start: d.Interval.Start, Hosts: uint64(d.Scale), Lifetime: d.HostLifetime}
+	}
+	middle := interval.Start.Add(interval.End.Sub(interval.Start) / 2)
+	return getRandomHosts(d.Scale, nHosts, churn, middle)
 }
 
 // cpuMetrics is the list of metric names for CPU
@@ -133,6 +149,12 @@ type HighCPUFiller interface {
 	HighCPUForHosts(query.Query, int)
 }
 
+// HostChurner is a type that can pick hosts of datasets whose hosts are
+// replaced over time
+type HostChurner interface {
+	SetHostLifetime(time.Duration)
+}
+
 // GetDoubleGroupByLabel returns the Query human-readable label for DoubleGroupBy queries
 func GetDoubleGroupByLabel(dbName string, numMetrics int) string {
 	return fmt.Sprintf("%s mean of %d metrics, all hosts, random %s by 1h", dbName, numMetrics, DoubleGroupByDuration)
@@ -157,7 +179,9 @@ func GetMaxAllLabel(dbName string, nHosts int) string {
 	return fmt.Sprintf("%s max of all CPU metrics, random %4d hosts, random %s by 1h", dbName, nHosts, MaxAllDuration)
 }
 
-func getRandomHosts(scale, nHosts int) []string {
+// getRandomHosts returns nHosts random hostnames among the scale hosts alive
+// at t, as replaced by churn if not nil.
+func getRandomHosts(scale, nHosts int, churn *datadevops.HostChurn, t time.Time) []string {
 	if nHosts < 1 {
 		fatal("number of hosts cannot be < 1; got %d", nHosts)
 		return nil
@@ -171,7 +195,7 @@ func getRandomHosts(scale, nHosts int) []string {
 
 	hostnames := []string{}
 	for _, n := range nn {
-		hostnames = append(hostnames, fmt.Sprintf("host_%d", n))
+		hostnames = append(hostnames, churn.Hostname(uint64(n), t))
 	}
 
 	return hostnames
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
 )
 
 func TestNewCore(t *testing.T) {
@@ -151,7 +153,7 @@ func TestGetRandomHosts(t *testing.T) {
 			fatal = func(format string, args ...interface{}) {
 				errMsg = fmt.Sprintf(format, args...)
 			}
-			hosts := getRandomHosts(c.scale, c.nHosts)
+			hosts := getRandomHosts(c.scale, c.nHosts, nil, time.Time{})
 			if hosts != nil {
 				t.Errorf("%s: fatal'd but with non-nil return: %v", c.desc, hosts)
 			}
@@ -159,7 +161,7 @@ func TestGetRandomHosts(t *testing.T) {
 				t.Errorf("%s: incorrect fatal msg:\ngot\n%s\nwant\n%s", c.desc, errMsg, c.wantFatal)
 			}
 		} else {
-			hosts := getRandomHosts(c.scale, c.nHosts)
+			hosts := getRandomHosts(c.scale, c.nHosts, nil, time.Time{})
 			if got := strings.Join(hosts, ","); got != c.want {
 				t.Errorf("%s: incorrect output: got %s want %s", c.desc, got, c.want)
 			}
@@ -167,6 +169,33 @@ func TestGetRandomHosts(t *testing.T) {
 	}
 }
 
+func TestGetRandomHostsWithLifetime(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCore(start, start.Add(24*time.Hour), 4)
+	early := utils.NewTimeInterval(start, start.Add(time.Hour))
+	late := utils.NewTimeInterval(start.Add(12*time.Hour), start.Add(13*time.Hour))
+	// the hosts are drawn in random order, so only the set of them matters
+	sortedHosts := func(i utils.TimeInterval) string {
+		hosts := c.GetRandomHosts(4, i)
+		sort.Strings(hosts)
+		return strings.Join(hosts, ",")
+	}
+
+	// without a lifetime, the same hosts are alive in every window
+	if got := sortedHosts(late); got != "host_0,host_1,host_2,host_3" {
+		t.Errorf("incorrect hosts: got %s", got)
+	}
+
+	// with a lifetime of 4h, slot 3 is replaced at 1h, 5h, 9h...
+	c.SetHostLifetime(4 * time.Hour)
+	if got := sortedHosts(early); got != "host_0,host_1,host_2,host_3" {
+		t.Errorf("incorrect hosts in early window: got %s", got)
+	}
+	if got := sortedHosts(late); got != "host_12,host_13,host_14,host_15" {
+		t.Errorf("incorrect hosts in late window: got %s", got)
+	}
+}
+
 func TestGetDoubleGroupByLabel(t *testing.T) {
 	want := fmt.Sprintf("Foo mean of 10 metrics, all hosts, random %s by 1h", DoubleGroupByDuration)
 	got := GetDoubleGroupByLabel("Foo", 10)
@@ -0,0 +1,140 @@
+package iot
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+const (
+	allDevices      = "all devices"
+	errBadTimeOrder = "bad time order: start is after end"
+
+	// StaleDevicesDuration is how far back the stale-devices query looks for
+	// devices that reported
+	StaleDevicesDuration = time.Hour
+	// StaleDevicesThreshold is how long a device must not have reported for
+	// to be stale
+	StaleDevicesThreshold = 10 * time.Minute
+	// FleetAveragesDuration is how big the time range for the fleet-averages query is
+	FleetAveragesDuration = 12 * time.Hour
+	// HighLoadDuration is how big the time range for the high-load query is
+	HighLoadDuration = 12 * time.Hour
+	// HighLoadThreshold is the current load, in percent of the load
+	// capacity, above which readings are returned by the high-load query
+	HighLoadThreshold = 90.0
+
+	// LabelStaleDevices is the label for the stale-devices query
+	LabelStaleDevices = "stale-devices"
+	// LabelFleetAverages is the label for the fleet-averages query
+	LabelFleetAverages = "fleet-averages"
+	// LabelLastLoc is the label for the last-loc query
+	LabelLastLoc = "last-loc"
+	// LabelHighLoad is the label prefix for queries of the high-load variety
+	LabelHighLoad = "high-load"
+)
+
+// for ease of testing
+var fatal = log.Fatalf
+
+// Core is the common component of all generators for all systems
+type Core struct {
+	// Interval is the entire time range of the dataset
+	Interval utils.TimeInterval
+	// Scale is the cardinality of the dataset in terms of devices
+	Scale int
+}
+
+// NewCore returns a new Core for the given time range and cardinality
+func NewCore(start, end time.Time, scale int) *Core {
+	if !start.Before(end) {
+		fatal(errBadTimeOrder)
+		return nil
+	}
+
+	return &Core{utils.NewTimeInterval(start, end), scale}
+}
+
+// GetRandomDevices returns the names of a random set of nDevices devices
+func (c *Core) GetRandomDevices(nDevices int) []string {
+	if nDevices < 1 {
+		fatal("number of devices cannot be < 1; got %d", nDevices)
+		return nil
+	}
+	if nDevices > c.Scale {
+		fatal("number of devices (%d) larger than --scale-var (%d)", nDevices, c.Scale)
+		return nil
+	}
+
+	seen := map[int]bool{}
+	names := []string{}
+	for len(names) < nDevices {
+		n := rand.Intn(c.Scale)
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, fmt.Sprintf("truck_%d", n))
+		}
+	}
+	return names
+}
+
+// FleetAveragesMetrics are the readings averaged by the fleet-averages query
+var FleetAveragesMetrics = []string{"velocity", "fuel_consumption"}
+
+// StaleDevicesFiller is a type that can fill in a stale-devices query
+type StaleDevicesFiller interface {
+	StaleDevices(query.Query)
+}
+
+// FleetAveragesFiller is a type that can fill in a fleet-averages query
+type FleetAveragesFiller interface {
+	FleetAverages(query.Query)
+}
+
+// LastLocFiller is a type that can fill in a last-loc query
+type LastLocFiller interface {
+	LastLocPerDevice(query.Query)
+}
+
+// HighLoadFiller is a type that can fill in a high-load query
+type HighLoadFiller interface {
+	HighLoadDevices(query.Query, int)
+}
+
+// GetStaleDevicesLabel returns the Query human-readable label for StaleDevices queries
+func GetStaleDevicesLabel(dbName string) string {
+	return fmt.Sprintf("%s devices without readings for %s, random %s", dbName, StaleDevicesThreshold, StaleDevicesDuration)
+}
+
+// GetFleetAveragesLabel returns the Query human-readable label for FleetAverages queries
+func GetFleetAveragesLabel(dbName string) string {
+	return fmt.Sprintf("%s mean velocity and fuel consumption per fleet, random %s by 1h", dbName, FleetAveragesDuration)
+}
+
+// GetLastLocLabel returns the Query human-readable label for LastLocPerDevice queries
+func GetLastLocLabel(dbName string) string {
+	return dbName + " last location per device"
+}
+
+// GetHighLoadLabel returns the Query human-readable label for HighLoadDevices queries
+func GetHighLoadLabel(dbName string, nDevices int) string {
+	label := dbName + " load over threshold, "
+	if nDevices > 0 {
+		label += fmt.Sprintf("%d device(s)", nDevices)
+	} else if nDevices == 0 {
+		label += allDevices
+	} else {
+		fatal("nDevices cannot be negative")
+		return ""
+	}
+	return label
+}
+
+func panicUnimplementedQuery(dg utils.DevopsGenerator) {
+	panic(fmt.Sprintf("database (%v) does not implement query", reflect.TypeOf(dg)))
+}
@@ -0,0 +1,146 @@
+package iot
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCore(t *testing.T) {
+	s := time.Now()
+	e := s.Add(time.Hour)
+	c := NewCore(s, e, 10)
+	if got := c.Interval.Start.UnixNano(); got != s.UnixNano() {
+		t.Errorf("NewCore does not have right start time: got %d want %d", got, s.UnixNano())
+	}
+	if got := c.Interval.End.UnixNano(); got != e.UnixNano() {
+		t.Errorf("NewCore does not have right end time: got %d want %d", got, e.UnixNano())
+	}
+	if got := c.Scale; got != 10 {
+		t.Errorf("NewCore does not have right scale: got %d want %d", got, 10)
+	}
+}
+
+func TestNewCoreEndBeforeStart(t *testing.T) {
+	e := time.Now()
+	s := e.Add(time.Hour)
+	errMsg := ""
+	fatal = func(format string, args ...interface{}) {
+		errMsg = fmt.Sprintf(format, args...)
+	}
+	_ = NewCore(s, e, 10)
+	if errMsg != errBadTimeOrder {
+		t.Errorf("NewCore did not error correctly")
+	}
+}
+
+func TestGetRandomDevices(t *testing.T) {
+	cases := []struct {
+		desc      string
+		scale     int
+		nDevices  int
+		wantCount int
+		wantFatal string
+	}{
+		{
+			desc:      "0 devices out of 100",
+			scale:     100,
+			nDevices:  0,
+			wantFatal: "number of devices cannot be < 1; got 0",
+		},
+		{
+			desc:      "5 devices out of 1",
+			scale:     1,
+			nDevices:  5,
+			wantFatal: "number of devices (5) larger than --scale-var (1)",
+		},
+		{
+			desc:      "5 devices out of 100",
+			scale:     100,
+			nDevices:  5,
+			wantCount: 5,
+		},
+		{
+			desc:      "all devices",
+			scale:     10,
+			nDevices:  10,
+			wantCount: 10,
+		},
+	}
+
+	for _, c := range cases {
+		rand.Seed(100) // always reset the random number generator
+		errMsg := ""
+		fatal = func(format string, args ...interface{}) {
+			errMsg = fmt.Sprintf(format, args...)
+		}
+		core := &Core{Scale: c.scale}
+		names := core.GetRandomDevices(c.nDevices)
+		if c.wantFatal != "" {
+			if names != nil {
+				t.Errorf("%s: fatal'd but with non-nil return: %v", c.desc, names)
+			}
+			if errMsg != c.wantFatal {
+				t.Errorf("%s: incorrect fatal msg:\ngot\n%s\nwant\n%s", c.desc, errMsg, c.wantFatal)
+			}
+			continue
+		}
+
+		if got := len(names); got != c.wantCount {
+			t.Errorf("%s: incorrect number of devices: got %d want %d", c.desc, got, c.wantCount)
+		}
+		seen := map[string]bool{}
+		for _, n := range names {
+			if !strings.HasPrefix(n, "truck_") {
+				t.Errorf("%s: incorrect device name: %s", c.desc, n)
+			}
+			if seen[n] {
+				t.Errorf("%s: duplicate device name: %s", c.desc, n)
+			}
+			seen[n] = true
+		}
+	}
+}
+
+func TestGetHighLoadLabel(t *testing.T) {
+	cases := []struct {
+		desc        string
+		nDevices    int
+		want        string
+		shouldFatal bool
+	}{
+		{
+			desc:        "nDevices < 0",
+			nDevices:    -1,
+			shouldFatal: true,
+		},
+		{
+			desc:     "nDevices = 0",
+			nDevices: 0,
+			want:     fmt.Sprintf("Foo load over threshold, %s", allDevices),
+		},
+		{
+			desc:     "nDevices > 0",
+			nDevices: 1,
+			want:     "Foo load over threshold, 1 device(s)",
+		},
+	}
+	for _, c := range cases {
+		if c.shouldFatal {
+			errMsg := ""
+			fatal = func(format string, args ...interface{}) {
+				errMsg = fmt.Sprintf(format, args...)
+			}
+			_ = GetHighLoadLabel("Foo", c.nDevices)
+			if errMsg == "" {
+				t.Errorf("%s: did not fatal", c.desc)
+			}
+		} else {
+			if got := GetHighLoadLabel("Foo", c.nDevices); got != c.want {
+				t.Errorf("%s: incorrect output:\ngot\n%s\nwant\n%s", c.desc, got, c.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package iot
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// FleetAverages produces a QueryFiller for the iot fleet-averages case
+type FleetAverages struct {
+	core utils.DevopsGenerator
+}
+
+// NewFleetAverages returns a new FleetAverages for the given generator
+func NewFleetAverages(core utils.DevopsGenerator) utils.QueryFiller {
+	return &FleetAverages{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *FleetAverages) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(FleetAveragesFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.FleetAverages(q)
+	return q
+}
@@ -0,0 +1,30 @@
+package iot
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// HighLoad produces a QueryFiller for the iot high-load cases
+type HighLoad struct {
+	core    utils.DevopsGenerator
+	devices int
+}
+
+// NewHighLoad produces a new function that produces a new HighLoad for
+// nDevices devices, or all of them if 0
+func NewHighLoad(nDevices int) utils.QueryFillerMaker {
+	return func(core utils.DevopsGenerator) utils.QueryFiller {
+		return &HighLoad{core: core, devices: nDevices}
+	}
+}
+
+// Fill fills in the query.Query with query details
+func (i *HighLoad) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(HighLoadFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.HighLoadDevices(q, i.devices)
+	return q
+}
@@ -0,0 +1,26 @@
+package iot
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// LastLoc produces a QueryFiller for the iot last-loc case
+type LastLoc struct {
+	core utils.DevopsGenerator
+}
+
+// NewLastLoc returns a new LastLoc for the given generator
+func NewLastLoc(core utils.DevopsGenerator) utils.QueryFiller {
+	return &LastLoc{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *LastLoc) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(LastLocFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.LastLocPerDevice(q)
+	return q
+}
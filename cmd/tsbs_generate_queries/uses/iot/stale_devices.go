@@ -0,0 +1,26 @@
+package iot
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// StaleDevices produces a QueryFiller for the iot stale-devices case
+type StaleDevices struct {
+	core utils.DevopsGenerator
+}
+
+// NewStaleDevices returns a new StaleDevices for the given generator
+func NewStaleDevices(core utils.DevopsGenerator) utils.QueryFiller {
+	return &StaleDevices{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *StaleDevices) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(StaleDevicesFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.StaleDevices(q)
+	return q
+}
@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"time"
+
+	datak8s "github.com/hagen1778/tsbs/cmd/tsbs_generate_data/k8s"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+const (
+	errBadTimeOrder = "bad time order: start is after end"
+
+	// RollupDuration is how big the time range for the namespace and
+	// deployment rollups is
+	RollupDuration = time.Hour
+	// RollupInterval is the size of the buckets of the rollups
+	RollupInterval = 5 * time.Minute
+	// TopPodsDuration is how big the time range for the top-pods query is
+	TopPodsDuration = time.Hour
+	// PodRestartsDuration is how big the time range for the pod-restarts
+	// query is
+	PodRestartsDuration = time.Hour
+
+	// LabelNamespaceCPU is the label for the namespace-cpu query
+	LabelNamespaceCPU = "namespace-cpu"
+	// LabelNamespaceMemory is the label for the namespace-memory query
+	LabelNamespaceMemory = "namespace-memory"
+	// LabelDeploymentCPU is the label for the deployment-cpu query
+	LabelDeploymentCPU = "deployment-cpu"
+	// LabelTopPodsCPU is the label prefix for queries of the top-pods-cpu
+	// variety
+	LabelTopPodsCPU = "top-pods-cpu"
+	// LabelPodRestarts is the label for the pod-restarts query
+	LabelPodRestarts = "pod-restarts"
+)
+
+// for ease of testing
+var fatal = log.Fatalf
+
+// Core is the common component of all generators for all systems
+type Core struct {
+	// Interval is the entire time range of the dataset
+	Interval utils.TimeInterval
+	// Scale is the cardinality of the dataset in terms of nodes
+	Scale int
+}
+
+// NewCore returns a new Core for the given time range and cardinality
+func NewCore(start, end time.Time, scale int) *Core {
+	if !start.Before(end) {
+		fatal(errBadTimeOrder)
+		return nil
+	}
+
+	return &Core{utils.NewTimeInterval(start, end), scale}
+}
+
+// GetRandomNamespace returns the name of a random namespace of the clusters
+func (c *Core) GetRandomNamespace() string {
+	return datak8s.Namespaces[rand.Intn(len(datak8s.Namespaces))].Name
+}
+
+// NamespaceCPUFiller is a type that can fill in a namespace-cpu query
+type NamespaceCPUFiller interface {
+	NamespaceCPU(query.Query)
+}
+
+// NamespaceMemoryFiller is a type that can fill in a namespace-memory query
+type NamespaceMemoryFiller interface {
+	NamespaceMemory(query.Query)
+}
+
+// DeploymentCPUFiller is a type that can fill in a deployment-cpu query
+type DeploymentCPUFiller interface {
+	DeploymentCPU(query.Query)
+}
+
+// TopPodsCPUFiller is a type that can fill in a top-pods-cpu query
+type TopPodsCPUFiller interface {
+	TopPodsCPU(query.Query, int)
+}
+
+// PodRestartsFiller is a type that can fill in a pod-restarts query
+type PodRestartsFiller interface {
+	PodRestarts(query.Query)
+}
+
+// GetNamespaceCPULabel returns the Query human-readable label for
+// NamespaceCPU queries
+func GetNamespaceCPULabel(dbName string) string {
+	return fmt.Sprintf("%s cpu cores used per namespace, random %s by %s", dbName, RollupDuration, RollupInterval)
+}
+
+// GetNamespaceMemoryLabel returns the Query human-readable label for
+// NamespaceMemory queries
+func GetNamespaceMemoryLabel(dbName string) string {
+	return fmt.Sprintf("%s memory working set per namespace, random %s by %s", dbName, RollupDuration, RollupInterval)
+}
+
+// GetDeploymentCPULabel returns the Query human-readable label for
+// DeploymentCPU queries
+func GetDeploymentCPULabel(dbName string) string {
+	return fmt.Sprintf("%s cpu cores used per deployment of a random namespace, random %s by %s", dbName, RollupDuration, RollupInterval)
+}
+
+// GetTopPodsCPULabel returns the Query human-readable label for TopPodsCPU
+// queries
+func GetTopPodsCPULabel(dbName string, k int) string {
+	if k < 1 {
+		fatal("number of pods cannot be < 1; got %d", k)
+		return ""
+	}
+	return fmt.Sprintf("%s top %d pods by cpu time, random %s", dbName, k, TopPodsDuration)
+}
+
+// GetPodRestartsLabel returns the Query human-readable label for PodRestarts
+// queries
+func GetPodRestartsLabel(dbName string) string {
+	return fmt.Sprintf("%s pods with container restarts, random %s", dbName, PodRestartsDuration)
+}
+
+func panicUnimplementedQuery(dg utils.DevopsGenerator) {
+	panic(fmt.Sprintf("database (%v) does not implement query", reflect.TypeOf(dg)))
+}
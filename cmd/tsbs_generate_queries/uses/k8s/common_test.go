@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	datak8s "github.com/hagen1778/tsbs/cmd/tsbs_generate_data/k8s"
+)
+
+func TestNewCore(t *testing.T) {
+	s := time.Now()
+	e := s.Add(time.Hour)
+	c := NewCore(s, e, 10)
+	if got := c.Interval.Start.UnixNano(); got != s.UnixNano() {
+		t.Errorf("NewCore does not have right start time: got %d want %d", got, s.UnixNano())
+	}
+	if got := c.Interval.End.UnixNano(); got != e.UnixNano() {
+		t.Errorf("NewCore does not have right end time: got %d want %d", got, e.UnixNano())
+	}
+	if got := c.Scale; got != 10 {
+		t.Errorf("NewCore does not have right scale: got %d want %d", got, 10)
+	}
+}
+
+func TestNewCoreEndBeforeStart(t *testing.T) {
+	e := time.Now()
+	s := e.Add(time.Hour)
+	errMsg := ""
+	fatal = func(format string, args ...interface{}) {
+		errMsg = fmt.Sprintf(format, args...)
+	}
+	_ = NewCore(s, e, 10)
+	if errMsg != errBadTimeOrder {
+		t.Errorf("NewCore did not error correctly")
+	}
+}
+
+func TestGetRandomNamespace(t *testing.T) {
+	c := NewCore(time.Now(), time.Now().Add(time.Hour), 10)
+	namespaces := map[string]bool{}
+	for _, ns := range datak8s.Namespaces {
+		namespaces[ns.Name] = true
+	}
+	for i := 0; i < 100; i++ {
+		if ns := c.GetRandomNamespace(); !namespaces[ns] {
+			t.Fatalf("unknown namespace %s", ns)
+		}
+	}
+}
+
+func TestGetTopPodsCPULabel(t *testing.T) {
+	if got, want := GetTopPodsCPULabel("Foo", 5), "Foo top 5 pods by cpu time, random 1h0m0s"; got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	errMsg := ""
+	fatal = func(format string, args ...interface{}) {
+		errMsg = fmt.Sprintf(format, args...)
+	}
+	_ = GetTopPodsCPULabel("Foo", 0)
+	if errMsg == "" {
+		t.Errorf("did not fatal on 0 pods")
+	}
+}
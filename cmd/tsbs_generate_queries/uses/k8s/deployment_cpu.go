@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// DeploymentCPU produces a QueryFiller for the k8s deployment-cpu case
+type DeploymentCPU struct {
+	core utils.DevopsGenerator
+}
+
+// NewDeploymentCPU returns a new DeploymentCPU for the given generator
+func NewDeploymentCPU(core utils.DevopsGenerator) utils.QueryFiller {
+	return &DeploymentCPU{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *DeploymentCPU) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(DeploymentCPUFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.DeploymentCPU(q)
+	return q
+}
@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// NamespaceCPU produces a QueryFiller for the k8s namespace-cpu case
+type NamespaceCPU struct {
+	core utils.DevopsGenerator
+}
+
+// NewNamespaceCPU returns a new NamespaceCPU for the given generator
+func NewNamespaceCPU(core utils.DevopsGenerator) utils.QueryFiller {
+	return &NamespaceCPU{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *NamespaceCPU) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(NamespaceCPUFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.NamespaceCPU(q)
+	return q
+}
@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// NamespaceMemory produces a QueryFiller for the k8s namespace-memory case
+type NamespaceMemory struct {
+	core utils.DevopsGenerator
+}
+
+// NewNamespaceMemory returns a new NamespaceMemory for the given generator
+func NewNamespaceMemory(core utils.DevopsGenerator) utils.QueryFiller {
+	return &NamespaceMemory{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *NamespaceMemory) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(NamespaceMemoryFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.NamespaceMemory(q)
+	return q
+}
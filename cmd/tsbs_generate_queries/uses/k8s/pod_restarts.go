@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// PodRestarts produces a QueryFiller for the k8s pod-restarts case
+type PodRestarts struct {
+	core utils.DevopsGenerator
+}
+
+// NewPodRestarts returns a new PodRestarts for the given generator
+func NewPodRestarts(core utils.DevopsGenerator) utils.QueryFiller {
+	return &PodRestarts{core}
+}
+
+// Fill fills in the query.Query with query details
+func (i *PodRestarts) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(PodRestartsFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.PodRestarts(q)
+	return q
+}
@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// TopPodsCPU produces a QueryFiller for the k8s top-pods-cpu cases
+type TopPodsCPU struct {
+	core utils.DevopsGenerator
+	k    int
+}
+
+// NewTopPodsCPU produces a new function that produces a new TopPodsCPU for
+// the k pods that used the most cpu time
+func NewTopPodsCPU(k int) utils.QueryFillerMaker {
+	return func(core utils.DevopsGenerator) utils.QueryFiller {
+		return &TopPodsCPU{core: core, k: k}
+	}
+}
+
+// Fill fills in the query.Query with query details
+func (i *TopPodsCPU) Fill(q query.Query) query.Query {
+	fc, ok := i.core.(TopPodsCPUFiller)
+	if !ok {
+		panicUnimplementedQuery(i.core)
+	}
+	fc.TopPodsCPU(q, i.k)
+	return q
+}
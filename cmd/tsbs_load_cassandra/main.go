@@ -10,10 +10,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/cassandra"
 )
 
 // Program option vars:
@@ -22,11 +26,14 @@ var (
 	replicationFactor int
 	consistencyLevel  string
 	writeTimeout      time.Duration
+	aggrPlanLabel     string
+	queryConfig       cassandra.Config
 )
 
 // Global vars
 var (
-	loader *load.BenchmarkRunner
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
 )
 
 // Map of user specified strings to gocql consistency settings
@@ -42,6 +49,7 @@ var consistencyMapping = map[string]gocql.Consistency{
 // Parse args:
 func init() {
 	loader = load.GetBenchmarkRunnerWithBatchSize(100)
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
 
 	flag.StringVar(&hosts, "hosts", "localhost:9042", "Comma separated list of Cassandra hosts in a cluster.")
 
@@ -49,6 +57,10 @@ func init() {
 	flag.StringVar(&consistencyLevel, "consistency", "ALL", "Desired write consistency level. See Cassandra consistency documentation. Default: ALL")
 	flag.DurationVar(&writeTimeout, "write-timeout", 10*time.Second, "Write timeout.")
 
+	flag.StringVar(&aggrPlanLabel, "query-aggregation-plan", "server", "In mixed mode, aggregation plan of the queries (choices: server, client)")
+	flag.DurationVar(&queryConfig.RequestTimeout, "query-read-timeout", 1*time.Second, "In mixed mode, maximum query request timeout.")
+	flag.DurationVar(&queryConfig.CSITimeout, "query-client-side-index-timeout", 10*time.Second, "In mixed mode, maximum client-side index timeout (only used when queries start).")
+
 	flag.Parse()
 
 	if _, ok := consistencyMapping[consistencyLevel]; !ok {
@@ -56,6 +68,11 @@ func init() {
 		os.Exit(1)
 	}
 
+	if _, ok := cassandra.AggrPlanChoices[aggrPlanLabel]; !ok {
+		log.Fatal("invalid aggregation plan")
+	}
+	queryConfig.AggrPlan = cassandra.AggrPlanChoices[aggrPlanLabel]
+	queryConfig.DaemonURL = strings.Split(hosts, ",")[0]
 }
 
 type benchmark struct {
@@ -83,6 +100,11 @@ func (b *benchmark) GetDBCreator() load.DBCreator {
 }
 
 func main() {
+	if mixedRunner.Enabled() {
+		createFn := cassandra.NewProcessorCreate(mixedRunner.Queries(), &queryConfig)
+		mixedRunner.Run(&benchmark{dbc: &dbCreator{}}, load.SingleQueue, &query.CassandraPool, createFn)
+		return
+	}
 	loader.RunBenchmark(&benchmark{dbc: &dbCreator{}}, load.SingleQueue)
 }
 
@@ -97,19 +119,34 @@ func (p *processor) Init(_ int, _ bool) {}
 func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 	events := b.(*eventsBatch)
 
+	metricCnt := uint64(len(events.rows))
 	if doLoad {
 		batch := p.dbc.clientSession.NewBatch(gocql.LoggedBatch)
 		for _, event := range events.rows {
 			batch.Query(singleMetricToInsertStatement(event))
 		}
 
-		err := p.dbc.clientSession.ExecuteBatch(batch)
-		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
+		ok := loader.RetryInsert(classifyError, func() error {
+			return p.dbc.clientSession.ExecuteBatch(batch)
+		})
+		if !ok {
+			metricCnt = 0
 		}
 	}
-	metricCnt := uint64(len(events.rows))
 	events.rows = events.rows[:0]
 	ePool.Put(events)
 	return metricCnt, 0
 }
+
+// classifyError retries writes that timed out or could not reach enough
+// replicas, since the cluster may be able to handle them later
+func classifyError(err error) load.ErrorClass {
+	switch err.(type) {
+	case *gocql.RequestErrWriteTimeout, *gocql.RequestErrUnavailable:
+		return load.ErrorRetriable
+	}
+	if err == gocql.ErrTimeoutNoResponse || err == gocql.ErrNoConnections || load.IsTimeout(err) {
+		return load.ErrorRetriable
+	}
+	return load.ErrorFatal
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+type dbCreator struct {
+	header    *load.TableHeader
+	daemonURL string
+}
+
+func (d *dbCreator) Init() {
+	var err error
+	d.header, err = load.ReadTableHeader(loader.GetBufferedReader())
+	if err != nil {
+		fatal("input has wrong header format: %v", err)
+	}
+	d.daemonURL = daemonURLs[0] // pick first one since it always exists
+
+	// Tables are known from the header even if the database is not created,
+	// so the number of metrics of each row can be counted
+	tableCols = d.header.Fields
+}
+
+func (d *dbCreator) DBExists(dbName string) bool {
+	res, err := execQuery(d.daemonURL, fmt.Sprintf("SELECT count() FROM system.databases WHERE name = '%s'", dbName), nil)
+	if err != nil {
+		fatal("could not list databases: %v", err)
+	}
+	return strings.TrimSpace(string(res)) != "0"
+}
+
+func (d *dbCreator) RemoveOldDB(dbName string) error {
+	_, err := execQuery(d.daemonURL, "DROP DATABASE IF EXISTS "+dbName, nil)
+	return err
+}
+
+func (d *dbCreator) CreateDB(dbName string) error {
+	if _, err := execQuery(d.daemonURL, "CREATE DATABASE "+dbName, nil); err != nil {
+		return err
+	}
+
+	for _, table := range d.header.Tables {
+		sql := createTableSQL(dbName, table, d.header.TagKeys, d.header.Fields[table])
+		if _, err := execQuery(d.daemonURL, sql, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTableSQL returns the statement creating the MergeTree table of a
+// measurement, with a column per tag and per field. The table is partitioned
+// by time and ordered by its tags then time, so the rows of a series are
+// stored together.
+func createTableSQL(dbName, table string, tags, fields []string) string {
+	cols := []string{"time DateTime64(9, 'UTC')"}
+	for _, tag := range tags {
+		cols = append(cols, fmt.Sprintf("%s LowCardinality(String)", tag))
+	}
+	for _, field := range fields {
+		if len(field) == 0 {
+			continue
+		}
+		cols = append(cols, fmt.Sprintf("%s Nullable(Float64)", field))
+	}
+	orderBy := append(append([]string{}, tags...), "time")
+	return fmt.Sprintf("CREATE TABLE %s.%s (%s) ENGINE = MergeTree PARTITION BY %s ORDER BY (%s)",
+		dbName, table, strings.Join(cols, ", "), partitionBy, strings.Join(orderBy, ", "))
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCreateTableSQL(t *testing.T) {
+	oldPartitionBy := partitionBy
+	defer func() { partitionBy = oldPartitionBy }()
+	partitionBy = "toYYYYMMDD(time)"
+
+	got := createTableSQL("benchmark", "cpu", []string{"hostname", "region"}, []string{"usage_user", "", "usage_system"})
+	want := "CREATE TABLE benchmark.cpu (time DateTime64(9, 'UTC'), hostname LowCardinality(String), region LowCardinality(String), usage_user Nullable(Float64), usage_system Nullable(Float64)) ENGINE = MergeTree PARTITION BY toYYYYMMDD(time) ORDER BY (hostname, region, time)"
+	if got != want {
+		t.Errorf("incorrect create table statement:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
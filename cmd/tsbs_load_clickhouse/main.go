@@ -0,0 +1,90 @@
+// tsbs_load_clickhouse loads a ClickHouse server with data from stdin.
+//
+// If the database exists beforehand, it will be *DROPPED*.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/clickhouse"
+)
+
+// Program option vars:
+var (
+	daemonURLs  []string
+	user        string
+	password    string
+	partitionBy string
+)
+
+// Global vars
+var (
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+	tableCols   map[string][]string
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
+	var csvDaemonURLs string
+
+	flag.StringVar(&csvDaemonURLs, "urls", "http://localhost:8123", "ClickHouse HTTP interface URLs, comma-separated. Will be used in a round-robin fashion.")
+	flag.StringVar(&user, "user", "", "User to connect to ClickHouse as, if any.")
+	flag.StringVar(&password, "password", "", "Password of the user.")
+	flag.StringVar(&partitionBy, "partition-by", "toYYYYMMDD(time)", "Expression the MergeTree tables are partitioned by.")
+
+	flag.Parse()
+
+	daemonURLs = strings.Split(csvDaemonURLs, ",")
+	if len(daemonURLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+	tableCols = make(map[string][]string)
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetPointDecoder(br *bufio.Reader) load.PointDecoder {
+	return &decoder{scanner: bufio.NewScanner(br)}
+}
+
+func (b *benchmark) GetBatchFactory() load.BatchFactory {
+	return &factory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) load.PointIndexer {
+	return &load.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() load.Processor {
+	return &processor{}
+}
+
+func (b *benchmark) GetDBCreator() load.DBCreator {
+	return &dbCreator{}
+}
+
+func main() {
+	if mixedRunner.Enabled() {
+		queryConfig := &clickhouse.Config{
+			URLs:     daemonURLs,
+			User:     user,
+			Password: password,
+		}
+		createFn := clickhouse.NewProcessorCreate(mixedRunner.Queries(), queryConfig)
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, createFn)
+		return
+	}
+	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
+}
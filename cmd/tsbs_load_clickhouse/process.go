@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/query/clickhouse"
+)
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+type processor struct {
+	daemonURL string
+}
+
+func (p *processor) Init(numWorker int, _ bool) {
+	p.daemonURL = daemonURLs[numWorker%len(daemonURLs)]
+}
+
+func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*tableBatch)
+	if doLoad {
+		for table, rows := range batch.m {
+			sql := fmt.Sprintf("INSERT INTO %s.%s FORMAT TabSeparated", loader.DatabaseName(), table)
+			data := rows.Bytes()
+			ok := loader.RetryInsert(load.ClassifyHTTPError, func() error {
+				_, err := execQuery(p.daemonURL, sql, bytes.NewReader(data))
+				return err
+			})
+			if !ok {
+				return 0, 0
+			}
+		}
+	}
+	return batch.metrics, batch.rows
+}
+
+// execQuery runs sql with the HTTP interface at daemonURL and returns the
+// response. If data is not nil, it is sent as the body of the request, the
+// data of an INSERT, and sql in the URL; otherwise sql is the body.
+func execQuery(daemonURL, sql string, data io.Reader) ([]byte, error) {
+	u := daemonURL + "/"
+	if data != nil {
+		u += "?query=" + url.QueryEscape(sql)
+	} else {
+		data = bytes.NewReader([]byte(sql))
+	}
+	req, err := http.NewRequest("POST", u, data)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	clickhouse.SetAuth(req, user, password)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &load.RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &load.RequestError{Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &load.StatusError{Code: resp.StatusCode, Body: bytes.TrimSpace(body)}
+	}
+	return body, nil
+}
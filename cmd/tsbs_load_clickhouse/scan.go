@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+// point is a single TabSeparated row of data keyed by which table it belongs
+type point struct {
+	table string
+	row   []byte
+}
+
+type decoder struct {
+	scanner *bufio.Scanner
+}
+
+func (d *decoder) Decode(_ *bufio.Reader) *load.Point {
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return nil
+	} else if !ok {
+		fatal("scan error: %v", d.scanner.Err())
+		return nil
+	}
+
+	// Each line is the table name, then the row to insert in it
+	line := d.scanner.Bytes()
+	idx := bytes.IndexByte(line, '\t')
+	if idx <= 0 {
+		fatal("data file in invalid format; no table name in line: %s", line)
+		return nil
+	}
+	row := make([]byte, len(line)-idx-1)
+	copy(row, line[idx+1:])
+	return load.NewPoint(&point{
+		table: string(line[:idx]),
+		row:   row,
+	})
+}
+
+// tableBatch holds the rows of each table, ready to be inserted as
+// TabSeparated data
+type tableBatch struct {
+	m       map[string]*bytes.Buffer
+	rows    uint64
+	metrics uint64
+}
+
+func (b *tableBatch) Len() int {
+	return int(b.rows)
+}
+
+func (b *tableBatch) Append(item *load.Point) {
+	that := item.Data.(*point)
+	buf, ok := b.m[that.table]
+	if !ok {
+		buf = &bytes.Buffer{}
+		b.m[that.table] = buf
+	}
+	buf.Write(that.row)
+	buf.WriteByte('\n')
+	b.rows++
+	b.metrics += uint64(len(tableCols[that.table]))
+}
+
+type factory struct{}
+
+func (f *factory) New() load.Batch {
+	return &tableBatch{m: map[string]*bytes.Buffer{}}
+}
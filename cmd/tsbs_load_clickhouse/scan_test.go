@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		wantTable   string
+		wantRow     string
+		shouldFatal bool
+	}{
+		{
+			desc:      "correct input",
+			input:     "cpu\t140\thost_0\t1.5\t\\N\n",
+			wantTable: "cpu",
+			wantRow:   "140\thost_0\t1.5\t\\N",
+		},
+		{
+			desc:        "no table name",
+			input:       "\t140\thost_0\t1.5\n",
+			shouldFatal: true,
+		},
+		{
+			desc:        "no tab",
+			input:       "tags,hostname\n",
+			shouldFatal: true,
+		},
+	}
+	for _, c := range cases {
+		br := bufio.NewReader(bytes.NewReader([]byte(c.input)))
+		decoder := &decoder{scanner: bufio.NewScanner(br)}
+		if c.shouldFatal {
+			isCalled := false
+			fatal = func(fmt string, args ...interface{}) {
+				isCalled = true
+				log.Printf(fmt, args...)
+			}
+			_ = decoder.Decode(br)
+			if !isCalled {
+				t.Errorf("%s: did not call fatal when it should", c.desc)
+			}
+			continue
+		}
+		p := decoder.Decode(br).Data.(*point)
+		if p.table != c.wantTable {
+			t.Errorf("%s: incorrect table: got %s want %s", c.desc, p.table, c.wantTable)
+		}
+		if string(p.row) != c.wantRow {
+			t.Errorf("%s: incorrect row: got %q want %q", c.desc, p.row, c.wantRow)
+		}
+	}
+
+	decoder := &decoder{scanner: bufio.NewScanner(bytes.NewReader(nil))}
+	if p := decoder.Decode(nil); p != nil {
+		t.Errorf("Decode did not return nil at EOF: got %v", p)
+	}
+}
+
+func TestTableBatch(t *testing.T) {
+	tableCols["cpu"] = []string{"usage_user", "usage_system"}
+	tableCols["mem"] = []string{"used"}
+	defer delete(tableCols, "cpu")
+	defer delete(tableCols, "mem")
+
+	b := (&factory{}).New().(*tableBatch)
+	if b.Len() != 0 {
+		t.Errorf("tableBatch not initialized with count 0")
+	}
+	b.Append(load.NewPoint(&point{table: "cpu", row: []byte("0\thost_0\t1\t2")}))
+	b.Append(load.NewPoint(&point{table: "mem", row: []byte("0\thost_0\t3")}))
+	b.Append(load.NewPoint(&point{table: "cpu", row: []byte("1\thost_0\t4\t5")}))
+	if b.Len() != 3 {
+		t.Errorf("tableBatch count is not 3 after 3 appends: got %d", b.Len())
+	}
+	if b.metrics != 5 {
+		t.Errorf("tableBatch metrics is not 5: got %d", b.metrics)
+	}
+	if got, want := b.m["cpu"].String(), "0\thost_0\t1\t2\n1\thost_0\t4\t5\n"; got != want {
+		t.Errorf("incorrect cpu rows: got %q want %q", got, want)
+	}
+	if got, want := b.m["mem"].String(), "0\thost_0\t3\n"; got != want {
+		t.Errorf("incorrect mem rows: got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+type dbCreator struct {
+	header    *load.TableHeader
+	daemonURL string
+}
+
+func (d *dbCreator) Init() {
+	var err error
+	d.header, err = load.ReadTableHeader(loader.GetBufferedReader())
+	if err != nil {
+		fatal("input has wrong header format: %v", err)
+	}
+	d.daemonURL = daemonURLs[0] // pick first one since it always exists
+
+	// The fields of the indices are known from the header even if the
+	// database is not created, so the number of metrics of each document
+	// can be counted
+	indexFields = d.header.Fields
+}
+
+// indices returns the names of the indices of database dbName
+func (d *dbCreator) indices(dbName string) []string {
+	res, err := execRequest("GET", d.daemonURL+"/_cat/indices/"+dbName+"-*?h=index", nil)
+	if err != nil {
+		fatal("could not list indices: %v", err)
+	}
+	return strings.Fields(string(res))
+}
+
+func (d *dbCreator) DBExists(dbName string) bool {
+	return len(d.indices(dbName)) > 0
+}
+
+func (d *dbCreator) RemoveOldDB(dbName string) error {
+	// Indices are deleted by name, since deleting them with a wildcard is
+	// not allowed by default
+	indices := d.indices(dbName)
+	for len(indices) > 0 {
+		n := len(indices)
+		if n > 100 {
+			n = 100
+		}
+		if _, err := execRequest("DELETE", d.daemonURL+"/"+strings.Join(indices[:n], ","), nil); err != nil {
+			return err
+		}
+		indices = indices[n:]
+	}
+	for _, table := range d.header.Tables {
+		_, err := execRequest("DELETE", d.daemonURL+"/_index_template/"+dbName+"-"+table, nil)
+		if se, ok := err.(*load.StatusError); ok && se.Code == http.StatusNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dbCreator) CreateDB(dbName string) error {
+	for _, table := range d.header.Tables {
+		name := dbName + "-" + table
+		body := indexTemplate(name, d.header.TagKeys, d.header.Fields[table])
+		if _, err := execRequest("PUT", d.daemonURL+"/_index_template/"+name, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexTemplate returns the body of the index template of the daily indices
+// of a measurement, whose names start with name. Tags are mapped as
+// keywords, so they can be filtered on and aggregated by, and fields as
+// doubles.
+func indexTemplate(name string, tags, fields []string) []byte {
+	properties := map[string]interface{}{
+		"@timestamp": map[string]string{"type": "date", "format": "epoch_millis"},
+	}
+	for _, tag := range tags {
+		properties[tag] = map[string]string{"type": "keyword"}
+	}
+	for _, field := range fields {
+		if len(field) == 0 {
+			continue
+		}
+		properties[field] = map[string]string{"type": "double"}
+	}
+	template := map[string]interface{}{
+		"index_patterns": []string{name + "-*"},
+		"template": map[string]interface{}{
+			"settings": map[string]interface{}{
+				"number_of_shards":   numShards,
+				"number_of_replicas": numReplicas,
+				"refresh_interval":   refreshInterval,
+			},
+			"mappings": map[string]interface{}{
+				"properties": properties,
+			},
+		},
+	}
+	body, err := json.Marshal(template)
+	if err != nil {
+		fatal("could not marshal index template: %v", err)
+	}
+	return body
+}
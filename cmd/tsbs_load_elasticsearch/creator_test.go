@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIndexTemplate(t *testing.T) {
+	oldShards, oldReplicas, oldRefresh := numShards, numReplicas, refreshInterval
+	defer func() { numShards, numReplicas, refreshInterval = oldShards, oldReplicas, oldRefresh }()
+	numShards, numReplicas, refreshInterval = 2, 1, "30s"
+
+	got := string(indexTemplate("benchmark-cpu", []string{"hostname", "region"}, []string{"usage_user", "", "usage_system"}))
+	want := `{"index_patterns":["benchmark-cpu-*"],"template":{"mappings":{"properties":{"@timestamp":{"format":"epoch_millis","type":"date"},"hostname":{"type":"keyword"},"region":{"type":"keyword"},"usage_system":{"type":"double"},"usage_user":{"type":"double"}}},"settings":{"number_of_replicas":1,"number_of_shards":2,"refresh_interval":"30s"}}}`
+	if got != want {
+		t.Errorf("incorrect index template:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
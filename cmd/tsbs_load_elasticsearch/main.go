@@ -0,0 +1,89 @@
+// tsbs_load_elasticsearch loads an Elasticsearch or OpenSearch cluster with
+// data from stdin.
+//
+// Documents are sent with the _bulk API to daily indices named after the
+// database and their measurement, e.g. benchmark-cpu-2016.01.01, created
+// from an index template per measurement. If indices or templates of the
+// database exist beforehand, they will be *DELETED*.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/elasticsearch"
+)
+
+// Program option vars:
+var (
+	daemonURLs      []string
+	numShards       uint
+	numReplicas     uint
+	refreshInterval string
+)
+
+// Global vars
+var (
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+	indexFields map[string][]string
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
+	var csvDaemonURLs string
+
+	flag.StringVar(&csvDaemonURLs, "urls", "http://localhost:9200", "Elasticsearch URLs, comma-separated. Will be used in a round-robin fashion.")
+	flag.UintVar(&numShards, "shards", 1, "Number of primary shards of each index.")
+	flag.UintVar(&numReplicas, "replicas", 0, "Number of replicas of each shard.")
+	flag.StringVar(&refreshInterval, "refresh-interval", "30s", "How often the indices are refreshed, making new documents searchable (-1 to disable).")
+
+	flag.Parse()
+
+	daemonURLs = strings.Split(csvDaemonURLs, ",")
+	if len(daemonURLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+	indexFields = make(map[string][]string)
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetPointDecoder(br *bufio.Reader) load.PointDecoder {
+	return &decoder{scanner: bufio.NewScanner(br), prefix: loader.DatabaseName() + "-"}
+}
+
+func (b *benchmark) GetBatchFactory() load.BatchFactory {
+	return &factory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) load.PointIndexer {
+	return &load.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() load.Processor {
+	return &processor{}
+}
+
+func (b *benchmark) GetDBCreator() load.DBCreator {
+	return &dbCreator{}
+}
+
+func main() {
+	if mixedRunner.Enabled() {
+		createFn := elasticsearch.NewProcessorCreate(mixedRunner.Queries(), &elasticsearch.Config{URLs: daemonURLs})
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, createFn)
+		return
+	}
+	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+type processor struct {
+	bulkURL string
+}
+
+func (p *processor) Init(numWorker int, _ bool) {
+	p.bulkURL = daemonURLs[numWorker%len(daemonURLs)] + "/_bulk"
+}
+
+func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*batch)
+	if doLoad {
+		// Only the documents rejected by the previous attempt are retried,
+		// since the others were indexed
+		data := batch.buf.Bytes()
+		ok := loader.RetryInsert(load.ClassifyHTTPError, func() error {
+			rejected, err := p.bulk(data)
+			if rejected != nil {
+				data = rejected
+			}
+			return err
+		})
+		if !ok {
+			return 0, 0
+		}
+	}
+	return batch.metrics, batch.docs
+}
+
+// bulkResponse is the part of a response of the _bulk API the loader reads
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends data, the lines of the bulk request of a batch. If some of the
+// documents are rejected but would be accepted later, e.g., when the queues
+// of the cluster are full, it returns the lines of those documents to retry
+// as well as an error. If documents are rejected for good, e.g., for not
+// matching the mappings, the error drops the batch.
+func (p *processor) bulk(data []byte) ([]byte, error) {
+	res, err := execRequest("POST", p.bulkURL, data)
+	if err != nil {
+		return nil, err
+	}
+	var resp bulkResponse
+	if err := json.Unmarshal(res, &resp); err != nil {
+		return nil, fmt.Errorf("could not decode bulk response: %v", err)
+	}
+	if !resp.Errors {
+		return nil, nil
+	}
+
+	// Each document is an action line and a document line, in the order of
+	// the items of the response
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines) < 2*len(resp.Items) {
+		return nil, fmt.Errorf("bulk response has %d items for %d lines", len(resp.Items), len(lines))
+	}
+	var rejected []byte
+	var failed *load.StatusError
+	for i, item := range resp.Items {
+		for _, result := range item {
+			if result.Status < http.StatusMultipleChoices {
+				continue
+			}
+			if load.ClassifyHTTPStatus(result.Status) == load.ErrorRetriable {
+				rejected = append(rejected, lines[2*i]...)
+				rejected = append(rejected, lines[2*i+1]...)
+			}
+			if failed == nil || load.ClassifyHTTPStatus(failed.Code) == load.ErrorRetriable {
+				failed = &load.StatusError{Code: result.Status, Body: result.Error}
+			}
+		}
+	}
+	if failed == nil {
+		return nil, nil
+	}
+	return rejected, failed
+}
+
+// execRequest sends a request with body, if not nil, to u and returns the
+// response
+func execRequest(method, u string, body []byte) ([]byte, error) {
+	var data io.Reader
+	if body != nil {
+		data = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, data)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	} else if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &load.RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+	res, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &load.RequestError{Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &load.StatusError{Code: resp.StatusCode, Body: bytes.TrimSpace(res)}
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+func TestProcessorBulk(t *testing.T) {
+	data := []byte("a1\nd1\na2\nd2\na3\nd3\n")
+	cases := []struct {
+		desc         string
+		response     string
+		wantRejected string
+		wantClass    load.ErrorClass
+		wantOK       bool
+	}{
+		{
+			desc:     "all indexed",
+			response: `{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}},{"index":{"status":201}}]}`,
+			wantOK:   true,
+		},
+		{
+			desc:         "some rejected",
+			response:     `{"errors":true,"items":[{"index":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},{"index":{"status":201}},{"index":{"status":503}}]}`,
+			wantRejected: "a1\nd1\na3\nd3\n",
+			wantClass:    load.ErrorRetriable,
+		},
+		{
+			desc:         "some failed for good",
+			response:     `{"errors":true,"items":[{"index":{"status":429}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}},{"index":{"status":201}}]}`,
+			wantRejected: "a1\nd1\n",
+			wantClass:    load.ErrorDrop,
+		},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != string(data) {
+				t.Errorf("%s: incorrect request body: got %q", c.desc, body)
+			}
+			if got := r.Header.Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("%s: incorrect content type: got %s", c.desc, got)
+			}
+			w.Write([]byte(c.response))
+		}))
+		p := &processor{bulkURL: server.URL + "/_bulk"}
+		rejected, err := p.bulk(data)
+		server.Close()
+		if c.wantOK {
+			if err != nil || rejected != nil {
+				t.Errorf("%s: unexpected error %v or rejected documents %q", c.desc, err, rejected)
+			}
+			continue
+		}
+		if string(rejected) != c.wantRejected {
+			t.Errorf("%s: incorrect rejected documents: got %q want %q", c.desc, rejected, c.wantRejected)
+		}
+		if err == nil {
+			t.Errorf("%s: no error returned", c.desc)
+		} else if got := load.ClassifyHTTPError(err); got != c.wantClass {
+			t.Errorf("%s: incorrect error class: got %d want %d", c.desc, got, c.wantClass)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+var indexKey = []byte(`"_index":"`)
+
+// point is the action and document lines of a bulk request for a single
+// document, keyed by which table it belongs
+type point struct {
+	table string
+	lines []byte
+}
+
+type decoder struct {
+	scanner *bufio.Scanner
+	prefix  string // prefix is prepended to the index names
+}
+
+func (d *decoder) Decode(_ *bufio.Reader) *load.Point {
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return nil
+	} else if !ok {
+		fatal("scan error: %v", d.scanner.Err())
+		return nil
+	}
+
+	// The action line names the index, <table>-<day>, of the document on
+	// the next line
+	action := d.scanner.Bytes()
+	start := bytes.Index(action, indexKey)
+	if start < 0 {
+		fatal("data file in invalid format; no index in action: %s", action)
+		return nil
+	}
+	start += len(indexKey)
+	end := bytes.IndexByte(action[start:], '"')
+	if end < 0 {
+		fatal("data file in invalid format; invalid index in action: %s", action)
+		return nil
+	}
+	index := action[start : start+end]
+	sep := bytes.LastIndexByte(index, '-')
+	if sep <= 0 {
+		fatal("data file in invalid format; no day in index: %s", index)
+		return nil
+	}
+	table := string(index[:sep])
+
+	lines := make([]byte, 0, 2*len(action)+len(d.prefix))
+	lines = append(lines, action[:start]...)
+	lines = append(lines, d.prefix...)
+	lines = append(lines, action[start:]...)
+	lines = append(lines, '\n')
+
+	if !d.scanner.Scan() {
+		fatal("data file in invalid format; no document after action: %s", action)
+		return nil
+	}
+	lines = append(lines, d.scanner.Bytes()...)
+	lines = append(lines, '\n')
+	return load.NewPoint(&point{table: table, lines: lines})
+}
+
+// batch holds the lines of a bulk request
+type batch struct {
+	buf     *bytes.Buffer
+	docs    uint64
+	metrics uint64
+}
+
+func (b *batch) Len() int {
+	return int(b.docs)
+}
+
+func (b *batch) Append(item *load.Point) {
+	that := item.Data.(*point)
+	b.buf.Write(that.lines)
+	b.docs++
+	b.metrics += uint64(len(indexFields[that.table]))
+}
+
+type factory struct{}
+
+func (f *factory) New() load.Batch {
+	return &batch{buf: &bytes.Buffer{}}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		wantTable   string
+		wantLines   string
+		shouldFatal bool
+	}{
+		{
+			desc:      "correct input",
+			input:     "{\"index\":{\"_index\":\"cpu-2016.01.01\"}}\n{\"@timestamp\":1451606400000,\"hostname\":\"host_0\"}\n",
+			wantTable: "cpu",
+			wantLines: "{\"index\":{\"_index\":\"benchmark-cpu-2016.01.01\"}}\n{\"@timestamp\":1451606400000,\"hostname\":\"host_0\"}\n",
+		},
+		{
+			desc:      "table with dashes",
+			input:     "{\"index\":{\"_index\":\"disk-io-2016.01.01\"}}\n{}\n",
+			wantTable: "disk-io",
+			wantLines: "{\"index\":{\"_index\":\"benchmark-disk-io-2016.01.01\"}}\n{}\n",
+		},
+		{
+			desc:        "no index",
+			input:       "{\"index\":{}}\n{}\n",
+			shouldFatal: true,
+		},
+		{
+			desc:        "no day in index",
+			input:       "{\"index\":{\"_index\":\"cpu\"}}\n{}\n",
+			shouldFatal: true,
+		},
+		{
+			desc:        "no document",
+			input:       "{\"index\":{\"_index\":\"cpu-2016.01.01\"}}\n",
+			shouldFatal: true,
+		},
+	}
+	for _, c := range cases {
+		br := bufio.NewReader(bytes.NewReader([]byte(c.input)))
+		decoder := &decoder{scanner: bufio.NewScanner(br), prefix: "benchmark-"}
+		if c.shouldFatal {
+			isCalled := false
+			fatal = func(fmt string, args ...interface{}) {
+				isCalled = true
+				log.Printf(fmt, args...)
+			}
+			_ = decoder.Decode(br)
+			if !isCalled {
+				t.Errorf("%s: did not call fatal when it should", c.desc)
+			}
+			continue
+		}
+		p := decoder.Decode(br).Data.(*point)
+		if p.table != c.wantTable {
+			t.Errorf("%s: incorrect table: got %s want %s", c.desc, p.table, c.wantTable)
+		}
+		if string(p.lines) != c.wantLines {
+			t.Errorf("%s: incorrect lines: got %q want %q", c.desc, p.lines, c.wantLines)
+		}
+	}
+
+	decoder := &decoder{scanner: bufio.NewScanner(bytes.NewReader(nil))}
+	if p := decoder.Decode(nil); p != nil {
+		t.Errorf("Decode did not return nil at EOF: got %v", p)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	indexFields["cpu"] = []string{"usage_user", "usage_system"}
+	indexFields["mem"] = []string{"used"}
+	defer delete(indexFields, "cpu")
+	defer delete(indexFields, "mem")
+
+	b := (&factory{}).New().(*batch)
+	if b.Len() != 0 {
+		t.Errorf("batch not initialized with count 0")
+	}
+	b.Append(load.NewPoint(&point{table: "cpu", lines: []byte("a\nb\n")}))
+	b.Append(load.NewPoint(&point{table: "mem", lines: []byte("c\nd\n")}))
+	if b.Len() != 2 {
+		t.Errorf("batch count is not 2 after 2 appends: got %d", b.Len())
+	}
+	if b.metrics != 3 {
+		t.Errorf("batch metrics is not 3: got %d", b.metrics)
+	}
+	if got, want := b.buf.String(), "a\nb\nc\nd\n"; got != want {
+		t.Errorf("incorrect lines: got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+// tsbs_load_graphite loads a Graphite server with data from stdin.
+//
+// Data points are streamed with the plaintext protocol over TCP. Graphite has
+// no databases, so none is created or dropped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/graphite"
+)
+
+// Program option vars:
+var (
+	addresses []string
+	queryURLs []string
+)
+
+// Global vars
+var (
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
+	var csvAddresses, csvQueryURLs string
+
+	flag.StringVar(&csvAddresses, "addresses", "localhost:2003", "Host:port of the plaintext receivers, comma-separated. Will be used in a round-robin fashion.")
+	flag.StringVar(&csvQueryURLs, "query-urls", "http://localhost:8080", "In mixed mode, Graphite web URLs to send queries to, comma-separated.")
+
+	flag.Parse()
+
+	addresses = strings.Split(csvAddresses, ",")
+	if len(addresses) == 0 {
+		log.Fatal("missing 'addresses' flag")
+	}
+	queryURLs = strings.Split(csvQueryURLs, ",")
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetPointDecoder(br *bufio.Reader) load.PointDecoder {
+	return load.NewLineDecoder(br)
+}
+
+func (b *benchmark) GetBatchFactory() load.BatchFactory {
+	return &load.LineBatchFactory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) load.PointIndexer {
+	return &load.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() load.Processor {
+	return &processor{}
+}
+
+func (b *benchmark) GetDBCreator() load.DBCreator {
+	return &dbCreator{}
+}
+
+func main() {
+	if mixedRunner.Enabled() {
+		createFn := graphite.NewProcessorCreate(mixedRunner.Queries(), &graphite.Config{URLs: queryURLs})
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, createFn)
+		return
+	}
+	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
+}
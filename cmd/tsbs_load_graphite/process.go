@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+const writeTimeout = time.Minute
+
+type processor struct {
+	tcp *load.TCPWriter
+}
+
+func (p *processor) Init(numWorker int, _ bool) {
+	p.tcp = &load.TCPWriter{Address: addresses[numWorker%len(addresses)], Timeout: writeTimeout}
+}
+
+func (p *processor) Close(_ bool) {
+	p.tcp.Close()
+}
+
+func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*load.LineBatch)
+	if !doLoad {
+		return uint64(batch.Len()), 0
+	}
+	ok := loader.RetryInsert(classifyError, func() error {
+		return p.tcp.Write(batch.Bytes())
+	})
+	if !ok {
+		return 0, 0
+	}
+	return uint64(batch.Len()), 0
+}
+
+// classifyError retries writes over broken connections
+func classifyError(err error) load.ErrorClass {
+	if _, ok := err.(net.Error); ok {
+		return load.ErrorRetriable
+	}
+	return load.ErrorFatal
+}
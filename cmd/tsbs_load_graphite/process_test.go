@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+func TestProcessBatch(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		b, _ := ioutil.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	oldAddresses := addresses
+	defer func() { addresses = oldAddresses }()
+	addresses = []string{ln.Addr().String()}
+
+	input := "cpu.usage_user;hostname=host_0 1 1\ncpu.usage_system;hostname=host_0 2 1\n"
+	br := bufio.NewReader(bytes.NewReader([]byte(input)))
+	decoder := load.NewLineDecoder(br)
+	b := (&load.LineBatchFactory{}).New()
+	for p := decoder.Decode(br); p != nil; p = decoder.Decode(br) {
+		b.Append(p)
+	}
+
+	p := &processor{}
+	p.Init(0, true)
+	metrics, rows := p.ProcessBatch(b, true)
+	if metrics != 2 || rows != 0 {
+		t.Errorf("incorrect counts: got %d metrics and %d rows, want 2 and 0", metrics, rows)
+	}
+	p.Close(true)
+	if got := <-received; got != input {
+		t.Errorf("incorrect data received: got %q want %q", got, input)
+	}
+}
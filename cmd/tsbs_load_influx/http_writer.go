@@ -30,9 +30,6 @@ type HTTPWriterConfig struct {
 	// Name of the target database into which points will be written.
 	Database string
 
-	BackingOffChan chan bool
-	BackingOffDone chan struct{}
-
 	// Debug label for more informative errors.
 	DebugInfo string
 }
@@ -12,9 +12,11 @@ import (
 	"log"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/influx"
 	"github.com/valyala/fasthttp"
 )
 
@@ -22,7 +24,6 @@ import (
 var (
 	daemonURLs        []string
 	replicationFactor int
-	backoff           time.Duration
 	useGzip           bool
 	doAbortOnExist    bool
 	consistency       string
@@ -30,8 +31,10 @@ var (
 
 // Global vars
 var (
-	loader  *load.BenchmarkRunner
-	bufPool sync.Pool
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+	queryConfig influx.Config
+	bufPool     sync.Pool
 )
 
 var consistencyChoices = map[string]struct{}{
@@ -47,13 +50,14 @@ var fatal = log.Fatalf
 // Parse args:
 func init() {
 	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
 	var csvDaemonURLs string
 
 	flag.StringVar(&csvDaemonURLs, "urls", "http://localhost:8086", "InfluxDB URLs, comma-separated. Will be used in a round-robin fashion.")
 	flag.IntVar(&replicationFactor, "replication-factor", 1, "Cluster replication factor (only applies to clustered databases).")
 	flag.StringVar(&consistency, "consistency", "all", "Write consistency. Must be one of: any, one, quorum, all.")
-	flag.DurationVar(&backoff, "backoff", time.Second, "Time to sleep between requests when server indicates backpressure is needed.")
 	flag.BoolVar(&useGzip, "gzip", true, "Whether to gzip encode requests (default true).")
+	flag.Uint64Var(&queryConfig.ChunkSize, "query-chunk-response-size", 0, "In mixed mode, number of series to chunk query results into. 0 means no chunking.")
 
 	flag.Parse()
 
@@ -65,6 +69,7 @@ func init() {
 	if len(daemonURLs) == 0 {
 		log.Fatal("missing 'urls' flag")
 	}
+	queryConfig.DaemonURLs = daemonURLs
 }
 
 type benchmark struct{}
@@ -96,42 +101,36 @@ func main() {
 		},
 	}
 
+	if mixedRunner.Enabled() {
+		createFn := influx.NewProcessorCreate(mixedRunner.Queries(), &queryConfig)
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, createFn)
+		return
+	}
 	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
 }
 
 type processor struct {
-	backingOffChan chan bool
-	backingOffDone chan struct{}
-	httpWriter     *HTTPWriter
+	httpWriter *HTTPWriter
 }
 
 func (p *processor) Init(numWorker int, _ bool) {
 	daemonURL := daemonURLs[numWorker%len(daemonURLs)]
-	p.backingOffChan = make(chan bool, 100)
-	p.backingOffDone = make(chan struct{})
 	cfg := HTTPWriterConfig{
-		DebugInfo:      fmt.Sprintf("worker #%d, dest url: %s", numWorker, daemonURL),
-		Host:           daemonURL,
-		Database:       loader.DatabaseName(),
-		BackingOffChan: p.backingOffChan,
-		BackingOffDone: p.backingOffDone,
+		DebugInfo: fmt.Sprintf("worker #%d, dest url: %s", numWorker, daemonURL),
+		Host:      daemonURL,
+		Database:  loader.DatabaseName(),
 	}
 	p.httpWriter = NewHTTPWriter(cfg, consistency)
-	go processBackoffMessages(numWorker, p.backingOffChan, p.backingOffDone)
-}
-
-func (p *processor) Close(_ bool) {
-	close(p.backingOffChan)
-	<-p.backingOffDone
 }
 
 func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 	batch := b.(*batch)
 
-	// Write the batch: try until backoff is not needed.
+	// Write the batch, retrying while the server indicates backpressure is needed.
+	ok := true
 	if doLoad {
-		var err error
-		for {
+		ok = loader.RetryInsert(classifyError, func() error {
+			var err error
 			if useGzip {
 				compressedBatch := bufPool.Get().(*bytes.Buffer)
 				fasthttp.WriteGzip(compressedBatch, batch.buf.Bytes())
@@ -142,21 +141,14 @@ func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 			} else {
 				_, err = p.httpWriter.WriteLineProtocol(batch.buf.Bytes(), false)
 			}
-
-			if err == BackoffError {
-				p.backingOffChan <- true
-				time.Sleep(backoff)
-			} else {
-				p.backingOffChan <- false
-				break
-			}
-		}
-		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
-		}
+			return err
+		})
 	}
 	metricCnt := batch.metrics
 	rowCnt := batch.rows
+	if !ok {
+		metricCnt, rowCnt = 0, 0
+	}
 
 	// Return the batch buffer to the pool.
 	batch.buf.Reset()
@@ -164,22 +156,11 @@ func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 	return metricCnt, rowCnt
 }
 
-func processBackoffMessages(workerID int, src chan bool, dst chan struct{}) {
-	var totalBackoffSecs float64
-	var start time.Time
-	last := false
-	for this := range src {
-		if this && !last {
-			start = time.Now()
-			last = true
-		} else if !this && last {
-			took := time.Now().Sub(start)
-			fmt.Printf("[worker %d] backoff took %.02fsec\n", workerID, took.Seconds())
-			totalBackoffSecs += took.Seconds()
-			last = false
-			start = time.Now()
-		}
+// classifyError retries writes when the server indicates backpressure is
+// needed or the request timed out
+func classifyError(err error) load.ErrorClass {
+	if err == BackoffError || load.IsTimeout(err) {
+		return load.ErrorRetriable
 	}
-	fmt.Printf("[worker %d] backoffs took a total of %fsec of runtime\n", workerID, totalBackoffSecs)
-	dst <- struct{}{}
+	return load.ErrorFatal
 }
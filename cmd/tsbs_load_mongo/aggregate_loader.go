@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"hash/fnv"
-	"log"
 	"sync"
 	"time"
 
@@ -13,22 +12,22 @@ import (
 	"github.com/hagen1778/tsbs/load"
 )
 
-type hostnameIndexer struct {
+// seriesIndexer partitions points by the value of their first tag, which
+// identifies the series (e.g. hostname for devops, name for iot)
+type seriesIndexer struct {
 	partitions uint
 }
 
-func (i *hostnameIndexer) GetIndex(item *load.Point) int {
+func (i *seriesIndexer) GetIndex(item *load.Point) int {
 	p := item.Data.(*serialize.MongoPoint)
-	t := &serialize.MongoTag{}
-	for j := 0; j < p.TagsLength(); j++ {
-		p.Tags(t, j)
-		if string(t.Key()) == "hostname" {
-			h := fnv.New32a()
-			h.Write([]byte(string(t.Value())))
-			return int(h.Sum32()) % int(i.partitions)
-		}
+	if p.TagsLength() == 0 {
+		return -1
 	}
-	return -1
+	t := &serialize.MongoTag{}
+	p.Tags(t, 0)
+	h := fnv.New32a()
+	h.Write(t.Value())
+	return int(h.Sum32()) % int(i.partitions)
 }
 
 // aggBenchmark allows you to run a benchmark using the aggregated document format
@@ -49,7 +48,7 @@ func (b *aggBenchmark) GetProcessor() load.Processor {
 }
 
 func (b *aggBenchmark) GetPointIndexer(maxPartitions uint) load.PointIndexer {
-	return &hostnameIndexer{partitions: maxPartitions}
+	return &seriesIndexer{partitions: maxPartitions}
 }
 
 // point is a reusable data structure to store a BSON data document for Mongo,
@@ -121,16 +120,20 @@ func (p *aggProcessor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64)
 	eventCnt := uint64(0)
 	for _, event := range batch.arr {
 		tagsMap := map[string]string{}
+		seriesKey := ""
 		t := &serialize.MongoTag{}
 		for j := 0; j < event.TagsLength(); j++ {
 			event.Tags(t, j)
 			tagsMap[string(t.Key())] = string(t.Value())
+			if j == 0 {
+				seriesKey = string(t.Value())
+			}
 		}
 
 		// Determine which document this event belongs too
 		ts := event.Timestamp()
 		dateKey := time.Unix(0, ts).UTC().Format(aggDateFmt)
-		docKey := fmt.Sprintf("day_%s_%s_%s", tagsMap["hostname"], dateKey, string(event.MeasurementName()))
+		docKey := fmt.Sprintf("day_%s_%s_%s", seriesKey, dateKey, string(event.MeasurementName()))
 
 		// Check that it has been created using a cached map, if not, add
 		// to creation queue
@@ -168,33 +171,44 @@ func (p *aggProcessor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64)
 
 	if doLoad {
 		// Checks if any new documents need to be made and does so
-		bulk := p.collection.Bulk()
-		bulk = insertNewAggregateDocs(p.collection, bulk, p.createQueue)
+		bulk, created := insertNewAggregateDocs(p.collection, p.collection.Bulk(), p.createQueue)
+		ok := created == len(p.createQueue)
+		// the events cannot be set on documents that do not exist, so the
+		// batch fails and the missing documents are created again with the
+		// next events that belong to them
+		for _, doc := range p.createQueue[created:] {
+			delete(p.createdDocs, doc.(bson.M)[aggDocID].(string))
+		}
 		p.createQueue = p.createQueue[:0]
 
-		// For each document, create one 'set' command for all records
-		// that belong to the document
-		for docKey, events := range docToEvents {
-			selector := bson.M{aggDocID: docKey}
-			updateMap := bson.M{}
-			for _, event := range events {
-				minKey := (event.Timestamp / (1e9 * 60)) % 60
-				secKey := (event.Timestamp / 1e9) % 60
-				key := fmt.Sprintf("events.%d.%d", minKey, secKey)
-				val := event.Fields
-
-				val[timestampField] = event.Timestamp
-				updateMap[key] = val
+		if ok {
+			// For each document, create one 'set' command for all records
+			// that belong to the document
+			for docKey, events := range docToEvents {
+				selector := bson.M{aggDocID: docKey}
+				updateMap := bson.M{}
+				for _, event := range events {
+					minKey := (event.Timestamp / (1e9 * 60)) % 60
+					secKey := (event.Timestamp / 1e9) % 60
+					key := fmt.Sprintf("events.%d.%d", minKey, secKey)
+					val := event.Fields
+
+					val[timestampField] = event.Timestamp
+					updateMap[key] = val
+				}
+
+				update := bson.M{"$set": updateMap}
+				bulk.Update(selector, update)
 			}
 
-			update := bson.M{"$set": updateMap}
-			bulk.Update(selector, update)
+			// All documents accounted for, finally run the operation
+			ok = loader.RetryInsert(classifyError, func() error {
+				_, err := bulk.Run()
+				return err
+			})
 		}
-
-		// All documents accounted for, finally run the operation
-		_, err := bulk.Run()
-		if err != nil {
-			log.Fatalf("Bulk aggregate update err: %s\n", err.Error())
+		if !ok {
+			eventCnt = 0
 		}
 
 		for _, events := range docToEvents {
@@ -208,8 +222,10 @@ func (p *aggProcessor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64)
 }
 
 // insertNewAggregateDocs handles creating new aggregated documents when new devices
-// or time periods are encountered
-func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQueue []interface{}) *mgo.Bulk {
+// or time periods are encountered. It returns the number of documents created,
+// which is less than the length of createQueue if a bulk insert was dropped,
+// in which case the documents that follow are not inserted either.
+func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQueue []interface{}) (*mgo.Bulk, int) {
 	b := bulk
 	if len(createQueue) > 0 {
 		off := 0
@@ -220,15 +236,18 @@ func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQu
 			}
 
 			b.Insert(createQueue[off:l]...)
-			_, err := b.Run()
-			if err != nil {
-				log.Fatalf("Bulk aggregate docs err: %s\n", err.Error())
-			}
+			ok := loader.RetryInsert(classifyError, func() error {
+				_, err := b.Run()
+				return err
+			})
 			b = collection.Bulk()
+			if !ok {
+				return b, off
+			}
 
 			off = l
 		}
 	}
 
-	return b
+	return b, len(createQueue)
 }
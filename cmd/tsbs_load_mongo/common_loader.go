@@ -85,3 +85,12 @@ func (b *mongoBenchmark) GetBatchFactory() load.BatchFactory {
 func (b *mongoBenchmark) GetDBCreator() load.DBCreator {
 	return b.dbc
 }
+
+// classifyError retries bulk operations that timed out or lost their
+// connection to the server
+func classifyError(err error) load.ErrorClass {
+	if err == io.EOF || load.IsTimeout(err) {
+		return load.ErrorRetriable
+	}
+	return load.ErrorFatal
+}
@@ -69,22 +69,24 @@ func (d *dbCreator) CreateDB(dbName string) error {
 	}
 
 	collection := d.session.DB(dbName).C(collectionName)
-	var key []string
-	if documentPer {
-		key = []string{"measurement", "tags.hostname", timestampField}
-	} else {
-		key = []string{aggKeyID, "measurement", "tags.hostname"}
-	}
+	for _, tag := range seriesTags {
+		var key []string
+		if documentPer {
+			key = []string{"measurement", "tags." + tag, timestampField}
+		} else {
+			key = []string{aggKeyID, "measurement", "tags." + tag}
+		}
 
-	index := mgo.Index{
-		Key:        key,
-		Unique:     false, // Unique does not work on the entire array of tags!
-		Background: false,
-		Sparse:     false,
-	}
-	err = collection.EnsureIndex(index)
-	if err != nil {
-		return fmt.Errorf("create basic index err: %v", err)
+		index := mgo.Index{
+			Key:        key,
+			Unique:     false, // Unique does not work on the entire array of tags!
+			Background: false,
+			Sparse:     false,
+		}
+		err = collection.EnsureIndex(index)
+		if err != nil {
+			return fmt.Errorf("create basic index err: %v", err)
+		}
 	}
 
 	// To make updates for new records more efficient, we need a efficient doc
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"log"
 	"sync"
 
 	"github.com/globalsign/mgo"
@@ -86,9 +85,12 @@ func (p *naiveProcessor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64
 	if doLoad {
 		bulk := p.collection.Bulk()
 		bulk.Insert(p.pvs...)
-		_, err := bulk.Run()
-		if err != nil {
-			log.Fatalf("Bulk insert docs err: %s\n", err.Error())
+		ok := loader.RetryInsert(classifyError, func() error {
+			_, err := bulk.Run()
+			return err
+		})
+		if !ok {
+			metricCnt = 0
 		}
 	}
 	for _, p := range p.pvs {
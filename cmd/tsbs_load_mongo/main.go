@@ -5,9 +5,14 @@ package main
 
 import (
 	"flag"
+	"log"
 	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/mongo"
 )
 
 const (
@@ -19,24 +24,32 @@ const (
 	timestampField     = "timestamp_ns"
 )
 
+// seriesTags are the tags identifying a series in each of the use cases,
+// which are indexed on collection creation
+var seriesTags = []string{"hostname", "name"}
+
 // Program option vars:
 var (
 	daemonURL    string
 	documentPer  bool
 	writeTimeout time.Duration
+	readTimeout  time.Duration
 )
 
 // Global vars
 var (
-	loader *load.BenchmarkRunner
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
 )
 
 // Parse args:
 func init() {
 	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
 
 	flag.StringVar(&daemonURL, "url", "localhost:27017", "Mongo URL.")
 	flag.DurationVar(&writeTimeout, "write-timeout", 10*time.Second, "Write timeout.")
+	flag.DurationVar(&readTimeout, "query-read-timeout", 30*time.Second, "In mixed mode, timeout value for individual queries.")
 	flag.BoolVar(&documentPer, "document-per-event", false, "Whether to use one document per event or aggregate by hour")
 
 	flag.Parse()
@@ -53,5 +66,14 @@ func main() {
 		workQueues = load.WorkerPerQueue
 	}
 
+	if mixedRunner.Enabled() {
+		session, err := mgo.DialWithTimeout(daemonURL, readTimeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		createFn := mongo.NewProcessorCreate(mixedRunner.Queries(), session)
+		mixedRunner.Run(benchmark, workQueues, &query.MongoPool, createFn)
+		return
+	}
 	loader.RunBenchmark(benchmark, workQueues)
 }
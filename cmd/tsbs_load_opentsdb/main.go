@@ -0,0 +1,88 @@
+// tsbs_load_opentsdb loads an OpenTSDB daemon with data from stdin.
+//
+// Data points are streamed as telnet put commands over TCP, or sent as JSON
+// to the HTTP API. OpenTSDB has no databases, so none is created or dropped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/opentsdb"
+)
+
+const (
+	protocolTelnet = "telnet"
+	protocolHTTP   = "http"
+)
+
+// Program option vars:
+var (
+	daemonURLs []string
+	protocol   string
+)
+
+// Global vars
+var (
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
+	var csvDaemonURLs string
+
+	flag.StringVar(&csvDaemonURLs, "urls", "http://localhost:4242", "OpenTSDB URLs, comma-separated. Will be used in a round-robin fashion. The telnet protocol connects to their host and port.")
+	flag.StringVar(&protocol, "protocol", protocolTelnet, "Protocol to write data points with (choices: telnet, http).")
+
+	flag.Parse()
+
+	if protocol != protocolTelnet && protocol != protocolHTTP {
+		log.Fatalf("invalid protocol: %s", protocol)
+	}
+	daemonURLs = strings.Split(csvDaemonURLs, ",")
+	if len(daemonURLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetPointDecoder(br *bufio.Reader) load.PointDecoder {
+	return load.NewLineDecoder(br)
+}
+
+func (b *benchmark) GetBatchFactory() load.BatchFactory {
+	return &load.LineBatchFactory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) load.PointIndexer {
+	return &load.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() load.Processor {
+	return &processor{}
+}
+
+func (b *benchmark) GetDBCreator() load.DBCreator {
+	return &dbCreator{}
+}
+
+func main() {
+	if mixedRunner.Enabled() {
+		createFn := opentsdb.NewProcessorCreate(mixedRunner.Queries(), &opentsdb.Config{URLs: daemonURLs})
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, createFn)
+		return
+	}
+	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+)
+
+const writeTimeout = time.Minute
+
+type processor struct {
+	tcp    *load.TCPWriter
+	putURL string
+	client *http.Client
+}
+
+func (p *processor) Init(numWorker int, _ bool) {
+	daemonURL := daemonURLs[numWorker%len(daemonURLs)]
+	if protocol == protocolTelnet {
+		u, err := url.Parse(daemonURL)
+		if err != nil {
+			fatal("invalid url %s: %v", daemonURL, err)
+		}
+		p.tcp = &load.TCPWriter{Address: u.Host, Timeout: writeTimeout}
+		return
+	}
+	p.putURL = daemonURL + "/api/put"
+	p.client = &http.Client{Timeout: writeTimeout}
+}
+
+func (p *processor) Close(_ bool) {
+	if p.tcp != nil {
+		p.tcp.Close()
+	}
+}
+
+func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*load.LineBatch)
+	if !doLoad {
+		return uint64(batch.Len()), 0
+	}
+
+	var body []byte
+	if protocol == protocolHTTP {
+		body = putLinesToJSON(batch.Bytes())
+	}
+	ok := loader.RetryInsert(load.ClassifyHTTPError, func() error {
+		if protocol == protocolTelnet {
+			return p.tcp.Write(batch.Bytes())
+		}
+		return p.post(body)
+	})
+	if !ok {
+		return 0, 0
+	}
+	return uint64(batch.Len()), 0
+}
+
+// post sends data points as JSON to the put endpoint of the HTTP API
+func (p *processor) post(body []byte) error {
+	resp, err := p.client.Post(p.putURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return &load.RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return &load.StatusError{Code: resp.StatusCode, Body: bytes.TrimSpace(msg)}
+	}
+	return nil
+}
+
+// putLinesToJSON converts telnet put lines, e.g.
+//
+// put cpu.usage_user 1451606400000 58.13 hostname=host_0 region=eu-west-1
+//
+// to the JSON array of data points accepted by the HTTP API:
+//
+// [{"metric":"cpu.usage_user","timestamp":1451606400000,"value":58.13,"tags":{"hostname":"host_0","region":"eu-west-1"}}]
+func putLinesToJSON(lines []byte) []byte {
+	buf := make([]byte, 0, len(lines)*2)
+	buf = append(buf, '[')
+	first := true
+	for _, line := range bytes.Split(lines, []byte("\n")) {
+		args := bytes.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		if len(args) < 4 || string(args[0]) != "put" {
+			fatal("parse error: invalid put line: %s", line)
+			return nil
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = append(buf, `{"metric":`...)
+		buf = strconv.AppendQuote(buf, string(args[1]))
+		buf = append(buf, `,"timestamp":`...)
+		buf = append(buf, args[2]...)
+		buf = append(buf, `,"value":`...)
+		buf = append(buf, args[3]...)
+		buf = append(buf, `,"tags":{`...)
+		for i, tag := range args[4:] {
+			kv := bytes.SplitN(tag, []byte("="), 2)
+			if len(kv) != 2 {
+				fatal("parse error: invalid tag in put line: %s", line)
+				return nil
+			}
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = strconv.AppendQuote(buf, string(kv[0]))
+			buf = append(buf, ':')
+			buf = strconv.AppendQuote(buf, string(kv[1]))
+		}
+		buf = append(buf, "}}"...)
+	}
+	return append(buf, ']')
+}
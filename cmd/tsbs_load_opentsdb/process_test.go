@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"testing"
+)
+
+func TestPutLinesToJSON(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		want        string
+		shouldFatal bool
+	}{
+		{
+			desc:  "empty batch",
+			input: "",
+			want:  "[]",
+		},
+		{
+			desc:  "single line",
+			input: "put cpu.usage_user 1451606400000 58.13 hostname=host_0 region=eu-west-1\n",
+			want:  `[{"metric":"cpu.usage_user","timestamp":1451606400000,"value":58.13,"tags":{"hostname":"host_0","region":"eu-west-1"}}]`,
+		},
+		{
+			desc:  "multiple lines",
+			input: "put cpu.usage_user 1000 1 hostname=host_0\nput cpu.usage_system 1000 2 hostname=host_0\n",
+			want:  `[{"metric":"cpu.usage_user","timestamp":1000,"value":1,"tags":{"hostname":"host_0"}},{"metric":"cpu.usage_system","timestamp":1000,"value":2,"tags":{"hostname":"host_0"}}]`,
+		},
+		{
+			desc:        "not a put line",
+			input:       "cpu.usage_user 1000 1 hostname=host_0\n",
+			shouldFatal: true,
+		},
+		{
+			desc:        "invalid tag",
+			input:       "put cpu.usage_user 1000 1 hostname\n",
+			shouldFatal: true,
+		},
+	}
+	for _, c := range cases {
+		if c.shouldFatal {
+			isCalled := false
+			fatal = func(fmt string, args ...interface{}) {
+				isCalled = true
+				log.Printf(fmt, args...)
+			}
+			putLinesToJSON([]byte(c.input))
+			if !isCalled {
+				t.Errorf("%s: did not call fatal when it should", c.desc)
+			}
+			continue
+		}
+		if got := string(putLinesToJSON([]byte(c.input))); got != c.want {
+			t.Errorf("%s: incorrect output:\ngot\n%s\nwant\n%s", c.desc, got, c.want)
+		}
+	}
+}
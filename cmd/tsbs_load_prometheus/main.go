@@ -3,23 +3,29 @@ package main
 import (
 	"bufio"
 	"flag"
-	"log"
 	"time"
 	"net/http"
 	"fmt"
 	"bytes"
 
 	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/prometheus"
 )
 
 var (
 	loader           *load.BenchmarkRunner
+	mixedRunner      *mixed.BenchmarkRunner
 	remoteStorageURL string
+	queryURL         string
 )
 
 func init() {
 	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
 	flag.StringVar(&remoteStorageURL, "url", "http://localhost:8080", "Prometheus Remote Storage Insert daemon URL")
+	flag.StringVar(&queryURL, "query-url", "http://localhost:8081/select/1m/1/foobar/prometheus/", "In mixed mode, Prometheus URL to send queries to")
 	flag.Parse()
 	remoteStorageURL = fmt.Sprintf("%s/insert/1d/1/foobar/prometheus/", remoteStorageURL)
 }
@@ -47,6 +53,10 @@ func (b *benchmark) GetDBCreator() load.DBCreator {
 }
 
 func main() {
+	if mixedRunner.Enabled() {
+		mixedRunner.Run(&benchmark{}, load.SingleQueue, &query.HTTPPool, prometheus.NewProcessorCreate(mixedRunner.Queries(), queryURL))
+		return
+	}
 	loader.RunBenchmark(&benchmark{}, load.SingleQueue)
 }
 
@@ -68,10 +78,10 @@ func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 		return 0, 0
 	}
 
-	for {
+	ok := loader.RetryInsert(load.ClassifyHTTPError, func() error {
 		httpReq, err := http.NewRequest("POST", remoteStorageURL, bytes.NewReader(batch.Bytes()))
 		if err != nil {
-			log.Fatalf("error while creating new request: %s", err)
+			return fmt.Errorf("error while creating new request: %s", err)
 		}
 		httpReq.Header.Add("Content-Encoding", "snappy")
 		httpReq.Header.Set("Content-Type", "application/x-protobuf")
@@ -79,13 +89,16 @@ func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 
 		httpResp, err := p.Client.Do(httpReq)
 		if err != nil {
-			log.Fatalf("error while executing request: %s", err)
+			return &load.RequestError{Err: err}
 		}
 		httpResp.Body.Close()
-		if httpResp.StatusCode == http.StatusOK {
-			return uint64(batch.Len()), 0
+		if httpResp.StatusCode != http.StatusOK {
+			return &load.StatusError{Code: httpResp.StatusCode}
 		}
-		log.Printf("server returned HTTP status %d. Retrying", httpResp.Status)
-		time.Sleep(time.Millisecond*10)
+		return nil
+	})
+	if !ok {
+		return 0, 0
 	}
+	return uint64(batch.Len()), 0
 }
@@ -1,58 +1,31 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/hagen1778/tsbs/load"
 	"github.com/jmoiron/sqlx"
 )
 
 type dbCreator struct {
-	tags    string
-	cols    []string
+	header  *load.TableHeader
 	connStr string
 }
 
 func (d *dbCreator) Init() {
-	br := loader.GetBufferedReader()
-	d.readDataHeader(br)
+	var err error
+	d.header, err = load.ReadTableHeader(loader.GetBufferedReader())
+	if err != nil {
+		fatal("input has wrong header format: %v", err)
+	}
 
 	// Needed to connect to user's database in order to drop/create db-name database
 	re := regexp.MustCompile(`(dbname)=\S*\b`)
 	d.connStr = re.ReplaceAllString(getConnectString(), "")
 }
 
-func (d *dbCreator) readDataHeader(br *bufio.Reader) {
-	// First N lines are header, with the first line containing the tags
-	// and their names, the second through N-1 line containing the column
-	// names, and last line being blank to separate from the data
-	i := 0
-	for {
-		var err error
-		var line string
-		if i == 0 {
-			d.tags, err = br.ReadString('\n')
-			if err != nil {
-				fatal("input has wrong header format: %v", err)
-			}
-			d.tags = strings.TrimSpace(d.tags)
-		} else {
-			line, err = br.ReadString('\n')
-			if err != nil {
-				fatal("input has wrong header format: %v", err)
-			}
-			line = strings.TrimSpace(line)
-			if len(line) == 0 {
-				break
-			}
-			d.cols = append(d.cols, line)
-		}
-		i++
-	}
-}
-
 func (d *dbCreator) DBExists(dbName string) bool {
 	db := sqlx.MustConnect(dbType, d.connStr)
 	defer db.Close()
@@ -76,18 +49,13 @@ func (d *dbCreator) CreateDB(dbName string) error {
 	dbBench := sqlx.MustConnect(dbType, getConnectString())
 	defer dbBench.Close()
 
-	parts := strings.Split(strings.TrimSpace(d.tags), ",")
-	if parts[0] != "tags" {
-		return fmt.Errorf("input header in wrong format. got '%s', expected 'tags'", parts[0])
-	}
-	createTagsTable(dbBench, parts[1:])
-	tableCols["tags"] = parts[1:]
+	createTagsTable(dbBench, d.header.TagKeys)
+	tableCols["tags"] = d.header.TagKeys
 
-	for _, cols := range d.cols {
-		parts = strings.Split(strings.TrimSpace(cols), ",")
-		hypertable := parts[0]
+	for _, hypertable := range d.header.Tables {
+		fields := d.header.Fields[hypertable]
 		partitioningField := tableCols["tags"][0]
-		tableCols[hypertable] = parts[1:]
+		tableCols[hypertable] = fields
 
 		psuedoCols := []string{}
 		if inTableTag {
@@ -96,7 +64,7 @@ func (d *dbCreator) CreateDB(dbName string) error {
 
 		fieldDef := []string{}
 		indexes := []string{}
-		psuedoCols = append(psuedoCols, parts[1:]...)
+		psuedoCols = append(psuedoCols, fields...)
 		extraCols := 0 // set to 1 when hostname is kept in-table
 		for idx, field := range psuedoCols {
 			if len(field) == 0 {
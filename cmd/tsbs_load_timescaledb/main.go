@@ -16,6 +16,9 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/mixed"
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/timescaledb"
 )
 
 const (
@@ -56,8 +59,9 @@ type insertData struct {
 
 // Global vars
 var (
-	loader    *load.BenchmarkRunner
-	tableCols map[string][]string
+	loader      *load.BenchmarkRunner
+	mixedRunner *mixed.BenchmarkRunner
+	tableCols   map[string][]string
 )
 
 // allows for testing
@@ -66,6 +70,7 @@ var fatal = log.Fatalf
 // Parse args:
 func init() {
 	loader = load.GetBenchmarkRunner()
+	mixedRunner = mixed.NewBenchmarkRunner(loader)
 
 	flag.StringVar(&postgresConnect, "postgres", "sslmode=disable", "PostgreSQL connection string")
 	flag.StringVar(&host, "host", "localhost", "Hostname of TimescaleDB (PostgreSQL) instance")
@@ -131,10 +136,20 @@ func main() {
 		go OutputReplicationStats(getConnectString(), replicationStatsFile, &replicationStatsWaitGroup)
 	}
 
+	workQueues := uint(load.SingleQueue)
 	if hashWorkers {
-		loader.RunBenchmark(&benchmark{}, load.WorkerPerQueue)
+		workQueues = load.WorkerPerQueue
+	}
+	if mixedRunner.Enabled() {
+		queryConfig := &timescaledb.Config{
+			PostgresConnect: postgresConnect,
+			Hosts:           []string{host},
+			User:            user,
+		}
+		createFn := timescaledb.NewProcessorCreate(mixedRunner.Queries(), queryConfig)
+		mixedRunner.Run(&benchmark{}, workQueues, &query.TimescaleDBPool, createFn)
 	} else {
-		loader.RunBenchmark(&benchmark{}, load.SingleQueue)
+		loader.RunBenchmark(&benchmark{}, workQueues)
 	}
 
 	if len(replicationStatsFile) > 0 {
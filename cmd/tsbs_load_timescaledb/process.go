@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql/driver"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"sync"
@@ -96,7 +98,7 @@ func insertTags(db *sqlx.DB, tagRows [][]string, returnResults bool) map[string]
 	return nil
 }
 
-func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
+func (p *processor) processCSI(hypertable string, rows []*insertData) (uint64, bool) {
 	tagRows := make([][]string, 0, len(rows))
 	dataRows := make([][]interface{}, 0, len(rows))
 	ret := uint64(0)
@@ -122,7 +124,6 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		}
 
 		metrics := strings.Split(data.fields, ",")
-		ret += uint64(len(metrics) - 1) // 1 field is timestamp
 
 		timeInt, err := strconv.ParseInt(metrics[0], 10, 64)
 		if err != nil {
@@ -136,8 +137,14 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		if inTableTag {
 			r = append(r, tags[0])
 		}
+		// 1st field is timestamp, and missing values are empty
 		for _, v := range metrics[1:] {
+			if v == "" {
+				r = append(r, nil)
+				continue
+			}
 			r = append(r, v)
+			ret++
 		}
 
 		dataRows = append(dataRows, r)
@@ -168,7 +175,6 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		dataRows[i][1] = p.csi.m[tagKey]
 	}
 	p.csi.mutex.RUnlock()
-	tx := p.db.MustBegin()
 
 	cols := make([]string, 0, colLen)
 	cols = append(cols, "time", "tags_id", "additional_tags")
@@ -176,27 +182,58 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		cols = append(cols, tableCols["tags"][0])
 	}
 	cols = append(cols, tableCols[hypertable]...)
+	ok := loader.RetryInsert(classifyError, func() error {
+		return p.copyRows(hypertable, cols, dataRows)
+	})
+	if !ok {
+		return 0, false
+	}
+
+	return ret, true
+}
+
+// copyRows inserts dataRows into the given columns of hypertable with COPY,
+// in a single transaction that is rolled back on errors
+func (p *processor) copyRows(hypertable string, cols []string, dataRows [][]interface{}) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
 	stmt, err := tx.Prepare(pq.CopyIn(hypertable, cols...))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 	for _, r := range dataRows {
 		stmt.Exec(r...)
 	}
 
 	_, err = stmt.Exec()
-	if err != nil {
-		panic(err)
+	if closeErr := stmt.Close(); err == nil {
+		err = closeErr
 	}
-
-	err = stmt.Close()
 	if err != nil {
-		panic(err)
+		tx.Rollback()
+		return err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		panic(err)
-	}
+	return tx.Commit()
+}
 
-	return ret
+// classifyError retries inserts that failed because of the connection, a
+// conflict with another transaction, or a lack of resources on the server
+func classifyError(err error) load.ErrorClass {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Class() {
+		case "08", "40", "53", "57":
+			return load.ErrorRetriable
+		}
+		return load.ErrorFatal
+	}
+	if err == driver.ErrBadConn || err == io.EOF || load.IsTimeout(err) {
+		return load.ErrorRetriable
+	}
+	return load.ErrorFatal
 }
 
 type processor struct {
@@ -229,7 +266,12 @@ func (p *processor) ProcessBatch(b load.Batch, doLoad bool) (uint64, uint64) {
 		rowCnt += len(rows)
 		if doLoad {
 			start := time.Now()
-			metricCnt += p.processCSI(hypertable, rows)
+			metrics, ok := p.processCSI(hypertable, rows)
+			if !ok {
+				// the rows of a dropped batch were not inserted
+				rowCnt -= len(rows)
+			}
+			metricCnt += metrics
 
 			if logBatches {
 				now := time.Now()
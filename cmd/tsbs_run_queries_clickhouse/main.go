@@ -0,0 +1,45 @@
+// tsbs_run_queries_clickhouse speed tests ClickHouse using requests from stdin.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the HTTP interface of the provided ClickHouse servers.
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/clickhouse"
+)
+
+// Program option vars:
+var (
+	config clickhouse.Config
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	runner = query.NewBenchmarkRunner()
+	var csvURLs string
+
+	flag.StringVar(&csvURLs, "urls", "http://localhost:8123", "ClickHouse HTTP interface URLs, comma-separated. Will be used in a round-robin fashion.")
+	flag.StringVar(&config.User, "user", "", "User to run the queries as, if any.")
+	flag.StringVar(&config.Password, "password", "", "Password of the user.")
+
+	flag.Parse()
+
+	config.URLs = strings.Split(csvURLs, ",")
+	if len(config.URLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, clickhouse.NewProcessorCreate(runner, &config))
+}
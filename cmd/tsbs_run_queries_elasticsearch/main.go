@@ -0,0 +1,45 @@
+// tsbs_run_queries_elasticsearch speed tests Elasticsearch using requests
+// from stdin.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the search API of the provided Elasticsearch or OpenSearch nodes. The
+// time each search took on the server is reported alongside the latency.
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/elasticsearch"
+)
+
+// Program option vars:
+var (
+	config elasticsearch.Config
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	runner = query.NewBenchmarkRunner()
+	var csvURLs string
+
+	flag.StringVar(&csvURLs, "urls", "http://localhost:9200", "Elasticsearch URLs, comma-separated. Will be used in a round-robin fashion.")
+
+	flag.Parse()
+
+	config.URLs = strings.Split(csvURLs, ",")
+	if len(config.URLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, elasticsearch.NewProcessorCreate(runner, &config))
+}
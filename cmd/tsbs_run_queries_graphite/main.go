@@ -0,0 +1,43 @@
+// tsbs_run_queries_graphite speed tests Graphite using requests from stdin.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the render API of the provided Graphite servers.
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hagen1778/tsbs/query"
+	"github.com/hagen1778/tsbs/query/graphite"
+)
+
+// Program option vars:
+var (
+	config graphite.Config
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	runner = query.NewBenchmarkRunner()
+	var csvURLs string
+
+	flag.StringVar(&csvURLs, "urls", "http://localhost:8080", "Graphite web URLs, comma-separated. Will be used in a round-robin fashion.")
+
+	flag.Parse()
+
+	config.URLs = strings.Split(csvURLs, ",")
+	if len(config.URLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, graphite.NewProcessorCreate(runner, &config))
+}
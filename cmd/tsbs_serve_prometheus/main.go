@@ -0,0 +1,219 @@
+// tsbs_serve_prometheus serves simulated devops hosts as Prometheus scrape
+// targets.
+//
+// Each host is a target exposing its readings in the text exposition format,
+// either at /hosts/<i>/metrics of the listen address or at /metrics of its
+// own port after the listen port. Each scrape of a target advances the
+// simulation of its host by one reading interval, so the ingestion of a
+// scraper, and how it handles the series of hosts that are replaced or go
+// silent, can be benchmarked. The targets are listed at /targets for the
+// HTTP service discovery of Prometheus.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+const (
+	// Use case choices
+	useCaseDevops    = "devops"
+	useCaseCPUOnly   = "cpu-only"
+	useCaseCPUSingle = "cpu-single"
+)
+
+// Program option vars:
+var (
+	useCase         string
+	scaleVar        uint64
+	timestampStart  time.Time
+	duration        time.Duration
+	logInterval     time.Duration
+	seed            int64
+	realistic       bool
+	hostLifetime    time.Duration
+	sparse          common.SparseConfig
+	timestamps      bool
+	listenAddress   string
+	portPerHost     bool
+	reportingPeriod time.Duration
+)
+
+// Global vars
+var (
+	scrapes uint64
+	samples uint64
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	var timestampStartStr string
+	flag.StringVar(&useCase, "use-case", useCaseDevops, fmt.Sprintf("Use case to model. (choices: %s, %s, %s)", useCaseDevops, useCaseCPUOnly, useCaseCPUSingle))
+	flag.Uint64Var(&scaleVar, "scale-var", 1, "Number of hosts to simulate, each being a target.")
+
+	flag.StringVar(&timestampStartStr, "timestamp-start", "", "Beginning timestamp of the simulation (RFC3339). Empty means now.")
+	flag.DurationVar(&duration, "duration", 24*time.Hour, "Simulated duration, after which the targets answer with 404.")
+	flag.DurationVar(&logInterval, "log-interval", 10*time.Second, "Simulated duration between two scrapes of a target.")
+	flag.Int64Var(&seed, "seed", 0, "PRNG seed (0 uses the current timestamp). (default 0)")
+
+	flag.BoolVar(&realistic, "realistic-values", false, "Make values follow daily and weekly cycles, with spikes, level shifts, bursts and counter resets, instead of pure random walks.")
+	flag.DurationVar(&hostLifetime, "host-lifetime", 0, "Lifetime of hosts, after which they are decommissioned and replaced by hosts with new hostnames. 0 keeps the same hosts.")
+	flag.Float64Var(&sparse.SilenceProbability, "silence-probability", 0, "Probability for a host to go silent at each scrape (0 to 1), returning no samples.")
+	flag.DurationVar(&sparse.SilenceDuration, "silence-duration", 10*time.Minute, "Mean simulated duration of the silences of hosts.")
+
+	flag.BoolVar(&timestamps, "timestamps", false, "Expose the simulated timestamps of the samples, instead of leaving it to the scraper.")
+	flag.StringVar(&listenAddress, "listen", ":9100", "Address to listen on for /targets and, unless -port-per-host, the targets.")
+	flag.BoolVar(&portPerHost, "port-per-host", false, "Serve each target at /metrics on its own port, following the port of -listen.")
+	flag.DurationVar(&reportingPeriod, "reporting-period", 10*time.Second, "Period to report scrape stats (0 disables).")
+
+	flag.Parse()
+
+	if useCase != useCaseDevops && useCase != useCaseCPUOnly && useCase != useCaseCPUSingle {
+		log.Fatalf("invalid use case: %s", useCase)
+	}
+	if scaleVar == 0 {
+		log.Fatal("scale-var must be positive")
+	}
+	if logInterval <= 0 || duration < logInterval {
+		log.Fatal("duration must be at least one log interval")
+	}
+	if hostLifetime < 0 {
+		log.Fatal("host lifetime cannot be negative")
+	}
+	if err := sparse.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	timestampStart = time.Now().UTC().Truncate(logInterval)
+	if len(timestampStartStr) > 0 {
+		var err error
+		timestampStart, err = time.Parse(time.RFC3339, timestampStartStr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		timestampStart = timestampStart.UTC()
+	}
+}
+
+func getConfig() common.ShardableSimulatorConfig {
+	switch useCase {
+	case useCaseDevops:
+		return &devops.DevopsSimulatorConfig{
+			Start: timestampStart,
+			End:   timestampStart.Add(duration),
+
+			InitHostCount:   scaleVar,
+			HostCount:       scaleVar,
+			HostConstructor: devops.NewHost,
+			Realistic:       realistic,
+			HostLifetime:    hostLifetime,
+		}
+	case useCaseCPUOnly, useCaseCPUSingle:
+		constructor := devops.NewHostCPUOnly
+		if useCase == useCaseCPUSingle {
+			constructor = devops.NewHostCPUSingle
+		}
+		return &devops.CPUOnlySimulatorConfig{
+			Start: timestampStart,
+			End:   timestampStart.Add(duration),
+
+			InitHostCount:   scaleVar,
+			HostCount:       scaleVar,
+			HostConstructor: constructor,
+			Realistic:       realistic,
+			HostLifetime:    hostLifetime,
+		}
+	default:
+		fatal("unknown use case: '%s'", useCase)
+		return nil
+	}
+}
+
+// getTargets returns a target per host, each simulating its host with its
+// own Simulator so that targets advance independently
+func getTargets(cfg common.ShardableSimulatorConfig) []*target {
+	sims := cfg.ToShards(logInterval, int(scaleVar), seed)
+	targets := make([]*target, len(sims))
+	for i, sim := range sims {
+		if sparse.Enabled() {
+			c := sparse
+			c.Seed = seed + int64(i)
+			sim = common.NewSparseSimulator(sim, &c)
+		}
+		targets[i] = newTarget(sim, &serialize.PrometheusTextSerializer{Timestamps: timestamps}, &scrapes, &samples)
+	}
+	return targets
+}
+
+func main() {
+	host, portStr, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		log.Fatalf("invalid listen address %s: %v", listenAddress, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		log.Fatalf("invalid port in listen address %s: %v", listenAddress, err)
+	}
+
+	targets := getTargets(getConfig())
+	mux := http.NewServeMux()
+	mux.Handle("/targets", serveTargetGroups(port, len(targets), portPerHost))
+	for i, t := range targets {
+		if !portPerHost {
+			mux.Handle(targetPath(i), t)
+			continue
+		}
+		targetMux := http.NewServeMux()
+		targetMux.Handle("/metrics", t)
+		go serve(net.JoinHostPort(host, strconv.Itoa(port+1+i)), targetMux)
+	}
+
+	if reportingPeriod > 0 {
+		go report(reportingPeriod)
+	}
+	log.Printf("serving %d targets from %s, simulating %s from %s", len(targets), listenAddress, duration, timestampStart.Format(time.RFC3339))
+	serve(listenAddress, mux)
+}
+
+func serve(address string, handler http.Handler) {
+	if err := http.ListenAndServe(address, handler); err != nil {
+		log.Fatalf("could not serve %s: %v", address, err)
+	}
+}
+
+// report handles periodic reporting of scrape stats
+func report(period time.Duration) {
+	start := time.Now()
+	prevTime := start
+	prevSamples := uint64(0)
+
+	fmt.Printf("time,per. sample/s,sample total,overall sample/s,scrape total\n")
+	for now := range time.NewTicker(period).C {
+		sCount := atomic.LoadUint64(&samples)
+		scrapeCount := atomic.LoadUint64(&scrapes)
+
+		took := now.Sub(prevTime)
+		rate := float64(sCount-prevSamples) / took.Seconds()
+		overallRate := float64(sCount) / now.Sub(start).Seconds()
+		fmt.Printf("%d,%0.2f,%E,%0.2f,%d\n", now.Unix(), rate, float64(sCount), overallRate, scrapeCount)
+
+		prevSamples = sCount
+		prevTime = now
+	}
+}
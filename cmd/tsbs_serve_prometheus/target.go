@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/common"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// target is the scrape target of a simulated host. Each scrape advances the
+// simulation of the host by one reading interval and returns its readings.
+type target struct {
+	mu         sync.Mutex
+	sim        common.Simulator
+	serializer serialize.PointSerializer
+	point      *serialize.Point
+	// readings is the number of points of each interval, one per
+	// measurement of the host
+	readings int
+
+	// scrapes and samples count what was served by all targets
+	scrapes *uint64
+	samples *uint64
+}
+
+func newTarget(sim common.Simulator, serializer serialize.PointSerializer, scrapes, samples *uint64) *target {
+	return &target{
+		sim:        sim,
+		serializer: serializer,
+		point:      serialize.NewPoint(),
+		readings:   len(sim.Fields()),
+		scrapes:    scrapes,
+		samples:    samples,
+	}
+}
+
+// scrape returns the readings of the next interval in the text exposition
+// format, and whether the simulation has not finished yet
+func (t *target) scrape() ([]byte, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sim.Finished() {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < t.readings && !t.sim.Finished(); i++ {
+		// the points of hosts that are not up yet, or silent, are not
+		// written
+		if t.sim.Next(t.point) {
+			if err := t.serializer.Serialize(t.point, &buf); err != nil {
+				fatal("could not serialize point: %v", err)
+			}
+		}
+		t.point.Reset()
+	}
+	return buf.Bytes(), true
+}
+
+func (t *target) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, ok := t.scrape()
+	if !ok {
+		http.Error(w, "simulation finished", http.StatusNotFound)
+		return
+	}
+	atomic.AddUint64(t.scrapes, 1)
+	atomic.AddUint64(t.samples, uint64(bytes.Count(body, []byte("\n"))))
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// targetGroup is a group of targets in the format of the HTTP and file
+// based service discovery of Prometheus
+type targetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// targetGroups returns the target groups of numTargets targets, served by
+// host either on consecutive ports after basePort, if portPerHost is set,
+// or on basePort
+func targetGroups(host string, basePort, numTargets int, portPerHost bool) []targetGroup {
+	if portPerHost {
+		g := targetGroup{Targets: make([]string, numTargets)}
+		for i := range g.Targets {
+			g.Targets[i] = net.JoinHostPort(host, strconv.Itoa(basePort+1+i))
+		}
+		return []targetGroup{g}
+	}
+	groups := make([]targetGroup, numTargets)
+	address := net.JoinHostPort(host, strconv.Itoa(basePort))
+	for i := range groups {
+		groups[i] = targetGroup{
+			Targets: []string{address},
+			Labels:  map[string]string{"__metrics_path__": targetPath(i)},
+		}
+	}
+	return groups
+}
+
+// targetPath returns the path of the i-th target, when they share a port
+func targetPath(i int) string {
+	return "/hosts/" + strconv.Itoa(i) + "/metrics"
+}
+
+// serveTargetGroups serves the target groups of the targets, as reached by
+// the client
+func serveTargetGroups(basePort, numTargets int, portPerHost bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(targetGroups(host, basePort, numTargets, portPerHost))
+	}
+}
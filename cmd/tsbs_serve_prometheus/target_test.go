@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/devops"
+	"github.com/hagen1778/tsbs/cmd/tsbs_generate_data/serialize"
+)
+
+func TestTargetScrape(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &devops.CPUOnlySimulatorConfig{
+		Start:           start,
+		End:             start.Add(20 * time.Second),
+		InitHostCount:   2,
+		HostCount:       2,
+		HostConstructor: devops.NewHostCPUOnly,
+	}
+	var scrapes, samples uint64
+	sims := cfg.ToShards(10*time.Second, 2, 123)
+	if len(sims) != 2 {
+		t.Fatalf("incorrect number of simulators: got %d want 2", len(sims))
+	}
+	tgt := newTarget(sims[1], &serialize.PrometheusTextSerializer{Timestamps: true}, &scrapes, &samples)
+
+	for _, ts := range []string{"1451606400000", "1451606410000"} {
+		w := httptest.NewRecorder()
+		tgt.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("incorrect status: got %d want 200", w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != contentType {
+			t.Errorf("incorrect content type: got %s", got)
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 10 {
+			t.Errorf("incorrect number of samples: got %d want 10", len(lines))
+		}
+		for _, l := range lines {
+			if !strings.HasPrefix(l, "cpu_") || !strings.Contains(l, `hostname="host_1"`) || !strings.HasSuffix(l, " "+ts) {
+				t.Errorf("incorrect sample: %s", l)
+			}
+		}
+	}
+	if scrapes != 2 || samples != 20 {
+		t.Errorf("incorrect counts: got %d scrapes and %d samples, want 2 and 20", scrapes, samples)
+	}
+
+	w := httptest.NewRecorder()
+	tgt.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("incorrect status after the simulation: got %d want 404", w.Code)
+	}
+}
+
+func TestTargetGroups(t *testing.T) {
+	cases := []struct {
+		desc        string
+		portPerHost bool
+		want        string
+	}{
+		{
+			desc: "shared port",
+			want: `[{"targets":["example.com:9100"],"labels":{"__metrics_path__":"/hosts/0/metrics"}},{"targets":["example.com:9100"],"labels":{"__metrics_path__":"/hosts/1/metrics"}}]`,
+		},
+		{
+			desc:        "port per host",
+			portPerHost: true,
+			want:        `[{"targets":["example.com:9101","example.com:9102"]}]`,
+		},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		serveTargetGroups(9100, 2, c.portPerHost)(w, httptest.NewRequest("GET", "http://example.com:9100/targets", nil))
+		var got bytes.Buffer
+		if err := json.Compact(&got, w.Body.Bytes()); err != nil {
+			t.Fatalf("%s: invalid JSON %s: %v", c.desc, w.Body.Bytes(), err)
+		}
+		if got.String() != c.want {
+			t.Errorf("%s: incorrect target groups:\ngot\n%s\nwant\n%s", c.desc, got.String(), c.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package load
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// TableHeader is the header that starts the data of the formats loaded into
+// tables, e.g., timescaledb or clickhouse: a line with the tag keys prefixed
+// with "tags", a line per table with its name then its fields, and a blank
+// line to separate it from the data.
+type TableHeader struct {
+	// TagKeys are the keys of the tags, common to all tables
+	TagKeys []string
+	// Tables are the names of the tables, in the order of the header
+	Tables []string
+	// Fields are the fields of every table, some of which may be empty
+	Fields map[string][]string
+}
+
+// ReadTableHeader reads the header at the start of br, which is left at the
+// first line of data
+func ReadTableHeader(br *bufio.Reader) (*TableHeader, error) {
+	line, err := readHeaderLine(br)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(line, ",")
+	if parts[0] != "tags" {
+		return nil, fmt.Errorf("got '%s', expected 'tags'", parts[0])
+	}
+	h := &TableHeader{TagKeys: parts[1:], Fields: map[string][]string{}}
+	for {
+		line, err := readHeaderLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			return h, nil
+		}
+		parts := strings.Split(line, ",")
+		h.Tables = append(h.Tables, parts[0])
+		h.Fields[parts[0]] = parts[1:]
+	}
+}
+
+func readHeaderLine(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
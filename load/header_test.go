@@ -0,0 +1,85 @@
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadTableHeader(t *testing.T) {
+	cases := []struct {
+		desc         string
+		input        string
+		want         *TableHeader
+		wantBuffered int
+		shouldError  bool
+	}{
+		{
+			desc:  "min case: exactly three lines",
+			input: "tags,tag1,tag2\ncols,col1,col2\n\n",
+			want: &TableHeader{
+				TagKeys: []string{"tag1", "tag2"},
+				Tables:  []string{"cols"},
+				Fields:  map[string][]string{"cols": {"col1", "col2"}},
+			},
+			wantBuffered: 0,
+		},
+		{
+			desc:  "min case: more than the header 3 lines",
+			input: "tags,tag1,tag2\ncols,col1,col2\n\nrow1\nrow2\n",
+			want: &TableHeader{
+				TagKeys: []string{"tag1", "tag2"},
+				Tables:  []string{"cols"},
+				Fields:  map[string][]string{"cols": {"col1", "col2"}},
+			},
+			wantBuffered: len([]byte("row1\nrow2\n")),
+		},
+		{
+			desc:  "multiple tables: more than 3 lines for header w/ extra",
+			input: "tags,tag1,tag2\ncols2,col21,col22\ncols,col1,,col3\n\nrow1\nrow2\n",
+			want: &TableHeader{
+				TagKeys: []string{"tag1", "tag2"},
+				Tables:  []string{"cols2", "cols"},
+				Fields:  map[string][]string{"cols": {"col1", "", "col3"}, "cols2": {"col21", "col22"}},
+			},
+			wantBuffered: len([]byte("row1\nrow2\n")),
+		},
+		{
+			desc:        "too few lines",
+			input:       "tags\ncols\n",
+			shouldError: true,
+		},
+		{
+			desc:        "no line ender",
+			input:       "tags",
+			shouldError: true,
+		},
+		{
+			desc:        "no tags",
+			input:       "cols,col1,col2\n\n",
+			shouldError: true,
+		},
+	}
+
+	for _, c := range cases {
+		br := bufio.NewReader(bytes.NewReader([]byte(c.input)))
+		h, err := ReadTableHeader(br)
+		if c.shouldError {
+			if err == nil {
+				t.Errorf("%s: did not return an error when it should", c.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(h, c.want) {
+			t.Errorf("%s: incorrect header: got\n%+v\nwant\n%+v", c.desc, h, c.want)
+		}
+		if br.Buffered() != c.wantBuffered {
+			t.Errorf("%s: incorrect amt buffered: got\n%d\nwant\n%d", c.desc, br.Buffered(), c.wantBuffered)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b, 0x08}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// multiFileReader is an io.Reader that reads a list of files one after
+// another, as if they were a single stream. Each file is opened lazily and is
+// decompressed on the fly if it is gzip or zstd compressed. A newline is added
+// between two files if the first does not end with one, so that their lines
+// are not merged.
+type multiFileReader struct {
+	files []string
+	cur   io.Reader
+	close func() error
+	last  byte // last is the last byte read, 0 if nothing was read yet
+}
+
+// newMultiFileReader returns a reader over all the files matching the glob
+// pattern, in natural order: numbers in the names are compared by value, so
+// data_2.gz comes before data_10.gz. It returns an error if nothing matches.
+func newMultiFileReader(pattern string) (*multiFileReader, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %q", pattern)
+	}
+	sort.Slice(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
+	return &multiFileReader{files: files}, nil
+}
+
+// OpenFiles returns a reader over all the files matching the glob pattern, read
+// and decompressed the same way as the -file of the loaders
+func OpenFiles(pattern string) (io.Reader, error) {
+	r, err := newMultiFileReader(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// naturalLess compares a and b like strings, except for their runs of digits
+// which are compared by numerical value
+func naturalLess(a, b string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		da, db := digitsPrefix(a), digitsPrefix(b)
+		if len(da) == 0 || len(db) == 0 {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		// compare the values without leading zeros by length, then digits
+		va, vb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if len(va) != len(vb) {
+			return len(va) < len(vb)
+		}
+		if va != vb {
+			return va < vb
+		}
+		if len(da) != len(db) {
+			return len(da) < len(db)
+		}
+		a, b = a[len(da):], b[len(db):]
+	}
+	return len(a) < len(b)
+}
+
+// digitsPrefix returns the run of digits at the start of s
+func digitsPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// Read implements io.Reader, moving on to the next file when the current one
+// is exhausted
+func (r *multiFileReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+			if r.last != 0 && r.last != '\n' && len(p) > 0 {
+				p[0] = '\n'
+				r.last = '\n'
+				return 1, nil
+			}
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.cur.Read(p)
+		if n > 0 {
+			r.last = p[n-1]
+		}
+		if err == io.EOF {
+			if cerr := r.Close(); cerr != nil {
+				return n, cerr
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close closes the file currently being read, if any
+func (r *multiFileReader) Close() error {
+	r.cur = nil
+	if r.close == nil {
+		return nil
+	}
+	err := r.close()
+	r.close = nil
+	return err
+}
+
+// next opens the next file in the list and sets up decompression if needed
+func (r *multiFileReader) next() error {
+	name := r.files[0]
+	r.files = r.files[1:]
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReaderSize(f, defaultReadSize)
+	// Peek errors just mean the file is too short to hold a magic number,
+	// in which case it is read as is
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("cannot read gzip file %s: %v", name, err)
+		}
+		r.cur = zr
+		r.close = func() error {
+			zr.Close()
+			return f.Close()
+		}
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("cannot read zstd file %s: %v", name, err)
+		}
+		r.cur = zr
+		r.close = func() error {
+			zr.Close()
+			return f.Close()
+		}
+	default:
+		r.cur = br
+		r.close = f.Close
+	}
+	return nil
+}
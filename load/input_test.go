@@ -0,0 +1,145 @@
+package load
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeTestFile(t *testing.T, name string, data string, compression string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("could not create file %s: %v", name, err)
+	}
+	defer f.Close()
+	switch compression {
+	case "gzip":
+		w := gzip.NewWriter(f)
+		w.Write([]byte(data))
+		w.Close()
+	case "zstd":
+		w, err := zstd.NewWriter(f)
+		if err != nil {
+			t.Fatalf("could not create zstd writer: %v", err)
+		}
+		w.Write([]byte(data))
+		w.Close()
+	default:
+		f.Write([]byte(data))
+	}
+}
+
+func TestMultiFileReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-load")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, filepath.Join(dir, "data-0"), "line0\nline1\n", "")
+	writeTestFile(t, filepath.Join(dir, "data-1"), "line2\n", "gzip")
+	writeTestFile(t, filepath.Join(dir, "data-2"), "", "")
+	writeTestFile(t, filepath.Join(dir, "data-3"), "line3\nline4\n", "zstd")
+	writeTestFile(t, filepath.Join(dir, "other"), "other\n", "")
+
+	cases := []struct {
+		desc    string
+		pattern string
+		want    string
+	}{
+		{
+			desc:    "single plain file",
+			pattern: filepath.Join(dir, "data-0"),
+			want:    "line0\nline1\n",
+		},
+		{
+			desc:    "single gzip file",
+			pattern: filepath.Join(dir, "data-1"),
+			want:    "line2\n",
+		},
+		{
+			desc:    "single zstd file",
+			pattern: filepath.Join(dir, "data-3"),
+			want:    "line3\nline4\n",
+		},
+		{
+			desc:    "glob of mixed files",
+			pattern: filepath.Join(dir, "data-*"),
+			want:    "line0\nline1\nline2\nline3\nline4\n",
+		},
+	}
+
+	for _, c := range cases {
+		r, err := newMultiFileReader(c.pattern)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Errorf("%s: unexpected read error: %v", c.desc, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: incorrect output: got\n%q\nwant\n%q", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestMultiFileReaderOrderAndNewlines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-load")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the files that do not end with a newline are not merged with the next
+	writeTestFile(t, filepath.Join(dir, "data_10.gz"), "line10\n", "gzip")
+	writeTestFile(t, filepath.Join(dir, "data_2.gz"), "line2", "gzip")
+	writeTestFile(t, filepath.Join(dir, "data_1"), "line1", "")
+	writeTestFile(t, filepath.Join(dir, "data_3"), "", "")
+	writeTestFile(t, filepath.Join(dir, "data_11"), "line11", "")
+
+	r, err := newMultiFileReader(filepath.Join(dir, "data_*"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Errorf("unexpected read error: %v", err)
+	}
+	if want := "line1\nline2\nline10\nline11"; string(got) != want {
+		t.Errorf("incorrect output: got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"data_2.gz", "data_10.gz", true},
+		{"data_10.gz", "data_2.gz", false},
+		{"data_02", "data_2", false},
+		{"data_2", "data_02", true},
+		{"data_2", "data_2", false},
+		{"a1b2", "a1b10", true},
+		{"a", "a1", true},
+		{"b1", "a2", false},
+	}
+	for _, c := range cases {
+		if got := naturalLess(c.a, c.b); got != c.want {
+			t.Errorf("naturalLess(%q, %q): got %v want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestMultiFileReaderNoMatch(t *testing.T) {
+	_, err := newMultiFileReader(filepath.Join(os.TempDir(), "tsbs-does-not-exist-*"))
+	if err == nil {
+		t.Errorf("did not get error for pattern with no matches")
+	}
+}
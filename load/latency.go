@@ -0,0 +1,101 @@
+package load
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+const (
+	// latencyUnit is the resolution of batch latencies stored in the
+	// histograms, which record integer values: microseconds
+	latencyUnit = time.Microsecond
+	// latencyMax is the largest batch latency tracked (in latencyUnit);
+	// larger values are recorded as latencyMax
+	latencyMax = int64(time.Hour / latencyUnit)
+	// latencySigFigs is the number of significant figures kept by the
+	// histograms, i.e., 3 gives a precision of 0.1%
+	latencySigFigs = 3
+)
+
+// workerLatencies holds the batch latencies of a single worker, both for the
+// whole run and for the current reporting period
+type workerLatencies struct {
+	mu     sync.Mutex
+	total  *hdrhistogram.Histogram
+	period *hdrhistogram.Histogram
+}
+
+// batchLatencies records how long workers take to process each batch, with
+// one histogram per worker so workers do not contend on a single lock.
+type batchLatencies struct {
+	workers []*workerLatencies
+}
+
+func newLatencyHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(1, latencyMax, latencySigFigs)
+}
+
+// newBatchLatencies returns a batchLatencies for the given number of workers
+func newBatchLatencies(workers uint) *batchLatencies {
+	ret := &batchLatencies{}
+	for i := uint(0); i < workers; i++ {
+		ret.workers = append(ret.workers, &workerLatencies{
+			total:  newLatencyHistogram(),
+			period: newLatencyHistogram(),
+		})
+	}
+	return ret
+}
+
+// record adds the time a worker took to process a batch
+func (bl *batchLatencies) record(workerNum int, took time.Duration) {
+	v := int64(took / latencyUnit)
+	if v > latencyMax {
+		v = latencyMax
+	}
+	w := bl.workers[workerNum%len(bl.workers)]
+	w.mu.Lock()
+	// values are within range, so recording cannot fail
+	w.total.RecordValue(v)
+	w.period.RecordValue(v)
+	w.mu.Unlock()
+}
+
+// takePeriod returns the batch latencies of all workers for the current
+// period and starts a new one
+func (bl *batchLatencies) takePeriod() *hdrhistogram.Histogram {
+	ret := newLatencyHistogram()
+	for _, w := range bl.workers {
+		w.mu.Lock()
+		ret.Merge(w.period)
+		w.period.Reset()
+		w.mu.Unlock()
+	}
+	return ret
+}
+
+// total returns the batch latencies of all workers for the whole run
+func (bl *batchLatencies) total() *hdrhistogram.Histogram {
+	ret := newLatencyHistogram()
+	for _, w := range bl.workers {
+		w.mu.Lock()
+		ret.Merge(w.total)
+		w.mu.Unlock()
+	}
+	return ret
+}
+
+// latencyMs converts a histogram value to milliseconds
+func latencyMs(v int64) float64 {
+	return float64(time.Duration(v)*latencyUnit) / float64(time.Millisecond)
+}
+
+// formatLatencies returns the p50, p99 and max of the batch latencies in h,
+// in milliseconds, as comma-separated columns for the periodic report
+func formatLatencies(h *hdrhistogram.Histogram) string {
+	return fmt.Sprintf(",%0.2f,%0.2f,%0.2f",
+		latencyMs(h.ValueAtQuantile(50)), latencyMs(h.ValueAtQuantile(99)), latencyMs(h.Max()))
+}
@@ -0,0 +1,51 @@
+package load
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchLatenciesRecord(t *testing.T) {
+	bl := newBatchLatencies(2)
+	for i := 1; i <= 100; i++ {
+		bl.record(i%2, time.Duration(i)*time.Millisecond)
+	}
+	// values larger than the histogram range are capped
+	bl.record(0, 2*time.Hour)
+
+	h := bl.total()
+	if got := h.TotalCount(); got != 101 {
+		t.Errorf("incorrect count: got %d want %d", got, 101)
+	}
+	// the capped value is the largest, so the median is the 51st value
+	if got := latencyMs(h.ValueAtQuantile(50)); got < 51 || got > 51.1 {
+		t.Errorf("incorrect p50: got %v want %v", got, 51)
+	}
+	if got := latencyMs(h.Max()); got < 3600*1000 || got > 3600*1000*1.001 {
+		t.Errorf("incorrect max: got %v want %v", got, 3600*1000)
+	}
+}
+
+func TestBatchLatenciesTakePeriod(t *testing.T) {
+	bl := newBatchLatencies(3)
+	bl.record(0, time.Millisecond)
+	bl.record(1, 2*time.Millisecond)
+	bl.record(2, 3*time.Millisecond)
+
+	h := bl.takePeriod()
+	if got := h.TotalCount(); got != 3 {
+		t.Errorf("incorrect period count: got %d want %d", got, 3)
+	}
+	if got := formatLatencies(h); got != ",2.00,3.00,3.00" {
+		t.Errorf("incorrect formatted latencies: got %s want %s", got, ",2.00,3.00,3.00")
+	}
+
+	// taking the period resets it, but not the totals
+	bl.record(0, 4*time.Millisecond)
+	if got := bl.takePeriod().TotalCount(); got != 1 {
+		t.Errorf("period not reset: got count %d want %d", got, 1)
+	}
+	if got := bl.total().TotalCount(); got != 4 {
+		t.Errorf("incorrect total count: got %d want %d", got, 4)
+	}
+}
@@ -0,0 +1,67 @@
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+)
+
+var newLine = []byte("\n")
+
+// LineDecoder is a PointDecoder for formats with a single metric per line,
+// e.g., the Graphite plaintext protocol. The Data of its Points is a copy of
+// the line, without its newline.
+type LineDecoder struct {
+	scanner *bufio.Scanner
+}
+
+// NewLineDecoder returns a LineDecoder reading the lines of br
+func NewLineDecoder(br *bufio.Reader) *LineDecoder {
+	return &LineDecoder{scanner: bufio.NewScanner(br)}
+}
+
+// Decode returns the next line as a Point, or nil at the end of the input
+func (d *LineDecoder) Decode(_ *bufio.Reader) *Point {
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return nil
+	} else if !ok {
+		log.Fatalf("scan error: %v", d.scanner.Err())
+		return nil
+	}
+	line := make([]byte, len(d.scanner.Bytes()))
+	copy(line, d.scanner.Bytes())
+	return NewPoint(line)
+}
+
+// LineBatch is a Batch of the lines decoded by a LineDecoder, each of them
+// being a single metric
+type LineBatch struct {
+	buf     *bytes.Buffer
+	metrics uint64
+}
+
+// Len returns the number of lines, and thus metrics, of the batch
+func (b *LineBatch) Len() int {
+	return int(b.metrics)
+}
+
+// Append adds the line of item to the batch
+func (b *LineBatch) Append(item *Point) {
+	b.buf.Write(item.Data.([]byte))
+	b.buf.Write(newLine)
+	b.metrics++
+}
+
+// Bytes returns the lines of the batch, each ending with a newline
+func (b *LineBatch) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// LineBatchFactory is a BatchFactory of LineBatches
+type LineBatchFactory struct{}
+
+// New returns an empty LineBatch
+func (f *LineBatchFactory) New() Batch {
+	return &LineBatch{buf: &bytes.Buffer{}}
+}
@@ -0,0 +1,23 @@
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestLineDecoderAndBatch(t *testing.T) {
+	input := "cpu.usage_user;hostname=host_0 1 1\ncpu.usage_system;hostname=host_0 2 1\n"
+	br := bufio.NewReader(bytes.NewReader([]byte(input)))
+	decoder := NewLineDecoder(br)
+	b := (&LineBatchFactory{}).New().(*LineBatch)
+	for p := decoder.Decode(br); p != nil; p = decoder.Decode(br) {
+		b.Append(p)
+	}
+	if b.Len() != 2 {
+		t.Errorf("incorrect batch len: got %d want 2", b.Len())
+	}
+	if got := string(b.Bytes()); got != input {
+		t.Errorf("incorrect batch data: got %q want %q", got, input)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/hagen1778/tsbs/manifest"
+	"github.com/hagen1778/tsbs/metrics"
 )
 
 const (
@@ -53,12 +57,23 @@ type BenchmarkRunner struct {
 	doCreateDB      bool
 	doAbortOnExist  bool
 	reportingPeriod time.Duration
-	filename        string // TODO implement file reading
+	fileName        string
+	maxRate         float64
+	duration        time.Duration
+	metricsAddr     string
+	manifestFile    string
 
 	// non-flag fields
 	br        *bufio.Reader
 	metricCnt uint64
 	rowCnt    uint64
+	limiter   *rateLimiter
+	deadline  time.Time
+	latencies *batchLatencies
+	retrier   *retrier
+	manifest  *manifest.Manifest
+
+	batchDurations []*metrics.Histogram
 }
 
 var loader = &BenchmarkRunner{}
@@ -72,6 +87,7 @@ func GetBenchmarkRunner() *BenchmarkRunner {
 // GetBenchmarkRunnerWithBatchSize returns the singleton BenchmarkRunner for use in a benchmark program
 // with a non-default batch size.
 func GetBenchmarkRunnerWithBatchSize(batchSize uint) *BenchmarkRunner {
+	loader.retrier = newRetrier()
 	flag.StringVar(&loader.dbName, "db-name", "benchmark", "Name of database")
 
 	flag.UintVar(&loader.batchSize, "batch-size", batchSize, "Number of items to batch together in a single insert")
@@ -81,6 +97,14 @@ func GetBenchmarkRunnerWithBatchSize(batchSize uint) *BenchmarkRunner {
 	flag.BoolVar(&loader.doCreateDB, "do-create-db", true, "Whether to create the database. Disable on all but one client if running on a multi client setup.")
 	flag.BoolVar(&loader.doAbortOnExist, "do-abort-on-exist", false, "Whether to abort if a database with the given name already exists.")
 	flag.DurationVar(&loader.reportingPeriod, "reporting-period", 10*time.Second, "Period to report write stats")
+	flag.Float64Var(&loader.maxRate, "max-rate", 0, "Maximum number of metrics per second to insert, shared across all workers (0 = no limit).")
+	flag.DurationVar(&loader.duration, "duration", 0, "Stop loading after this long, even if there is more input (0 = load all of the input).")
+	flag.UintVar(&loader.retrier.maxRetries, "max-retries", 10, "Number of times to retry a failed insert before dropping the batch (0 = retry until it succeeds).")
+	flag.DurationVar(&loader.retrier.backoff, "retry-backoff", 100*time.Millisecond, "Time to wait before the first retry of a failed insert, doubled for each further retry.")
+	flag.DurationVar(&loader.retrier.maxBackoff, "retry-max-backoff", 30*time.Second, "Maximum time to wait between two retries of a failed insert.")
+	flag.StringVar(&loader.fileName, "file", "", "File name to read data from, or a glob pattern to read several files in natural order (data_2 before data_10). Gzip and zstd compressed files are decompressed transparently. Empty means reading from stdin.")
+	flag.StringVar(&loader.metricsAddr, "metrics-addr", "", "Address to serve live loading metrics on at /metrics in the Prometheus text format, e.g., ':9090' (empty to disable).")
+	flag.StringVar(&loader.manifestFile, "manifest", "", "Manifest written by tsbs_generate_data for the input, to check that all of its metrics and rows were loaded (empty to disable).")
 
 	return loader
 }
@@ -90,14 +114,57 @@ func (l *BenchmarkRunner) DatabaseName() string {
 	return l.dbName
 }
 
+// FileName returns the value of the -file flag, empty if data is read from
+// stdin
+func (l *BenchmarkRunner) FileName() string {
+	return l.fileName
+}
+
+// ReportingPeriod returns the period at which loading stats are reported
+func (l *BenchmarkRunner) ReportingPeriod() time.Duration {
+	return l.reportingPeriod
+}
+
+// ResetReportingPeriod changes the period at which loading stats are
+// reported, with 0 disabling the reports
+func (l *BenchmarkRunner) ResetReportingPeriod(period time.Duration) {
+	l.reportingPeriod = period
+}
+
+// Progress returns the number of metrics and rows loaded so far
+func (l *BenchmarkRunner) Progress() (metrics, rows uint64) {
+	return atomic.LoadUint64(&l.metricCnt), atomic.LoadUint64(&l.rowCnt)
+}
+
 // RunBenchmark takes in a Benchmark b, a bufio.Reader br, and holders for number of metrics and rows
 // and uses those to run the load benchmark
 func (l *BenchmarkRunner) RunBenchmark(b Benchmark, workQueues uint) {
 	l.br = l.GetBufferedReader()
+	if len(l.manifestFile) > 0 {
+		m, err := manifest.Load(l.manifestFile)
+		if err != nil {
+			log.Fatalf("could not load manifest: %v", err)
+		}
+		l.manifest = m
+	}
 	cleanupFn := l.useDBCreator(b.GetDBCreator())
 	defer cleanupFn()
 
 	channels := l.createChannels(workQueues)
+	l.latencies = newBatchLatencies(l.workers)
+	if len(l.metricsAddr) > 0 {
+		if err := l.serveMetrics(); err != nil {
+			log.Fatalf("could not serve metrics: %v", err)
+		}
+	}
+
+	if l.maxRate > 0 {
+		l.limiter = newRateLimiter(l.maxRate)
+	}
+	start := time.Now()
+	if l.duration > 0 {
+		l.deadline = start.Add(l.duration)
+	}
 
 	var wg sync.WaitGroup
 	for i := 0; i < int(l.workers); i++ {
@@ -105,7 +172,6 @@ func (l *BenchmarkRunner) RunBenchmark(b Benchmark, workQueues uint) {
 		go l.work(b, &wg, channels[i%len(channels)], i)
 	}
 
-	start := time.Now()
 	l.scan(b, channels)
 
 	for _, c := range channels {
@@ -115,13 +181,37 @@ func (l *BenchmarkRunner) RunBenchmark(b Benchmark, workQueues uint) {
 	end := time.Now()
 
 	l.summary(end.Sub(start))
+	if err := l.checkManifest(); err != nil {
+		log.Fatalf("manifest check failed: %v", err)
+	}
+}
+
+// checkManifest returns an error if the counts of metrics and rows loaded do
+// not match the manifest, when the whole input was to be loaded
+func (l *BenchmarkRunner) checkManifest() error {
+	if l.manifest == nil || !l.doLoad {
+		return nil
+	}
+	if l.limit > 0 || l.expired() {
+		printFn("manifest not checked: the input was not loaded in full\n")
+		return nil
+	}
+	if err := l.manifest.CheckCounts(l.metricCnt, l.rowCnt); err != nil {
+		return err
+	}
+	printFn("manifest check passed: %d metrics and %d points expected\n", l.manifest.Metrics, l.manifest.Points)
+	return nil
 }
 
 // GetBufferedReader returns the buffered Reader that should be used by the loader
 func (l *BenchmarkRunner) GetBufferedReader() *bufio.Reader {
 	if l.br == nil {
-		if len(l.filename) > 0 {
-			l.br = nil // TODO - Support reading from files
+		if len(l.fileName) > 0 {
+			r, err := newMultiFileReader(l.fileName)
+			if err != nil {
+				panic(fmt.Sprintf("cannot open file for read %s: %v", l.fileName, err))
+			}
+			l.br = bufio.NewReaderSize(r, defaultReadSize)
 		} else {
 			l.br = bufio.NewReaderSize(os.Stdin, defaultReadSize)
 		}
@@ -191,7 +281,16 @@ func (l *BenchmarkRunner) scan(b Benchmark, channels []*duplexChannel) uint64 {
 	if l.reportingPeriod.Nanoseconds() > 0 {
 		go l.report(l.reportingPeriod)
 	}
-	return scanWithIndexer(channels, l.batchSize, l.limit, l.br, b.GetPointDecoder(l.br), b.GetBatchFactory(), b.GetPointIndexer(uint(len(channels))))
+	decoder := b.GetPointDecoder(l.br)
+	if !l.deadline.IsZero() {
+		decoder = &timedDecoder{decoder: decoder, expired: l.expired}
+	}
+	return scanWithIndexer(channels, l.batchSize, l.limit, l.br, decoder, b.GetBatchFactory(), b.GetPointIndexer(uint(len(channels))))
+}
+
+// expired returns whether the -duration of the benchmark has passed
+func (l *BenchmarkRunner) expired() bool {
+	return !l.deadline.IsZero() && time.Now().After(l.deadline)
 }
 
 // work is the processing function for each worker in the loader
@@ -199,9 +298,24 @@ func (l *BenchmarkRunner) work(b Benchmark, wg *sync.WaitGroup, c *duplexChannel
 	proc := b.GetProcessor()
 	proc.Init(workerNum, l.doLoad)
 	for b := range c.toWorker {
+		// batches still in flight when time is up are skipped so the
+		// benchmark stops on time
+		if l.expired() {
+			c.sendToScanner()
+			continue
+		}
+		start := time.Now()
 		metricCnt, rowCnt := proc.ProcessBatch(b, l.doLoad)
+		took := time.Since(start)
+		if l.latencies != nil {
+			l.latencies.record(workerNum, took)
+		}
+		l.observeBatch(workerNum, took)
 		atomic.AddUint64(&l.metricCnt, metricCnt)
 		atomic.AddUint64(&l.rowCnt, rowCnt)
+		if l.limiter != nil {
+			l.limiter.wait(metricCnt)
+		}
 		c.sendToScanner()
 	}
 	switch c := proc.(type) {
@@ -220,6 +334,22 @@ func (l *BenchmarkRunner) summary(took time.Duration) {
 		rowRate := float64(l.rowCnt) / float64(took.Seconds())
 		printFn("loaded %d rows in %0.3fsec with %d workers (mean rate %0.2f rows/sec)\n", l.rowCnt, took.Seconds(), l.workers, rowRate)
 	}
+	if l.latencies != nil {
+		h := l.latencies.total()
+		printFn("batch latency: p50 %0.2fms, p99 %0.2fms, max %0.2fms\n",
+			latencyMs(h.ValueAtQuantile(50)), latencyMs(h.ValueAtQuantile(99)), latencyMs(h.Max()))
+	}
+	if l.retrier != nil {
+		if retries := l.retrier.summary(); len(retries) > 0 {
+			printFn("%s", retries)
+		}
+	}
+	if l.maxRate > 0 {
+		printFn("target rate %0.2f metrics/sec, achieved %0.2f%% of target\n", l.maxRate, 100*metricRate/l.maxRate)
+	}
+	if l.expired() {
+		printFn("stopped after the -duration limit of %v\n", l.duration)
+	}
 }
 
 // report handles periodic reporting of loading stats
@@ -229,11 +359,27 @@ func (l *BenchmarkRunner) report(period time.Duration) {
 	prevColCount := uint64(0)
 	prevRowCount := uint64(0)
 
-	printFn("time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s\n")
+	// with a rate limit, the target rate is reported as an extra column
+	header := "time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s"
+	target := ""
+	if l.maxRate > 0 {
+		header += ",target metric/s"
+		target = fmt.Sprintf(",%0.2f", l.maxRate)
+	}
+	// so are the batch latencies of the period, when they are recorded
+	if l.latencies != nil {
+		header += ",batch p50 ms,batch p99 ms,batch max ms"
+	}
+	printFn("%s\n", header)
 	for now := range time.NewTicker(period).C {
 		cCount := atomic.LoadUint64(&l.metricCnt)
 		rCount := atomic.LoadUint64(&l.rowCnt)
 
+		latencies := ""
+		if l.latencies != nil {
+			latencies = formatLatencies(l.latencies.takePeriod())
+		}
+
 		sinceStart := now.Sub(start)
 		took := now.Sub(prevTime)
 		colrate := float64(cCount-prevColCount) / float64(took.Seconds())
@@ -241,9 +387,9 @@ func (l *BenchmarkRunner) report(period time.Duration) {
 		if rCount > 0 {
 			rowrate := float64(rCount-prevRowCount) / float64(took.Seconds())
 			overallRowRate := float64(rCount) / float64(sinceStart.Seconds())
-			printFn("%d,%0.2f,%E,%0.2f,%0.2f,%E,%0.2f\n", now.Unix(), colrate, float64(cCount), overallColRate, rowrate, float64(rCount), overallRowRate)
+			printFn("%d,%0.2f,%E,%0.2f,%0.2f,%E,%0.2f%s%s\n", now.Unix(), colrate, float64(cCount), overallColRate, rowrate, float64(rCount), overallRowRate, target, latencies)
 		} else {
-			printFn("%d,%0.2f,%E,%0.2f,-,-,-\n", now.Unix(), colrate, float64(cCount), overallColRate)
+			printFn("%d,%0.2f,%E,%0.2f,-,-,-%s%s\n", now.Unix(), colrate, float64(cCount), overallColRate, target, latencies)
 		}
 
 		prevColCount = cCount
@@ -8,6 +8,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/hagen1778/tsbs/manifest"
 )
 
 type testProcessor struct {
@@ -212,6 +214,74 @@ func TestSummary(t *testing.T) {
 	}
 }
 
+func TestSummaryMaxRate(t *testing.T) {
+	br := &BenchmarkRunner{}
+	br.metricCnt = 10
+	br.maxRate = 20
+	var b bytes.Buffer
+	printFn = func(s string, args ...interface{}) (n int, err error) {
+		return fmt.Fprintf(&b, s, args...)
+	}
+	br.summary(time.Second)
+	want := "\nSummary:\nloaded 10 metrics in 1.000sec with 0 workers (mean rate 10.00 metrics/sec)\ntarget rate 20.00 metrics/sec, achieved 50.00% of target\n"
+	if got := string(b.Bytes()); got != want {
+		t.Errorf("incorrect summary\ngot %s\nwant %s", got, want)
+	}
+}
+
+func TestCheckManifest(t *testing.T) {
+	var b bytes.Buffer
+	printFn = func(s string, args ...interface{}) (n int, err error) {
+		return fmt.Fprintf(&b, s, args...)
+	}
+	m := &manifest.Manifest{Points: 10, Metrics: 100}
+
+	br := &BenchmarkRunner{doLoad: true, manifest: m, metricCnt: 100, rowCnt: 10}
+	if err := br.checkManifest(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got, want := b.String(), "manifest check passed: 100 metrics and 10 points expected\n"; got != want {
+		t.Errorf("incorrect output\ngot %s\nwant %s", got, want)
+	}
+
+	br.metricCnt = 90
+	if err := br.checkManifest(); err == nil {
+		t.Errorf("expected an error for missing metrics")
+	}
+
+	// partial loads are not checked
+	b.Reset()
+	br.limit = 5
+	if err := br.checkManifest(); err != nil {
+		t.Errorf("unexpected error with a limit: %v", err)
+	}
+	if got, want := b.String(), "manifest not checked: the input was not loaded in full\n"; got != want {
+		t.Errorf("incorrect output\ngot %s\nwant %s", got, want)
+	}
+	br.limit = 0
+	br.doLoad = false
+	if err := br.checkManifest(); err != nil {
+		t.Errorf("unexpected error without loading: %v", err)
+	}
+}
+
+func TestSummaryBatchLatencies(t *testing.T) {
+	br := &BenchmarkRunner{}
+	br.metricCnt = 10
+	br.latencies = newBatchLatencies(1)
+	br.latencies.record(0, 2*time.Millisecond)
+	br.latencies.record(0, 4*time.Millisecond)
+	var b bytes.Buffer
+	printFn = func(s string, args ...interface{}) (n int, err error) {
+		return fmt.Fprintf(&b, s, args...)
+	}
+	br.summary(time.Second)
+	want := "\nSummary:\nloaded 10 metrics in 1.000sec with 0 workers (mean rate 10.00 metrics/sec)\nbatch latency: p50 2.00ms, p99 4.00ms, max 4.00ms\n"
+	if got := string(b.Bytes()); got != want {
+		t.Errorf("incorrect summary\ngot %s\nwant %s", got, want)
+	}
+}
+
 func TestReport(t *testing.T) {
 	var b bytes.Buffer
 	counter := 0
@@ -0,0 +1,45 @@
+package load
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hagen1778/tsbs/metrics"
+)
+
+// serveMetrics exposes the loading progress and the batch latencies of each
+// worker at -metrics-addr while the benchmark runs
+func (l *BenchmarkRunner) serveMetrics() error {
+	r := metrics.NewRegistry()
+	r.NewCounterFunc("tsbs_load_metrics_total", "Number of metrics loaded.", func() float64 {
+		return float64(atomic.LoadUint64(&l.metricCnt))
+	})
+	r.NewCounterFunc("tsbs_load_rows_total", "Number of rows loaded.", func() float64 {
+		return float64(atomic.LoadUint64(&l.rowCnt))
+	})
+	if l.retrier != nil {
+		r.NewCounterFunc("tsbs_load_retries_total", "Number of failed inserts that were retried.", func() float64 {
+			return float64(atomic.LoadUint64(&l.retrier.retries))
+		})
+		r.NewCounterFunc("tsbs_load_dropped_batches_total", "Number of batches dropped after failing to insert.", func() float64 {
+			return float64(atomic.LoadUint64(&l.retrier.dropped))
+		})
+	}
+	durations := r.NewHistogramVec("tsbs_load_batch_duration_seconds", "Time taken to insert a batch.",
+		metrics.LatencyBuckets, "worker")
+	l.batchDurations = make([]*metrics.Histogram, l.workers)
+	for i := range l.batchDurations {
+		l.batchDurations[i] = durations.With(strconv.Itoa(i))
+	}
+	return metrics.Serve(l.metricsAddr, r)
+}
+
+// observeBatch records the time a worker took to insert a batch, when
+// metrics are served
+func (l *BenchmarkRunner) observeBatch(workerNum int, took time.Duration) {
+	if l.batchDurations == nil {
+		return
+	}
+	l.batchDurations[workerNum%len(l.batchDurations)].Observe(took.Seconds())
+}
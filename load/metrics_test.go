@@ -0,0 +1,20 @@
+package load
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveBatch(t *testing.T) {
+	r := &BenchmarkRunner{workers: 2, metricsAddr: "127.0.0.1:0", retrier: newRetrier()}
+	// without metrics, observing is a no-op
+	r.observeBatch(0, time.Second)
+
+	if err := r.serveMetrics(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(r.batchDurations); got != 2 {
+		t.Fatalf("incorrect number of histograms: got %d want %d", got, 2)
+	}
+	r.observeBatch(3, time.Second)
+}
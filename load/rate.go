@@ -0,0 +1,73 @@
+package load
+
+import (
+	"bufio"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by all workers to cap the rate at
+// which metrics are inserted. Since the number of metrics in a batch is only
+// known once it has been processed, workers take tokens after each batch and
+// the bucket is allowed to go into debt, which later callers pay back by
+// waiting longer.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // rate is the number of tokens added per second
+	burst  float64 // burst is the maximum number of tokens the bucket holds
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing rate tokens per second, with
+// bursts of up to one second worth of tokens
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  rate,
+		tokens: rate,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens and returns how long the caller has to wait before
+// they are actually available
+func (r *rateLimiter) reserve(n uint64) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	r.tokens -= float64(n)
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
+
+// wait takes n tokens, blocking until they are available
+func (r *rateLimiter) wait(n uint64) {
+	if d := r.reserve(n); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// timedDecoder is a PointDecoder that stops returning points once expired
+// reports true, which makes the scanner finish as if it reached the end of
+// the input.
+type timedDecoder struct {
+	decoder PointDecoder
+	expired func() bool
+}
+
+// Decode returns the next Point of the wrapped decoder, or nil once expired
+func (d *timedDecoder) Decode(br *bufio.Reader) *Point {
+	if d.expired() {
+		return nil
+	}
+	return d.decoder.Decode(br)
+}
@@ -0,0 +1,99 @@
+package load
+
+import (
+	"bufio"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	r := newRateLimiter(100)
+	// the bucket starts full, so one second worth of tokens is free
+	if got := r.reserve(100); got != 0 {
+		t.Errorf("reserve within burst had to wait: %v", got)
+	}
+	// going into debt by 50 tokens at 100 tokens/sec takes ~500ms to pay back
+	got := r.reserve(50)
+	if got < 450*time.Millisecond || got > 500*time.Millisecond {
+		t.Errorf("incorrect wait for debt: got %v want ~%v", got, 500*time.Millisecond)
+	}
+	// later callers also pay back earlier debt
+	got = r.reserve(50)
+	if got < 950*time.Millisecond || got > time.Second {
+		t.Errorf("incorrect wait for accumulated debt: got %v want ~%v", got, time.Second)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	r := newRateLimiter(1000)
+	r.reserve(1000)
+	r.last = r.last.Add(-10 * time.Second)
+	// refilling never goes past the burst size
+	if got := r.reserve(1000); got != 0 {
+		t.Errorf("reserve after refill had to wait: %v", got)
+	}
+	if got := r.reserve(1); got == 0 {
+		t.Errorf("bucket was refilled past its burst size")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	r := newRateLimiter(1000)
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		r.wait(100)
+	}
+	// 1000 tokens are free, the next 200 take 200ms
+	if took := time.Since(start); took < 150*time.Millisecond {
+		t.Errorf("rate limited waits were too short: %v", took)
+	}
+}
+
+type countingDecoder struct {
+	calls int
+}
+
+func (d *countingDecoder) Decode(_ *bufio.Reader) *Point {
+	d.calls++
+	return NewPoint(byte(d.calls))
+}
+
+func TestTimedDecoder(t *testing.T) {
+	expired := false
+	inner := &countingDecoder{}
+	d := &timedDecoder{decoder: inner, expired: func() bool { return expired }}
+	if p := d.Decode(nil); p == nil {
+		t.Errorf("got nil point before expiring")
+	}
+	expired = true
+	if p := d.Decode(nil); p != nil {
+		t.Errorf("got point after expiring")
+	}
+	if inner.calls != 1 {
+		t.Errorf("wrapped decoder called after expiring: got %d calls want 1", inner.calls)
+	}
+}
+
+func TestWorkAfterDeadline(t *testing.T) {
+	br := &BenchmarkRunner{}
+	br.deadline = time.Now().Add(-time.Second)
+	b := &testBenchmark{processors: []*testProcessor{{}}}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c := newDuplexChannel(2)
+	c.sendToWorker(&testBatch{})
+	c.sendToWorker(&testBatch{})
+	go br.work(b, &wg, c, 0)
+	<-c.toScanner
+	<-c.toScanner
+	c.close()
+	wg.Wait()
+
+	if got := br.metricCnt; got != 0 {
+		t.Errorf("batches processed after the deadline: got %d metrics want 0", got)
+	}
+	if !b.processors[0].closed {
+		t.Errorf("processor not closed")
+	}
+}
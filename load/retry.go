@@ -0,0 +1,170 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorClass tells how a failed insert is handled by RetryInsert
+type ErrorClass int
+
+const (
+	// ErrorFatal errors stop the benchmark
+	ErrorFatal ErrorClass = iota
+	// ErrorRetriable errors are retried with exponential backoff
+	ErrorRetriable
+	// ErrorDrop errors drop the batch without retrying
+	ErrorDrop
+)
+
+// Classifier returns the ErrorClass of an error returned by an insert
+type Classifier func(err error) ErrorClass
+
+// ClassifyHTTPStatus returns the ErrorClass of an HTTP response status code:
+// throttling and server errors are retried, while other client errors drop
+// the batch since sending it again would fail the same way
+func ClassifyHTTPStatus(code int) ErrorClass {
+	if code == http.StatusTooManyRequests || code >= http.StatusInternalServerError {
+		return ErrorRetriable
+	}
+	return ErrorDrop
+}
+
+// RequestError is an error while executing an HTTP request, e.g., a timeout
+// or a refused connection
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("error while executing request: %s", e.Err)
+}
+
+// StatusError is an HTTP response, or an item of a bulk response, with an
+// unexpected status code
+type StatusError struct {
+	Code int
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("server returned HTTP status %d", e.Code)
+	}
+	return fmt.Sprintf("server returned HTTP status %d: %s", e.Code, e.Body)
+}
+
+// ClassifyHTTPError is the Classifier of the loaders inserting over HTTP:
+// failed requests and broken connections are retried, responses are
+// classified by ClassifyHTTPStatus, and other errors are fatal
+func ClassifyHTTPError(err error) ErrorClass {
+	switch e := err.(type) {
+	case *RequestError, net.Error:
+		return ErrorRetriable
+	case *StatusError:
+		return ClassifyHTTPStatus(e.Code)
+	}
+	return ErrorFatal
+}
+
+// IsTimeout reports whether err was caused by a timeout. Loaders usually
+// classify timeouts as ErrorRetriable, and query runners report them apart
+// from other failed queries.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
+
+// retrier retries failed inserts with exponential backoff and jitter, and
+// keeps count of the retries for all workers
+type retrier struct {
+	maxRetries uint          // maxRetries is the number of retries before a batch is dropped, 0 means no limit
+	backoff    time.Duration // backoff is the wait before the first retry, doubled for each retry
+	maxBackoff time.Duration // maxBackoff caps the wait between two retries
+	sleep      func(time.Duration)
+
+	retries   uint64 // retries is the number of inserts that were retried
+	backoffNs uint64 // backoffNs is the total time spent waiting to retry
+	dropped   uint64 // dropped is the number of batches given up on
+}
+
+func newRetrier() *retrier {
+	return &retrier{sleep: time.Sleep}
+}
+
+// wait returns how long to wait before retrying after attempt+1 failures:
+// half of the exponential backoff, plus a random part up to the other half
+// so workers failing at the same time do not retry at the same time
+func (r *retrier) wait(attempt uint) time.Duration {
+	d := r.maxBackoff
+	if attempt < 32 && r.backoff<<attempt < r.maxBackoff {
+		d = r.backoff << attempt
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// run calls insert until it succeeds. It returns false if the batch was
+// dropped, and an error if insert failed with an ErrorFatal error.
+func (r *retrier) run(classify Classifier, insert func() error) (bool, error) {
+	for attempt := uint(0); ; attempt++ {
+		err := insert()
+		if err == nil {
+			return true, nil
+		}
+		switch classify(err) {
+		case ErrorDrop:
+			atomic.AddUint64(&r.dropped, 1)
+			return false, nil
+		case ErrorRetriable:
+			if r.maxRetries > 0 && attempt >= r.maxRetries {
+				atomic.AddUint64(&r.dropped, 1)
+				return false, nil
+			}
+			d := r.wait(attempt)
+			atomic.AddUint64(&r.retries, 1)
+			atomic.AddUint64(&r.backoffNs, uint64(d))
+			r.sleep(d)
+		default:
+			return false, err
+		}
+	}
+}
+
+// summary returns a description of the retries, or an empty string if
+// nothing was retried or dropped
+func (r *retrier) summary() string {
+	retries := atomic.LoadUint64(&r.retries)
+	dropped := atomic.LoadUint64(&r.dropped)
+	if retries == 0 && dropped == 0 {
+		return ""
+	}
+	backoff := time.Duration(atomic.LoadUint64(&r.backoffNs))
+	return fmt.Sprintf("retried %d inserts, waiting %0.3fsec in backoff, and dropped %d batches\n", retries, backoff.Seconds(), dropped)
+}
+
+// RetryInsert calls insert until it succeeds, handling its errors according
+// to classify: ErrorRetriable errors are retried with exponential backoff
+// and jitter up to -max-retries times, after which the batch is dropped,
+// ErrorDrop errors drop the batch right away, and ErrorFatal errors stop the
+// program. It returns whether the batch was inserted.
+func (l *BenchmarkRunner) RetryInsert(classify Classifier, insert func() error) bool {
+	ok, err := l.retrier.run(classify, insert)
+	if err != nil {
+		log.Fatalf("Error writing: %s\n", err.Error())
+	}
+	return ok
+}
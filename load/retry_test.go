@@ -0,0 +1,199 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	errRetriable = errors.New("retriable")
+	errDrop      = errors.New("drop")
+	errFatal     = errors.New("fatal")
+)
+
+func testClassifier(err error) ErrorClass {
+	switch err {
+	case errRetriable:
+		return ErrorRetriable
+	case errDrop:
+		return ErrorDrop
+	}
+	return ErrorFatal
+}
+
+// newTestRetrier returns a retrier that records its waits instead of sleeping
+func newTestRetrier(maxRetries uint, waits *[]time.Duration) *retrier {
+	r := newRetrier()
+	r.maxRetries = maxRetries
+	r.backoff = 100 * time.Millisecond
+	r.maxBackoff = time.Second
+	r.sleep = func(d time.Duration) { *waits = append(*waits, d) }
+	return r
+}
+
+// failing returns an insert function failing with errs before succeeding
+func failing(errs ...error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetrierWait(t *testing.T) {
+	r := newTestRetrier(0, nil)
+	cases := []struct {
+		attempt uint
+		want    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second}, // capped by maxBackoff
+		{100, time.Second},
+	}
+	for _, c := range cases {
+		for i := 0; i < 100; i++ {
+			got := r.wait(c.attempt)
+			if got < c.want/2 || got > c.want {
+				t.Errorf("attempt %d: wait out of range: got %v want between %v and %v", c.attempt, got, c.want/2, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRetrierRun(t *testing.T) {
+	waits := []time.Duration{}
+	r := newTestRetrier(3, &waits)
+
+	insert, calls := failing(errRetriable, errRetriable)
+	ok, err := r.run(testClassifier, insert)
+	if !ok || err != nil {
+		t.Errorf("insert not successful after retries: ok %v, err %v", ok, err)
+	}
+	if *calls != 3 || len(waits) != 2 || r.retries != 2 {
+		t.Errorf("incorrect retries: got %d calls, %d waits, %d retries", *calls, len(waits), r.retries)
+	}
+	total := waits[0] + waits[1]
+	if got := time.Duration(r.backoffNs); got != total {
+		t.Errorf("incorrect backoff time: got %v want %v", got, total)
+	}
+
+	// out of retries, the batch is dropped
+	insert, calls = failing(errRetriable, errRetriable, errRetriable, errRetriable)
+	ok, err = r.run(testClassifier, insert)
+	if ok || err != nil {
+		t.Errorf("batch not dropped after max retries: ok %v, err %v", ok, err)
+	}
+	if *calls != 4 || r.dropped != 1 {
+		t.Errorf("incorrect drop: got %d calls, %d dropped", *calls, r.dropped)
+	}
+
+	insert, calls = failing(errDrop)
+	ok, err = r.run(testClassifier, insert)
+	if ok || err != nil || *calls != 1 || r.dropped != 2 {
+		t.Errorf("batch not dropped right away: ok %v, err %v, %d calls, %d dropped", ok, err, *calls, r.dropped)
+	}
+
+	insert, _ = failing(errRetriable, errFatal)
+	if _, err = r.run(testClassifier, insert); err != errFatal {
+		t.Errorf("fatal error not returned: got %v", err)
+	}
+}
+
+func TestRetrierRunNoLimit(t *testing.T) {
+	waits := []time.Duration{}
+	r := newTestRetrier(0, &waits)
+	errs := make([]error, 50)
+	for i := range errs {
+		errs[i] = errRetriable
+	}
+	insert, calls := failing(errs...)
+	ok, err := r.run(testClassifier, insert)
+	if !ok || err != nil || *calls != 51 {
+		t.Errorf("insert not retried until success: ok %v, err %v, %d calls", ok, err, *calls)
+	}
+}
+
+func TestRetrierSummary(t *testing.T) {
+	r := newRetrier()
+	if got := r.summary(); got != "" {
+		t.Errorf("non-empty summary without retries: %s", got)
+	}
+	r.retries = 3
+	r.backoffNs = uint64(1500 * time.Millisecond)
+	r.dropped = 1
+	want := "retried 3 inserts, waiting 1.500sec in backoff, and dropped 1 batches\n"
+	if got := r.summary(); got != want {
+		t.Errorf("incorrect summary: got %s want %s", got, want)
+	}
+}
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	cases := map[int]ErrorClass{
+		400: ErrorDrop,
+		404: ErrorDrop,
+		429: ErrorRetriable,
+		500: ErrorRetriable,
+		503: ErrorRetriable,
+	}
+	for code, want := range cases {
+		if got := ClassifyHTTPStatus(code); got != want {
+			t.Errorf("status %d: got %v want %v", code, got, want)
+		}
+	}
+}
+
+func TestClassifyHTTPError(t *testing.T) {
+	cases := []struct {
+		desc string
+		err  error
+		want ErrorClass
+	}{
+		{"request error", &RequestError{errors.New("refused")}, ErrorRetriable},
+		{"broken connection", testTimeoutError{}, ErrorRetriable},
+		{"throttled", &StatusError{Code: 429}, ErrorRetriable},
+		{"server error", &StatusError{Code: 503}, ErrorRetriable},
+		{"bad request", &StatusError{Code: 400, Body: []byte("invalid")}, ErrorDrop},
+		{"other error", errFatal, ErrorFatal},
+	}
+	for _, c := range cases {
+		if got := ClassifyHTTPError(c.err); got != c.want {
+			t.Errorf("%s: got %v want %v", c.desc, got, c.want)
+		}
+	}
+}
+
+type testTimeoutError struct{}
+
+func (e testTimeoutError) Error() string   { return "timeout" }
+func (e testTimeoutError) Timeout() bool   { return true }
+func (e testTimeoutError) Temporary() bool { return true }
+
+var _ net.Error = testTimeoutError{}
+
+func TestIsTimeout(t *testing.T) {
+	cases := []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{desc: "plain error", err: errRetriable, want: false},
+		{desc: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{desc: "timeout error", err: testTimeoutError{}, want: true},
+		{desc: "wrapped timeout error", err: fmt.Errorf("request: %w", testTimeoutError{}), want: true},
+	}
+	for _, c := range cases {
+		if got := IsTimeout(c.err); got != c.want {
+			t.Errorf("%s: got %v want %v", c.desc, got, c.want)
+		}
+	}
+}
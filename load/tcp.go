@@ -0,0 +1,48 @@
+package load
+
+import (
+	"net"
+	"time"
+)
+
+// TCPWriter streams data to a TCP server, e.g., a plaintext line protocol
+// endpoint. The connection is dialed on the first write, and dialed again
+// after a failed write, so writes can be retried with RetryInsert.
+type TCPWriter struct {
+	// Address is the host:port of the server
+	Address string
+	// Timeout bounds dialing and each write, 0 means no timeout
+	Timeout time.Duration
+
+	conn net.Conn
+}
+
+// Write writes b to the connection, dialing it first if needed. On error
+// the connection is closed, and the whole of b should be written again.
+func (w *TCPWriter) Write(b []byte) error {
+	if w.conn == nil {
+		conn, err := net.DialTimeout("tcp", w.Address, w.Timeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if w.Timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.Timeout))
+	}
+	if _, err := w.conn.Write(b); err != nil {
+		w.Close()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection, if any
+func (w *TCPWriter) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,48 @@
+package load
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPWriter(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		b, _ := ioutil.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	w := &TCPWriter{Address: ln.Addr().String(), Timeout: time.Second}
+	if err := w.Write([]byte("foo 1 0\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Write([]byte("bar 2 0\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %v", err)
+	}
+	if got, want := <-received, "foo 1 0\nbar 2 0\n"; got != want {
+		t.Errorf("incorrect data received: got %q want %q", got, want)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("closing twice returned an error: %v", err)
+	}
+
+	// Once the server is gone, writes fail as dialing does
+	ln.Close()
+	if err := w.Write([]byte("baz 3 0\n")); err == nil {
+		t.Errorf("write to a closed server did not return an error")
+	}
+}
@@ -0,0 +1,129 @@
+// Package manifest describes the datasets written by tsbs_generate_data, so
+// that the programs consuming them can use matching settings and check that
+// they loaded all of the data.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Manifest describes a dataset: how it was generated and what it contains.
+type Manifest struct {
+	Format string `json:"format"`
+	// UseCase is empty for datasets of a Scenario file
+	UseCase      string    `json:"use_case,omitempty"`
+	Scenario     string    `json:"scenario,omitempty"`
+	InitialScale uint64    `json:"initial_scale"`
+	Scale        uint64    `json:"scale"`
+	Seed         int64     `json:"seed"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Interval     Duration  `json:"interval"`
+	HostLifetime Duration  `json:"host_lifetime,omitempty"`
+	PodLifetime  Duration  `json:"pod_lifetime,omitempty"`
+
+	// A dataset generated in interleaved groups only holds the points of
+	// its group
+	InterleavedGroupID uint `json:"interleaved_group_id"`
+	InterleavedGroups  uint `json:"interleaved_groups"`
+
+	// Points is the number of points of the dataset, among which EmptyPoints
+	// have no values, which some formats leave out
+	Points      uint64 `json:"points"`
+	EmptyPoints uint64 `json:"empty_points"`
+	// Metrics is the number of field values of the points, not counting the
+	// MissingValues, which some formats write as nulls
+	Metrics       uint64 `json:"metrics"`
+	MissingValues uint64 `json:"missing_values"`
+
+	Measurements map[string]*Measurement `json:"measurements"`
+	// TagCardinalities is the number of distinct values of every tag
+	TagCardinalities map[string]int `json:"tag_cardinalities"`
+	// Hosts are the time ranges of the points of every host, device or
+	// series, identified by the value of their first tag
+	Hosts map[string]*TimeRange `json:"hosts"`
+}
+
+// Measurement describes the points of a measurement of a dataset.
+type Measurement struct {
+	Fields  []string `json:"fields"`
+	Points  uint64   `json:"points"`
+	Metrics uint64   `json:"metrics"`
+}
+
+// TimeRange is the range of timestamps of a set of points, inclusive.
+type TimeRange struct {
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// Add extends r to include t.
+func (r *TimeRange) Add(t time.Time) {
+	if t.Before(r.First) {
+		r.First = t
+	}
+	if t.After(r.Last) {
+		r.Last = t
+	}
+}
+
+// Duration is a time.Duration written in JSON as a string, e.g. "10s".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Load reads the Manifest in the JSON file at path.
+func Load(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Write writes m as JSON to the file at path.
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// CheckCounts returns an error if the numbers of metrics and rows loaded from
+// the dataset are not those of m. Depending on the format, missing values and
+// points without values may or may not be counted. Rows are not checked if
+// zero, for loaders that do not count them.
+func (m *Manifest) CheckCounts(metrics, rows uint64) error {
+	if metrics != m.Metrics && metrics != m.Metrics+m.MissingValues {
+		return fmt.Errorf("loaded %d metrics, manifest has %d", metrics, m.Metrics)
+	}
+	if rows > 0 && rows != m.Points && rows != m.Points-m.EmptyPoints {
+		return fmt.Errorf("loaded %d rows, manifest has %d points", rows, m.Points)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteLoad(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := &Manifest{
+		Format:       "influx",
+		UseCase:      "devops",
+		InitialScale: 10,
+		Scale:        10,
+		Seed:         123,
+		Start:        start,
+		End:          start.Add(time.Hour),
+		Interval:     Duration(10 * time.Second),
+		HostLifetime: Duration(6 * time.Hour),
+		Points:       3600,
+		Metrics:      36000,
+		Measurements: map[string]*Measurement{
+			"cpu": {Fields: []string{"usage_user"}, Points: 3600, Metrics: 36000},
+		},
+		TagCardinalities: map[string]int{"hostname": 10},
+		Hosts: map[string]*TimeRange{
+			"host_0": {First: start, Last: start.Add(time.Hour - 10*time.Second)},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.json")
+	if err := m.Write(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("incorrect manifest:\ngot\n%+v\nwant\n%+v", got, m)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"interval": "10s"`) {
+		t.Errorf("durations are not written as strings:\n%s", data)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"interval": 10}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("expected an error for a numeric duration")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestCheckCounts(t *testing.T) {
+	m := &Manifest{Points: 100, EmptyPoints: 2, Metrics: 900, MissingValues: 100}
+	cases := []struct {
+		desc          string
+		metrics, rows uint64
+		wantErr       bool
+	}{
+		{desc: "exact", metrics: 900, rows: 100},
+		{desc: "without empty points", metrics: 900, rows: 98},
+		{desc: "with missing values", metrics: 1000, rows: 100},
+		{desc: "rows not counted", metrics: 900},
+		{desc: "too few metrics", metrics: 899, rows: 100, wantErr: true},
+		{desc: "too many metrics", metrics: 1001, rows: 100, wantErr: true},
+		{desc: "too few rows", metrics: 900, rows: 97, wantErr: true},
+	}
+	for _, c := range cases {
+		err := m.CheckCounts(c.metrics, c.rows)
+		if c.wantErr && err == nil {
+			t.Errorf("%s: expected an error", c.desc)
+		} else if !c.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+	}
+}
+
+func TestTimeRangeAdd(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &TimeRange{First: start, Last: start}
+	r.Add(start.Add(time.Minute))
+	r.Add(start.Add(-time.Minute))
+	r.Add(start.Add(30 * time.Second))
+	if !r.First.Equal(start.Add(-time.Minute)) || !r.Last.Equal(start.Add(time.Minute)) {
+		t.Errorf("incorrect time range: %+v", r)
+	}
+}
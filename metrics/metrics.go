@@ -0,0 +1,295 @@
+// Package metrics exposes live benchmark statistics as counters and
+// histograms in the Prometheus text format, so a running benchmark can be
+// scraped and graphed alongside the system under test.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the histogram buckets
+// used for latencies
+var LatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// family is a set of samples sharing a name, written together
+type family interface {
+	write(w io.Writer) error
+}
+
+// Registry holds the metrics to expose
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) add(f family) {
+	r.mu.Lock()
+	r.families = append(r.families, f)
+	r.mu.Unlock()
+}
+
+// Write writes all metrics of the registry to w in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCounterVec adds a counter whose samples are distinguished by the
+// values of the given labels. Without labels, it holds a single sample.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{name: name, help: help, labels: labels, counters: map[string]*Counter{}}
+	r.add(cv)
+	return cv
+}
+
+// NewCounterFunc adds a counter whose value is read from fn when scraped,
+// for counts the caller already keeps
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.add(&counterFunc{name: name, help: help, fn: fn})
+}
+
+// NewHistogramVec adds a histogram with the given bucket upper bounds, whose
+// samples are distinguished by the values of the given labels
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	hv := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, histograms: map[string]*Histogram{}}
+	r.add(hv)
+	return hv
+}
+
+// Counter is a single counter sample
+type Counter struct {
+	labelValues []string
+	v           uint64
+}
+
+// Add increases the counter by n
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.v, n)
+}
+
+// Inc increases the counter by one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// CounterVec is a counter with one sample per combination of label values
+type CounterVec struct {
+	name     string
+	help     string
+	labels   []string
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+// With returns the counter for the given label values, creating it if needed
+func (cv *CounterVec) With(labelValues ...string) *Counter {
+	checkLabels(cv.name, cv.labels, labelValues)
+	key := strings.Join(labelValues, "\xff")
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	c, ok := cv.counters[key]
+	if !ok {
+		c = &Counter{labelValues: append([]string(nil), labelValues...)}
+		cv.counters[key] = c
+	}
+	return c
+}
+
+func (cv *CounterVec) write(w io.Writer) error {
+	cv.mu.Lock()
+	keys := make([]string, 0, len(cv.counters))
+	for k := range cv.counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	counters := make([]*Counter, 0, len(keys))
+	for _, k := range keys {
+		counters = append(counters, cv.counters[k])
+	}
+	cv.mu.Unlock()
+
+	if err := writeHeader(w, cv.name, cv.help, "counter"); err != nil {
+		return err
+	}
+	for _, c := range counters {
+		_, err := fmt.Fprintf(w, "%s%s %d\n", cv.name, formatLabels(cv.labels, c.labelValues), atomic.LoadUint64(&c.v))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type counterFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+func (cf *counterFunc) write(w io.Writer) error {
+	if err := writeHeader(w, cf.name, cf.help, "counter"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", cf.name, formatFloat(cf.fn()))
+	return err
+}
+
+// Histogram is a single histogram sample, counting observations in buckets
+type Histogram struct {
+	labelValues []string
+	buckets     []float64
+	mu          sync.Mutex
+	counts      []uint64 // counts has one count per bucket, plus one for +Inf, not cumulated
+	sum         float64
+	count       uint64
+}
+
+// Observe adds a value to the histogram
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// HistogramVec is a histogram with one sample per combination of label values
+type HistogramVec struct {
+	name       string
+	help       string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	histograms map[string]*Histogram
+}
+
+// With returns the histogram for the given label values, creating it if needed
+func (hv *HistogramVec) With(labelValues ...string) *Histogram {
+	checkLabels(hv.name, hv.labels, labelValues)
+	key := strings.Join(labelValues, "\xff")
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+	h, ok := hv.histograms[key]
+	if !ok {
+		h = &Histogram{
+			labelValues: append([]string(nil), labelValues...),
+			buckets:     hv.buckets,
+			counts:      make([]uint64, len(hv.buckets)+1),
+		}
+		hv.histograms[key] = h
+	}
+	return h
+}
+
+func (hv *HistogramVec) write(w io.Writer) error {
+	hv.mu.Lock()
+	keys := make([]string, 0, len(hv.histograms))
+	for k := range hv.histograms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	histograms := make([]*Histogram, 0, len(keys))
+	for _, k := range keys {
+		histograms = append(histograms, hv.histograms[k])
+	}
+	hv.mu.Unlock()
+
+	if err := writeHeader(w, hv.name, hv.help, "histogram"); err != nil {
+		return err
+	}
+	labels := append(append([]string(nil), hv.labels...), "le")
+	for _, h := range histograms {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		values := append(append([]string(nil), h.labelValues...), "")
+		cumulative := uint64(0)
+		for i, c := range counts {
+			cumulative += c
+			le := "+Inf"
+			if i < len(hv.buckets) {
+				le = formatFloat(hv.buckets[i])
+			}
+			values[len(values)-1] = le
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, formatLabels(labels, values), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+		sampleLabels := formatLabels(hv.labels, h.labelValues)
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", hv.name, sampleLabels, formatFloat(sum), hv.name, sampleLabels, count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkLabels(name string, labels, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", name, len(labels), len(values)))
+	}
+}
+
+func writeHeader(w io.Writer, name, help, typ string) error {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	return err
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// formatLabels returns the {name="value",...} part of a sample, or an empty
+// string when there are no labels
+func formatLabels(labels, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l)
+		sb.WriteString(`="`)
+		sb.WriteString(labelValueReplacer.Replace(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
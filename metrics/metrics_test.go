@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	cv := r.NewCounterVec("test_total", "Test counter.", "label")
+	cv.With("b").Add(3)
+	cv.With("a").Inc()
+	cv.With("b").Inc()
+	cv.With(`q"1`).Inc()
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{label="a"} 1
+test_total{label="b"} 4
+test_total{label="q\"1"} 1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounterVecWrongLabels(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("did not panic with the wrong number of label values")
+		}
+	}()
+	NewRegistry().NewCounterVec("test_total", "Test counter.", "label").With("a", "b")
+}
+
+func TestCounterFunc(t *testing.T) {
+	r := NewRegistry()
+	v := 1.0
+	r.NewCounterFunc("test_total", "Test counter.", func() float64 { return v })
+	v = 2.5
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "# HELP test_total Test counter.\n# TYPE test_total counter\ntest_total 2.5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	hv := r.NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "worker")
+	h := hv.With("0")
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{worker="0",le="0.1"} 2
+test_seconds_bucket{worker="0",le="1"} 3
+test_seconds_bucket{worker="0",le="+Inf"} 4
+test_seconds_sum{worker="0"} 2.65
+test_seconds_count{worker="0"} 4
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestServe(t *testing.T) {
+	r1 := NewRegistry()
+	r1.NewCounterVec("first_total", "First counter.").With().Inc()
+	r2 := NewRegistry()
+	r2.NewCounterVec("second_total", "Second counter.").With().Add(2)
+
+	const addr = "127.0.0.1:0"
+	if err := Serve(addr, r1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a second registry on the same address shares the listener
+	if err := Serve(addr, r2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	serversMu.Lock()
+	s := servers[addr]
+	serversMu.Unlock()
+	if got := len(s.registries); got != 2 {
+		t.Fatalf("incorrect number of registries: got %d want %d", got, 2)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"first_total 1\n", "second_total 2\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("response does not contain %q:\n%s", want, body)
+		}
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("incorrect content type: %s", got)
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// server exposes the registries served on one address
+type server struct {
+	mu         sync.Mutex
+	registries []*Registry
+}
+
+var (
+	serversMu sync.Mutex
+	servers   = map[string]*server{}
+)
+
+// Serve exposes the metrics of r at /metrics on addr, in the background.
+// Registries served on the same address are exposed together, so runners
+// sharing a program can also share a listener.
+func Serve(addr string, r *Registry) error {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	if s, ok := servers[addr]; ok {
+		s.mu.Lock()
+		s.registries = append(s.registries, r)
+		s.mu.Unlock()
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s := &server{registries: []*Registry{r}}
+	servers[addr] = s
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s)
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("metrics server on %s stopped: %v", addr, err)
+		}
+	}()
+	return nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	registries := append([]*Registry(nil), s.registries...)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, r := range registries {
+		if err := r.Write(w); err != nil {
+			return
+		}
+	}
+}
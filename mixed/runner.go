@@ -0,0 +1,156 @@
+// Package mixed runs a load benchmark and a query benchmark against the same
+// database at the same time, to measure how reads and writes affect each other.
+package mixed
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/query"
+)
+
+// queryFlagPrefix is prepended to the flags of the query runner so they do
+// not collide with the flags of the load runner
+const queryFlagPrefix = "query-"
+
+// reportHeader is the header of the combined periodic report
+const reportHeader = "time,per. metric/s,metric total,per. row/s,row total,per. query/s,query total,query mean ms,query p50 ms,query p99 ms,query max ms"
+
+// BenchmarkRunner runs a load.BenchmarkRunner and a query.BenchmarkRunner
+// concurrently, reporting the progress of both on a shared timeline.
+type BenchmarkRunner struct {
+	loader  *load.BenchmarkRunner
+	queries *query.BenchmarkRunner
+
+	enabled      bool
+	startMetrics uint64
+}
+
+// NewBenchmarkRunner creates a new instance of BenchmarkRunner around the
+// given loader, registering the flags of mixed mode and of the query runner,
+// which are prefixed with "query-"
+func NewBenchmarkRunner(loader *load.BenchmarkRunner) *BenchmarkRunner {
+	ret := &BenchmarkRunner{
+		loader:  loader,
+		queries: query.NewBenchmarkRunnerWithPrefix(queryFlagPrefix),
+	}
+	flag.BoolVar(&ret.enabled, "mixed", false, "Run the queries read from stdin while loading data, which then has to be read with -file. Query options are set with the -query- prefixed flags.")
+	flag.Uint64Var(&ret.startMetrics, "query-start-metrics", 1, "In mixed mode, start running queries once this many metrics are loaded.")
+
+	return ret
+}
+
+// Enabled indicates whether mixed mode was requested with the -mixed flag
+func (m *BenchmarkRunner) Enabled() bool {
+	return m.enabled
+}
+
+// Queries returns the query runner used in mixed mode
+func (m *BenchmarkRunner) Queries() *query.BenchmarkRunner {
+	return m.queries
+}
+
+// Run loads data with b while running the queries from stdin with the
+// Processors from createFn, and returns once both are done.
+func (m *BenchmarkRunner) Run(b load.Benchmark, workQueues uint, queryPool *sync.Pool, createFn query.ProcessorCreate) {
+	if err := checkDataFile(m.loader.FileName()); err != nil {
+		log.Fatal(err)
+	}
+
+	// the loader reports on its own, the combined report replaces it
+	period := m.loader.ReportingPeriod()
+	m.loader.ResetReportingPeriod(0)
+
+	loadDone := make(chan struct{})
+	done := make(chan struct{})
+	if period > 0 {
+		go m.report(os.Stdout, period, done)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.loader.RunBenchmark(b, workQueues)
+		close(loadDone)
+	}()
+	go func() {
+		defer wg.Done()
+		m.waitForMetrics(loadDone)
+		m.queries.Run(queryPool, createFn)
+	}()
+	wg.Wait()
+	close(done)
+}
+
+// checkDataFile returns an error if the data would be read from stdin, which
+// holds the queries in mixed mode
+func checkDataFile(fileName string) error {
+	if len(fileName) == 0 {
+		return fmt.Errorf("mixed mode reads queries from stdin, so data has to be read with -file")
+	}
+	return nil
+}
+
+// waitForMetrics blocks until the loader has loaded the number of metrics
+// given by -query-start-metrics, or is done
+func (m *BenchmarkRunner) waitForMetrics(loadDone <-chan struct{}) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if metrics, _ := m.loader.Progress(); metrics >= m.startMetrics {
+			return
+		}
+		select {
+		case <-loadDone:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report periodically prints the progress of both the loader and the
+// queries to w until done is closed
+func (m *BenchmarkRunner) report(w io.Writer, period time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	prevTime := time.Now()
+	prevMetrics, prevRows := uint64(0), uint64(0)
+	queryCount := int64(0)
+
+	if _, err := fmt.Fprintln(w, reportHeader); err != nil {
+		log.Fatal(err)
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			metrics, rows := m.loader.Progress()
+			ps := m.queries.TakePeriodStats()
+			queryCount += ps.Count
+			took := now.Sub(prevTime)
+			err := writeReportLine(w, now, took, metrics-prevMetrics, metrics, rows-prevRows, rows, ps, queryCount)
+			if err != nil {
+				log.Fatal(err)
+			}
+			prevTime, prevMetrics, prevRows = now, metrics, rows
+		}
+	}
+}
+
+// writeReportLine writes one line of the combined report for a period of
+// length took ending at now
+func writeReportLine(w io.Writer, now time.Time, took time.Duration, periodMetrics, metrics, periodRows, rows uint64, ps query.PeriodStats, queries int64) error {
+	secs := took.Seconds()
+	_, err := fmt.Fprintf(w, "%d,%0.2f,%E,%0.2f,%E,%0.2f,%d,%0.2f,%0.2f,%0.2f,%0.2f\n",
+		now.Unix(), float64(periodMetrics)/secs, float64(metrics), float64(periodRows)/secs, float64(rows),
+		float64(ps.Count)/secs, queries, ps.Mean, ps.P50, ps.P99, ps.Max)
+	return err
+}
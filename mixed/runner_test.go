@@ -0,0 +1,72 @@
+package mixed
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestWriteReportLine(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Unix(1500000000, 0)
+	ps := query.PeriodStats{Count: 20, Mean: 12.5, P50: 10, P99: 40.25, Max: 50}
+	err := writeReportLine(&buf, now, 2*time.Second, 1000, 5000, 100, 500, ps, 70)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "1500000000,500.00,5.000000E+03,50.00,5.000000E+02,10.00,70,12.50,10.00,40.25,50.00\n"
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect report line:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCheckDataFile(t *testing.T) {
+	// without -file, the loader reads data from stdin
+	if err := checkDataFile((&load.BenchmarkRunner{}).FileName()); err == nil {
+		t.Errorf("did not get error for data read from stdin")
+	}
+	if err := checkDataFile("/tmp/influx-data.gz"); err != nil {
+		t.Errorf("unexpected error for data read from a file: %v", err)
+	}
+}
+
+func TestWaitForMetricsLoadDone(t *testing.T) {
+	m := &BenchmarkRunner{loader: &load.BenchmarkRunner{}, startMetrics: 10}
+	loadDone := make(chan struct{})
+	waited := make(chan struct{})
+	go func() {
+		m.waitForMetrics(loadDone)
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatalf("stopped waiting before any metric was loaded")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// queries start anyway when the loader is done before the threshold
+	close(loadDone)
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Errorf("still waiting after the loader was done")
+	}
+}
+
+func TestWaitForMetricsThreshold(t *testing.T) {
+	m := &BenchmarkRunner{loader: &load.BenchmarkRunner{}, startMetrics: 0}
+	done := make(chan struct{})
+	go func() {
+		m.waitForMetrics(make(chan struct{}))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("still waiting after the threshold was reached")
+	}
+}
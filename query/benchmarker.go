@@ -9,6 +9,9 @@ import (
 	"runtime/pprof"
 	"sync"
 	"time"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/metrics"
 )
 
 const (
@@ -20,14 +23,27 @@ const (
 // BenchmarkRunner contains the common components for running a query benchmarking
 // program against a database.
 type BenchmarkRunner struct {
-	sp      *statProcessor
-	scanner *scanner
-	c       chan Query
+	sp         *statProcessor
+	scanner    *scanner
+	c          chan Query
+	sc         chan *scheduledQuery
+	dispatcher *dispatcher
+	errPolicy  *errorPolicy
 
 	dbName         string
 	workers        uint
 	limit          uint64
 	memProfile     string
+	summaryFile    string
+	percentiles    string
+	targetQPS      float64
+	arrival        string
+	maxLag         time.Duration
+	onError        string
+	maxRetries     uint
+	retryBackoff   time.Duration
+	timeout        time.Duration
+	metricsAddr    string
 	printResponses bool
 	debug          int
 }
@@ -35,20 +51,39 @@ type BenchmarkRunner struct {
 // NewBenchmarkRunner creates a new instance of BenchmarkRunner which is
 // common functionality to be used by query benchmarker programs
 func NewBenchmarkRunner() *BenchmarkRunner {
+	return NewBenchmarkRunnerWithPrefix("")
+}
+
+// NewBenchmarkRunnerWithPrefix creates a new instance of BenchmarkRunner
+// whose flags are all registered with the given prefix, so it can be used
+// in the same program as another runner with flags of the same name
+func NewBenchmarkRunnerWithPrefix(prefix string) *BenchmarkRunner {
 	ret := &BenchmarkRunner{}
 	ret.scanner = newScanner(&ret.limit)
 	ret.sp = &statProcessor{
 		limit: &ret.limit,
 	}
-	flag.StringVar(&ret.dbName, "db-name", "benchmark", "Name of database to use for queries")
-	flag.Uint64Var(&ret.sp.burnIn, "burn-in", 0, "Number of queries to ignore before collecting statistics.")
-	flag.Uint64Var(&ret.limit, "limit", 0, "Limit the number of queries to send, 0 = no limit")
-	flag.Uint64Var(&ret.sp.printInterval, "print-interval", 100, "Print timing stats to stderr after this many queries (0 to disable)")
-	flag.StringVar(&ret.memProfile, "memprofile", "", "Write a memory profile to this file.")
-	flag.UintVar(&ret.workers, "workers", 1, "Number of concurrent requests to make.")
-	flag.BoolVar(&ret.sp.prewarmQueries, "prewarm-queries", false, "Run each query twice in a row so the warm query is guaranteed to be a cache hit")
-	flag.BoolVar(&ret.printResponses, "print-responses", false, "Pretty print response bodies for correctness checking (default false).")
-	flag.IntVar(&ret.debug, "debug", 0, "Whether to print debug messages.")
+	flag.StringVar(&ret.dbName, prefix+"db-name", "benchmark", "Name of database to use for queries")
+	flag.Uint64Var(&ret.sp.burnIn, prefix+"burn-in", 0, "Number of queries to ignore before collecting statistics.")
+	flag.Uint64Var(&ret.limit, prefix+"limit", 0, "Limit the number of queries to send, 0 = no limit")
+	flag.Uint64Var(&ret.sp.printInterval, prefix+"print-interval", 100, "Print timing stats to stderr after this many queries (0 to disable)")
+	flag.StringVar(&ret.memProfile, prefix+"memprofile", "", "Write a memory profile to this file.")
+	flag.UintVar(&ret.workers, prefix+"workers", 1, "Number of concurrent requests to make.")
+	flag.BoolVar(&ret.sp.prewarmQueries, prefix+"prewarm-queries", false, "Run each query twice in a row so the warm query is guaranteed to be a cache hit")
+	flag.BoolVar(&ret.printResponses, prefix+"print-responses", false, "Pretty print response bodies for correctness checking (default false).")
+	flag.IntVar(&ret.debug, prefix+"debug", 0, "Whether to print debug messages.")
+	flag.StringVar(&ret.sp.resultsFile, prefix+"results-file", "", "Write the latency of every query to this file (empty to disable).")
+	flag.StringVar(&ret.sp.resultsFormat, prefix+"results-format", resultsFormatCSV, "Format of the results file (choices: csv, json). json writes one JSON object per line.")
+	flag.StringVar(&ret.percentiles, prefix+"percentiles", "50,90,95,99,99.9", "Comma-separated list of latency percentiles to report (empty to disable).")
+	flag.Float64Var(&ret.targetQPS, prefix+"target-qps", 0, "Send queries at this fixed rate (queries/sec) regardless of how fast they complete, with latencies measured from the intended send time. 0 means each worker sends its next query as soon as the previous one returns.")
+	flag.StringVar(&ret.arrival, prefix+"arrival", arrivalConstant, "Distribution of the time between queries when -target-qps is set (choices: constant, poisson).")
+	flag.DurationVar(&ret.maxLag, prefix+"max-lag", 0, "When -target-qps is set, drop queries that could not be started within this long of their intended send time (0 to never drop).")
+	flag.StringVar(&ret.onError, prefix+"on-error", errorPolicyAbort, "What to do when a query fails (choices: abort, retry, continue). retry runs the query again up to -max-retries times, continue records the failure and moves on to the next query.")
+	flag.UintVar(&ret.maxRetries, prefix+"max-retries", 3, "Number of times to retry a failed query when -on-error=retry, after which the failure is recorded.")
+	flag.DurationVar(&ret.retryBackoff, prefix+"retry-backoff", time.Second, "Time to wait before the first retry of a failed query, doubled for each further retry.")
+	flag.DurationVar(&ret.timeout, prefix+"timeout", 0, "Time after which a query is cancelled and fails as timed out, handled according to -on-error (0 means no timeout).")
+	flag.StringVar(&ret.metricsAddr, prefix+"metrics-addr", "", "Address to serve live query metrics on at /metrics in the Prometheus text format, e.g., ':9090' (empty to disable).")
+	flag.StringVar(&ret.summaryFile, prefix+"summary-file", "", "Write a JSON summary of the run with per label statistics to this file (empty to disable).")
 
 	return ret
 }
@@ -73,6 +108,18 @@ func (b *BenchmarkRunner) DatabaseName() string {
 	return b.dbName
 }
 
+// Timeout returns how long a query can run before it fails as timed out,
+// with 0 meaning no timeout
+func (b *BenchmarkRunner) Timeout() time.Duration {
+	return b.timeout
+}
+
+// TakePeriodStats returns statistics on the queries completed since the
+// previous call, starting a new period
+func (b *BenchmarkRunner) TakePeriodStats() PeriodStats {
+	return b.sp.takePeriod()
+}
+
 // ProcessorCreate is a function that creates a new Procesor (called in Run)
 type ProcessorCreate func() Processor
 
@@ -94,7 +141,31 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, createFn ProcessorCreate) {
 	if b.sp.burnIn > b.limit {
 		panic("burn-in is larger than limit")
 	}
+	percentiles, err := parsePercentiles(b.percentiles)
+	if err != nil {
+		panic(err)
+	}
+	b.sp.percentiles = percentiles
+	b.errPolicy, err = newErrorPolicy(b.onError, b.maxRetries, b.retryBackoff)
+	if err != nil {
+		panic(err)
+	}
+	if len(b.metricsAddr) > 0 {
+		r := metrics.NewRegistry()
+		b.sp.metrics = newQueryMetrics(r)
+		if err := metrics.Serve(b.metricsAddr, r); err != nil {
+			log.Fatalf("could not serve metrics: %v", err)
+		}
+	}
 	b.c = make(chan Query, b.workers)
+	if b.targetQPS > 0 {
+		b.dispatcher, err = newDispatcher(b.targetQPS, b.arrival)
+		if err != nil {
+			panic(err)
+		}
+		// unbuffered, so a query is only sent when a worker is ready for it
+		b.sc = make(chan *scheduledQuery)
+	}
 
 	// Launch the stats processor:
 	go b.sp.process(b.workers)
@@ -103,14 +174,27 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, createFn ProcessorCreate) {
 	var wg sync.WaitGroup
 	for i := 0; i < int(b.workers); i++ {
 		wg.Add(1)
-		go b.processorHandler(&wg, queryPool, createFn(), i)
+		if b.dispatcher != nil {
+			go b.scheduledProcessorHandler(&wg, queryPool, createFn(), i)
+		} else {
+			go b.processorHandler(&wg, queryPool, createFn(), i)
+		}
 	}
 
 	// Read in jobs, closing the job channel when done:
 	input := bufio.NewReaderSize(os.Stdin, 1<<20)
 	wallStart := time.Now()
-	b.scanner.setReader(input).scan(queryPool, b.c)
-	close(b.c)
+	if b.dispatcher != nil {
+		go func() {
+			b.scanner.setReader(input).scan(queryPool, b.c)
+			close(b.c)
+		}()
+		b.dispatcher.run(b.c, b.sc)
+		close(b.sc)
+	} else {
+		b.scanner.setReader(input).scan(queryPool, b.c)
+		close(b.c)
+	}
 
 	// Block for workers to finish sending requests, closing the stats
 	// channel when done:
@@ -119,11 +203,39 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, createFn ProcessorCreate) {
 
 	wallEnd := time.Now()
 	wallTook := wallEnd.Sub(wallStart)
-	_, err := fmt.Printf("wall clock time: %fsec\n", float64(wallTook.Nanoseconds())/1e9)
+	if b.dispatcher != nil {
+		if err = b.dispatcher.write(os.Stdout, wallTook); err != nil {
+			log.Fatal(err)
+		}
+	}
+	_, err = fmt.Printf("wall clock time: %fsec\n", float64(wallTook.Nanoseconds())/1e9)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// (Optional) write a summary of the run:
+	if len(b.summaryFile) > 0 {
+		f, err := os.Create(b.summaryFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		summary := newRunSummary(b.sp.statGroups, b.sp.percentiles, b.workers, b.sp.count, wallTook)
+		if b.dispatcher != nil {
+			summary.OpenLoop = &dispatchSummary{
+				TargetQPS: b.targetQPS,
+				Arrival:   b.arrival,
+				Sent:      b.dispatcher.sent,
+				Late:      b.dispatcher.late,
+				Dropped:   b.dispatcher.dropped,
+			}
+		}
+		err = writeSummary(f, summary)
+		if err != nil {
+			log.Fatal(err)
+		}
+		f.Close()
+	}
+
 	// (Optional) create a memory profile:
 	if len(b.memProfile) > 0 {
 		f, err := os.Create(b.memProfile)
@@ -138,25 +250,81 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, createFn ProcessorCreate) {
 func (b *BenchmarkRunner) processorHandler(wg *sync.WaitGroup, qPool *sync.Pool, p Processor, workerNum int) {
 	p.Init(workerNum)
 	for q := range b.c {
-		//p.ProcessQuery(b.sp, q)
-		stats, err := p.ProcessQuery(q, false)
-		if err != nil {
+		b.processQuery(p, q, workerNum, time.Now(), 0)
+		qPool.Put(q)
+	}
+	wg.Done()
+}
+
+// scheduledProcessorHandler is the processorHandler for open-loop mode, where
+// the time a query waited past its intended send time counts towards its latency
+func (b *BenchmarkRunner) scheduledProcessorHandler(wg *sync.WaitGroup, qPool *sync.Pool, p Processor, workerNum int) {
+	p.Init(workerNum)
+	for sq := range b.sc {
+		lag := time.Since(sq.intended)
+		if !b.dispatcher.isDropped(lag, b.maxLag) {
+			b.processQuery(p, sq.q, workerNum, sq.intended, float64(lag.Nanoseconds())/1e6)
+		}
+		qPool.Put(sq.q)
+	}
+	wg.Done()
+}
+
+// processQuery runs a query on p and sends its stats to the stat processor.
+// The query is considered to have started at start, wait milliseconds before
+// it was actually run.
+func (b *BenchmarkRunner) processQuery(p Processor, q Query, workerNum int, start time.Time, wait float64) {
+	stats := b.runQuery(p, q, false)
+	setQueryDetails(stats, q, workerNum, start, wait)
+	b.sp.sendStats(stats)
+
+	// If PrewarmQueries is set, we run the query as 'cold' first (see above),
+	// then we immediately run it a second time and report that as the 'warm'
+	// stat. This guarantees that the warm stat will reflect optimal cache performance.
+	if b.sp.prewarmQueries {
+		// Warm run
+		start = time.Now()
+		stats = b.runQuery(p, q, true)
+		setQueryDetails(stats, q, workerNum, start, 0)
+		b.sp.sendStatsWarm(stats)
+	}
+}
+
+// runQuery runs q on p, handling failures according to the error policy:
+// it panics, retries the query, or returns a Stat recording the failure. A
+// runner without an error policy aborts on failures.
+func (b *BenchmarkRunner) runQuery(p Processor, q Query, isWarm bool) []*Stat {
+	for attempt := uint(0); ; attempt++ {
+		stats, err := p.ProcessQuery(q, isWarm)
+		if err == nil {
+			return stats
+		}
+		if b.errPolicy == nil || b.errPolicy.policy == errorPolicyAbort {
 			panic(err)
 		}
-		b.sp.sendStats(stats)
-
-		// If PrewarmQueries is set, we run the query as 'cold' first (see above),
-		// then we immediately run it a second time and report that as the 'warm'
-		// stat. This guarantees that the warm stat will reflect optimal cache performance.
-		if b.sp.prewarmQueries {
-			// Warm run
-			stats, err = p.ProcessQuery(q, true)
-			if err != nil {
-				panic(err)
-			}
-			b.sp.sendStatsWarm(stats)
+		for _, s := range stats {
+			statPool.Put(s)
+		}
+		if b.debug > 0 {
+			log.Printf("query %d (%s) failed on attempt %d: %v", q.GetID(), q.HumanLabelName(), attempt+1, err)
+		}
+		wait, retry := b.errPolicy.retryWait(attempt)
+		if !retry {
+			return []*Stat{getErrorStat(q.HumanLabelName(), load.IsTimeout(err))}
+		}
+		time.Sleep(wait)
+	}
+}
+
+// setQueryDetails records which query, worker and start time the stats
+// belong to, adding the time the query waited to be run to their latency
+func setQueryDetails(stats []*Stat, q Query, workerNum int, start time.Time, wait float64) {
+	for _, s := range stats {
+		s.queryID = q.GetID()
+		s.workerNum = workerNum
+		s.startTime = start
+		if !s.isPartial {
+			s.value += wait
 		}
-		qPool.Put(q)
 	}
-	wg.Done()
 }
@@ -1,8 +1,14 @@
 package query
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 )
 
 type testProcessor struct {
@@ -52,3 +58,132 @@ func TestProcessorHandler(t *testing.T) {
 		t.Errorf("total queries wrong: want %d got %d", qLimit, p1.count+p2.count)
 	}
 }
+
+func TestNewBenchmarkRunnerWithPrefix(t *testing.T) {
+	b := NewBenchmarkRunnerWithPrefix("prefix-")
+	for _, name := range []string{"prefix-workers", "prefix-limit", "prefix-db-name"} {
+		if flag.Lookup(name) == nil {
+			t.Errorf("flag %s not registered", name)
+		}
+	}
+	if err := flag.Set("prefix-limit", "12"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.limit != 12 {
+		t.Errorf("prefixed flag not bound to runner: got limit %d want %d", b.limit, 12)
+	}
+}
+
+// failingProcessor fails the first failures queries it runs
+type failingProcessor struct {
+	failures int
+	calls    int
+	err      error
+}
+
+func (p *failingProcessor) Init(_ int) {}
+
+func (p *failingProcessor) ProcessQuery(q Query, _ bool) ([]*Stat, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return nil, p.err
+	}
+	return []*Stat{GetStat().Init(q.HumanLabelName(), 1)}, nil
+}
+
+func TestRunQueryRetry(t *testing.T) {
+	ep, _ := newErrorPolicy(errorPolicyRetry, 2, time.Millisecond)
+	b := &BenchmarkRunner{errPolicy: ep}
+
+	p := &failingProcessor{failures: 2, err: errors.New("failed")}
+	stats := b.runQuery(p, &testQuery{}, false)
+	if p.calls != 3 {
+		t.Errorf("incorrect number of attempts: got %d want %d", p.calls, 3)
+	}
+	if len(stats) != 1 || stats[0].isError {
+		t.Errorf("query not successful after retries: %v", stats)
+	}
+
+	// out of retries, the failure is recorded
+	p = &failingProcessor{failures: 3, err: errors.New("failed")}
+	stats = b.runQuery(p, &testQuery{}, false)
+	if p.calls != 3 {
+		t.Errorf("incorrect number of attempts: got %d want %d", p.calls, 3)
+	}
+	if len(stats) != 1 || !stats[0].isError || stats[0].isTimeout {
+		t.Errorf("failure not recorded after retries: %v", stats)
+	}
+}
+
+func TestRunQueryContinue(t *testing.T) {
+	ep, _ := newErrorPolicy(errorPolicyContinue, 2, time.Millisecond)
+	b := &BenchmarkRunner{errPolicy: ep}
+	p := &failingProcessor{failures: 1, err: context.DeadlineExceeded}
+	stats := b.runQuery(p, &testQuery{}, false)
+	if p.calls != 1 {
+		t.Errorf("query retried with continue policy: got %d attempts", p.calls)
+	}
+	if len(stats) != 1 || !stats[0].isError || !stats[0].isTimeout {
+		t.Errorf("timeout not recorded: %v", stats)
+	}
+	if got := string(stats[0].label); got != "test" {
+		t.Errorf("incorrect label: got %s want %s", got, "test")
+	}
+}
+
+// httpProcessor sends queries to url with a client using the timeout of runner,
+// like the processors of the HTTP based databases
+type httpProcessor struct {
+	runner *BenchmarkRunner
+	url    string
+	client *http.Client
+}
+
+func (p *httpProcessor) Init(_ int) {
+	p.client = &http.Client{Timeout: p.runner.Timeout()}
+}
+
+func (p *httpProcessor) ProcessQuery(q Query, _ bool) ([]*Stat, error) {
+	resp, err := p.client.Get(p.url)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return []*Stat{GetStat().Init(q.HumanLabelName(), 1)}, nil
+}
+
+func TestRunQueryTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	b := NewBenchmarkRunnerWithPrefix("timeout-test-")
+	if err := flag.Set("timeout-test-timeout", "10ms"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.errPolicy, _ = newErrorPolicy(errorPolicyContinue, 2, time.Millisecond)
+
+	p := &httpProcessor{runner: b, url: srv.URL}
+	p.Init(0)
+	stats := b.runQuery(p, &testQuery{}, false)
+	if len(stats) != 1 || !stats[0].isError || !stats[0].isTimeout {
+		t.Errorf("timeout not recorded for slow server: %v", stats)
+	}
+}
+
+func TestRunQueryAbort(t *testing.T) {
+	ep, _ := newErrorPolicy(errorPolicyAbort, 2, time.Millisecond)
+	b := &BenchmarkRunner{errPolicy: ep}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("did not panic on failure with abort policy")
+		}
+	}()
+	b.runQuery(&failingProcessor{failures: 1, err: errors.New("failed")}, &testQuery{}, false)
+}
@@ -0,0 +1,115 @@
+// Package cassandra runs benchmark queries against Cassandra. It is a 'heavy
+// client', i.e. it builds a client-side index of table metadata before
+// running any query. It is used both by tsbs_run_queries_cassandra and by the
+// mixed mode of tsbs_load_cassandra.
+package cassandra
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/hagen1778/tsbs/query"
+)
+
+const (
+	BucketDuration   = 24 * time.Hour
+	BucketTimeLayout = "2006-01-02"
+)
+
+// Blessed tables that hold benchmark data:
+var (
+	BlessedTables = []string{
+		"series_bigint",
+		"series_float",
+		"series_double",
+		"series_boolean",
+		"series_blob",
+	}
+)
+
+// AggrPlanChoices maps the names of the aggregation plans to their types
+var AggrPlanChoices = map[string]int{
+	"server": AggrPlanTypeWithServerAggregation,
+	"client": AggrPlanTypeWithoutServerAggregation,
+}
+
+// Config holds the options for running queries against Cassandra
+type Config struct {
+	// DaemonURL is the Cassandra hostname and port combination
+	DaemonURL string
+	// AggrPlan is one of the AggrPlanType values
+	AggrPlan int
+	// RequestTimeout is the maximum request timeout
+	RequestTimeout time.Duration
+	// CSITimeout is the maximum timeout when building the client-side index
+	CSITimeout time.Duration
+}
+
+type processor struct {
+	runner  *query.BenchmarkRunner
+	c       *Config
+	session *gocql.Session
+	csi     *ClientSideIndex
+	qe      *HLQueryExecutor
+	opts    *HLQueryExecutorDoOptions
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against Cassandra as configured by c. The client-side
+// index and the connection pool shared by all Processors are made when the
+// first one is created.
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	var once sync.Once
+	var session *gocql.Session
+	var csi *ClientSideIndex
+	return func() query.Processor {
+		once.Do(func() {
+			// Make client-side index:
+			session = NewCassandraSession(c.DaemonURL, runner.DatabaseName(), c.CSITimeout)
+			csi = NewClientSideIndex(FetchSeriesCollection(session))
+			session.Close()
+
+			// Make database connection pool:
+			session = NewCassandraSession(c.DaemonURL, runner.DatabaseName(), c.RequestTimeout)
+		})
+		return &processor{runner: runner, c: c, session: session, csi: csi}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.opts = &HLQueryExecutorDoOptions{
+		AggregationPlan:      p.c.AggrPlan,
+		Debug:                p.runner.DebugLevel(),
+		PrettyPrintResponses: p.runner.DoPrintResponses(),
+	}
+	p.qe = NewHLQueryExecutor(p.session, p.csi, p.runner.DebugLevel())
+}
+
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	cq := q.(*query.Cassandra)
+	hlq := &HLQuery{*cq}
+	hlq.ForceUTC()
+	labels := [][]byte{
+		q.HumanLabelName(),
+		append(q.HumanLabelName(), "-qp"...),
+		append(q.HumanLabelName(), "-req"...),
+	}
+	if isWarm {
+		for i, l := range labels {
+			labels[i] = append(l, " (warm)"...)
+		}
+	}
+	qpLagMs, reqLagMs, err := p.qe.Do(hlq, *p.opts)
+	if err != nil {
+		return nil, err
+	}
+	// total stat
+	totalMs := qpLagMs + reqLagMs
+	stats := []*query.Stat{
+		query.GetPartialStat().Init(labels[1], qpLagMs),
+		query.GetPartialStat().Init(labels[2], reqLagMs),
+		query.GetStat().Init(labels[0], totalMs),
+	}
+	return stats, nil
+}
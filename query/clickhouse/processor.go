@@ -0,0 +1,101 @@
+// Package clickhouse runs benchmark queries against the HTTP interface of
+// ClickHouse. It is used both by tsbs_run_queries_clickhouse and by the mixed
+// mode of tsbs_load_clickhouse.
+package clickhouse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Config holds the options for running queries against ClickHouse
+type Config struct {
+	// URLs are used in a round-robin fashion by the workers
+	URLs []string
+	// User and Password authenticate the queries, if User is set
+	User     string
+	Password string
+}
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	c      *Config
+	url    string
+	client *http.Client
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against ClickHouse as configured by c
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, c: c}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.url = p.c.URLs[workerNumber%len(p.c.URLs)]
+	p.client = &http.Client{Timeout: p.runner.Timeout()}
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+
+	// populate a request with data from the Query, the SQL being its body:
+	u := p.url + string(hq.Path) + "?database=" + url.QueryEscape(p.runner.DatabaseName())
+	req, err := http.NewRequest(string(hq.Method), u, bytes.NewReader(hq.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	SetAuth(req, p.c.User, p.c.Password)
+
+	// Perform the request while tracking latency:
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("non-200 statuscode received: %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	var body bytes.Buffer
+	if _, err := io.Copy(&body, resp.Body); err != nil {
+		return nil, fmt.Errorf("error while reading response body: %w", err)
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	switch p.runner.DebugLevel() {
+	case 0:
+	case 1:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms\n", hq.HumanLabel, lag)
+	default:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms -- %s\n", hq.HumanLabel, lag, hq.HumanDescription)
+		fmt.Fprintf(os.Stderr, "debug:   request: %s\n", hq.Body)
+	}
+	if p.runner.DoPrintResponses() {
+		fmt.Fprintf(os.Stderr, "ID %d: %s\n", q.GetID(), body.Bytes())
+	}
+
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, nil
+}
+
+// SetAuth sets the headers authenticating req as user, if it is not empty
+func SetAuth(req *http.Request, user, password string) {
+	if user == "" {
+		return
+	}
+	req.Header.Set("X-ClickHouse-User", user)
+	req.Header.Set("X-ClickHouse-Key", password)
+}
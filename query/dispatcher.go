@@ -0,0 +1,100 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	arrivalConstant = "constant"
+	arrivalPoisson  = "poisson"
+)
+
+// scheduledQuery is a Query along with the time it was meant to be sent at
+// when running in open-loop mode
+type scheduledQuery struct {
+	q        Query
+	intended time.Time
+}
+
+// dispatcher sends queries to workers at a fixed arrival rate regardless of
+// how long previous queries took (open-loop), as opposed to workers pulling
+// the next query as soon as they are done (closed-loop). This avoids hiding
+// latency spikes behind a lower send rate, i.e., coordinated omission.
+type dispatcher struct {
+	interval time.Duration // interval is the mean time between two queries
+	arrival  string        // arrival is the distribution of inter-arrival times
+	rand     *rand.Rand
+
+	sent    uint64 // sent is the number of queries handed to workers
+	late    uint64 // late is the number of queries no worker was free for at their intended time
+	dropped uint64 // dropped is the number of queries not run because they were too late
+}
+
+// newDispatcher returns a dispatcher sending qps queries per second with
+// inter-arrival times following the given distribution
+func newDispatcher(qps float64, arrival string) (*dispatcher, error) {
+	if qps <= 0 {
+		return nil, fmt.Errorf("target rate must be positive: %v", qps)
+	}
+	if arrival != arrivalConstant && arrival != arrivalPoisson {
+		return nil, fmt.Errorf("unknown arrival distribution: %s", arrival)
+	}
+	return &dispatcher{
+		interval: time.Duration(float64(time.Second) / qps),
+		arrival:  arrival,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// nextInterval returns the time to wait between the previous query and the
+// next one
+func (d *dispatcher) nextInterval() time.Duration {
+	if d.arrival == arrivalPoisson {
+		// a Poisson process has exponentially distributed inter-arrival times
+		return time.Duration(d.rand.ExpFloat64() * float64(d.interval))
+	}
+	return d.interval
+}
+
+// run reads queries from in and sends them on out according to the schedule
+// until in is closed. If no worker is ready to receive a query at its intended
+// time, it is counted as late and sent as soon as a worker is ready; the
+// following queries keep their original schedule.
+func (d *dispatcher) run(in <-chan Query, out chan<- *scheduledQuery) {
+	next := time.Now()
+	for q := range in {
+		if wait := time.Until(next); wait > 0 {
+			time.Sleep(wait)
+		}
+		sq := &scheduledQuery{q: q, intended: next}
+		select {
+		case out <- sq:
+		default:
+			atomic.AddUint64(&d.late, 1)
+			out <- sq
+		}
+		atomic.AddUint64(&d.sent, 1)
+		next = next.Add(d.nextInterval())
+	}
+}
+
+// isDropped reports whether a query waiting for lag should not be run at all,
+// counting it if so. A maxLag of 0 means queries are never dropped.
+func (d *dispatcher) isDropped(lag, maxLag time.Duration) bool {
+	if maxLag > 0 && lag > maxLag {
+		atomic.AddUint64(&d.dropped, 1)
+		return true
+	}
+	return false
+}
+
+// write prints a summary of the dispatching for a run that took wallTook
+func (d *dispatcher) write(w io.Writer, wallTook time.Duration) error {
+	_, err := fmt.Fprintf(w, "open-loop: target rate %0.2f queries/sec (%s), sent %d queries (%0.2f queries/sec), late: %d, dropped: %d\n",
+		float64(time.Second)/float64(d.interval), d.arrival, d.sent, float64(d.sent)/wallTook.Seconds(), d.late, d.dropped)
+	return err
+}
@@ -0,0 +1,181 @@
+package query
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewDispatcher(t *testing.T) {
+	cases := []struct {
+		desc         string
+		qps          float64
+		arrival      string
+		wantInterval time.Duration
+		shouldErr    bool
+	}{
+		{
+			desc:         "constant",
+			qps:          100,
+			arrival:      arrivalConstant,
+			wantInterval: 10 * time.Millisecond,
+		},
+		{
+			desc:         "poisson",
+			qps:          0.5,
+			arrival:      arrivalPoisson,
+			wantInterval: 2 * time.Second,
+		},
+		{
+			desc:      "zero rate",
+			qps:       0,
+			arrival:   arrivalConstant,
+			shouldErr: true,
+		},
+		{
+			desc:      "unknown arrival",
+			qps:       10,
+			arrival:   "bursty",
+			shouldErr: true,
+		},
+	}
+	for _, c := range cases {
+		d, err := newDispatcher(c.qps, c.arrival)
+		if c.shouldErr {
+			if err == nil {
+				t.Errorf("%s: did not get error when expected", c.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+			continue
+		}
+		if d.interval != c.wantInterval {
+			t.Errorf("%s: incorrect interval: got %v want %v", c.desc, d.interval, c.wantInterval)
+		}
+	}
+}
+
+func TestDispatcherNextInterval(t *testing.T) {
+	d, _ := newDispatcher(1000, arrivalConstant)
+	for i := 0; i < 10; i++ {
+		if got := d.nextInterval(); got != time.Millisecond {
+			t.Fatalf("incorrect constant interval: got %v want %v", got, time.Millisecond)
+		}
+	}
+
+	d, _ = newDispatcher(1000, arrivalPoisson)
+	n := 100000
+	sum := time.Duration(0)
+	for i := 0; i < n; i++ {
+		got := d.nextInterval()
+		if got < 0 {
+			t.Fatalf("negative poisson interval: %v", got)
+		}
+		sum += got
+	}
+	mean := float64(sum) / float64(n)
+	if math.Abs(mean-float64(time.Millisecond)) > 0.05*float64(time.Millisecond) {
+		t.Errorf("poisson intervals have incorrect mean: got %v want %v", time.Duration(mean), time.Millisecond)
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	d, _ := newDispatcher(200, arrivalConstant)
+	numQueries := 10
+	in := make(chan Query, numQueries)
+	for i := 0; i < numQueries; i++ {
+		in <- &testQuery{ID: uint64(i)}
+	}
+	close(in)
+
+	out := make(chan *scheduledQuery)
+	done := make(chan []*scheduledQuery)
+	go func() {
+		received := []*scheduledQuery{}
+		for sq := range out {
+			received = append(received, sq)
+			// a slow worker for the first query makes the next ones late
+			if len(received) == 1 {
+				time.Sleep(5 * d.interval)
+			}
+		}
+		done <- received
+	}()
+	d.run(in, out)
+	close(out)
+	received := <-done
+
+	if len(received) != numQueries {
+		t.Fatalf("incorrect number of queries: got %d want %d", len(received), numQueries)
+	}
+	if d.sent != uint64(numQueries) {
+		t.Errorf("incorrect sent count: got %d want %d", d.sent, numQueries)
+	}
+	if d.late == 0 {
+		t.Errorf("no late queries counted despite slow worker")
+	}
+	for i, sq := range received {
+		if got := sq.q.GetID(); got != uint64(i) {
+			t.Errorf("query out of order: got id %d want %d", got, i)
+		}
+		// late queries keep their schedule rather than shifting the next ones
+		if i > 0 {
+			if got := sq.intended.Sub(received[i-1].intended); got != d.interval {
+				t.Errorf("incorrect time between intended sends: got %v want %v", got, d.interval)
+			}
+		}
+	}
+}
+
+func TestDispatcherIsDropped(t *testing.T) {
+	d, _ := newDispatcher(10, arrivalConstant)
+	if d.isDropped(time.Hour, 0) {
+		t.Errorf("query dropped with no max lag")
+	}
+	if d.isDropped(time.Millisecond, time.Second) {
+		t.Errorf("query dropped with lag below max lag")
+	}
+	if !d.isDropped(2*time.Second, time.Second) {
+		t.Errorf("query not dropped with lag above max lag")
+	}
+	if d.dropped != 1 {
+		t.Errorf("incorrect dropped count: got %d want %d", d.dropped, 1)
+	}
+}
+
+func TestDispatcherWrite(t *testing.T) {
+	d, _ := newDispatcher(10, arrivalPoisson)
+	d.sent = 20
+	d.late = 3
+	d.dropped = 1
+	var buf bytes.Buffer
+	if err := d.write(&buf, 4*time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "open-loop: target rate 10.00 queries/sec (poisson), sent 20 queries (5.00 queries/sec), late: 3, dropped: 1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSetQueryDetailsWait(t *testing.T) {
+	full := GetStat().Init([]byte("full"), 10)
+	partial := GetPartialStat().Init([]byte("partial"), 4)
+	start := time.Now()
+	setQueryDetails([]*Stat{full, partial}, &testQuery{ID: 7}, 2, start, 5)
+
+	if full.value != 15 {
+		t.Errorf("wait not added to latency: got %v want %v", full.value, 15)
+	}
+	if partial.value != 4 {
+		t.Errorf("wait added to partial latency: got %v want %v", partial.value, 4)
+	}
+	for _, s := range []*Stat{full, partial} {
+		if s.queryID != 7 || s.workerNum != 2 || !s.startTime.Equal(start) {
+			t.Errorf("%s: incorrect details: got id %d, worker %d, start %v", s.label, s.queryID, s.workerNum, s.startTime)
+		}
+	}
+}
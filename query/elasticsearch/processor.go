@@ -0,0 +1,112 @@
+// Package elasticsearch runs benchmark queries against the search API of
+// Elasticsearch or OpenSearch. It is used both by tsbs_run_queries_elasticsearch
+// and by the mixed mode of tsbs_load_elasticsearch.
+//
+// The path of the queries starts with the index pattern they search, which
+// is prefixed with the database name, e.g., /cpu-*/_search searches the
+// indices matching benchmark-cpu-* of the database benchmark.
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Config holds the options for running queries against Elasticsearch
+type Config struct {
+	// URLs are used in a round-robin fashion by the workers
+	URLs []string
+}
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	c      *Config
+	url    string
+	client *http.Client
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against Elasticsearch as configured by c
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, c: c}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.url = p.c.URLs[workerNumber%len(p.c.URLs)]
+	p.client = &http.Client{Timeout: p.runner.Timeout()}
+}
+
+// searchResponse is the part of a search response the processor reads
+type searchResponse struct {
+	// Took is the time the search took on the server, in milliseconds
+	Took *float64 `json:"took"`
+}
+
+// ProcessQuery runs a query and returns its client-side latency, as well as
+// the time the server reports it took as a partial Stat labeled with a
+// "-took" suffix.
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+
+	// populate a request with data from the Query, searching the indices of
+	// the database:
+	u := p.url + "/" + p.runner.DatabaseName() + "-" + strings.TrimPrefix(string(hq.Path), "/")
+	req, err := http.NewRequest(string(hq.Method), u, bytes.NewReader(hq.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the request while tracking latency:
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 statuscode received: %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	var sr searchResponse
+	if err := json.Unmarshal(body, &sr); err != nil {
+		return nil, fmt.Errorf("error while decoding response: %s", err)
+	}
+	if sr.Took == nil {
+		return nil, fmt.Errorf("response has no 'took': %s", body)
+	}
+	took := *sr.Took
+
+	switch p.runner.DebugLevel() {
+	case 0:
+	case 1:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms (took %7.2fms)\n", hq.HumanLabel, lag, took)
+	default:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms (took %7.2fms) -- %s\n", hq.HumanLabel, lag, took, hq.HumanDescription)
+		fmt.Fprintf(os.Stderr, "debug:   request: %s %s\n", u, hq.Body)
+	}
+	if p.runner.DoPrintResponses() {
+		fmt.Fprintf(os.Stderr, "ID %d: %s\n", q.GetID(), body)
+	}
+
+	stats := []*query.Stat{
+		query.GetPartialStat().Init(append(q.HumanLabelName(), "-took"...), took),
+		query.GetStat().Init(q.HumanLabelName(), lag),
+	}
+	return stats, nil
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	errorPolicyAbort    = "abort"
+	errorPolicyRetry    = "retry"
+	errorPolicyContinue = "continue"
+)
+
+// errorPolicy decides what happens when a query fails: abort the whole run,
+// retry the query a number of times with exponential backoff, or record the
+// failure and continue with the next query. Failures that are out of retries
+// are recorded as well.
+type errorPolicy struct {
+	policy     string
+	maxRetries uint
+	backoff    time.Duration // backoff is the wait before the first retry, doubled for each retry
+}
+
+// newErrorPolicy returns the errorPolicy with the given name
+func newErrorPolicy(policy string, maxRetries uint, backoff time.Duration) (*errorPolicy, error) {
+	switch policy {
+	case errorPolicyAbort, errorPolicyRetry, errorPolicyContinue:
+	default:
+		return nil, fmt.Errorf("unknown error policy: %s", policy)
+	}
+	return &errorPolicy{
+		policy:     policy,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}, nil
+}
+
+// retryWait returns whether a query that failed attempt+1 times should be
+// retried, and how long to wait before doing so
+func (ep *errorPolicy) retryWait(attempt uint) (time.Duration, bool) {
+	if ep.policy != errorPolicyRetry || attempt >= ep.maxRetries {
+		return 0, false
+	}
+	return ep.backoff << attempt, true
+}
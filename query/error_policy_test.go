@@ -0,0 +1,39 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewErrorPolicy(t *testing.T) {
+	for _, policy := range []string{errorPolicyAbort, errorPolicyRetry, errorPolicyContinue} {
+		if _, err := newErrorPolicy(policy, 1, time.Second); err != nil {
+			t.Errorf("%s: unexpected error: %v", policy, err)
+		}
+	}
+	if _, err := newErrorPolicy("ignore", 1, time.Second); err == nil {
+		t.Errorf("did not get error for unknown policy")
+	}
+}
+
+func TestErrorPolicyRetryWait(t *testing.T) {
+	ep, _ := newErrorPolicy(errorPolicyRetry, 3, 100*time.Millisecond)
+	wants := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	for attempt, want := range wants {
+		got, ok := ep.retryWait(uint(attempt))
+		if !ok {
+			t.Errorf("attempt %d: not retried", attempt)
+		}
+		if got != want {
+			t.Errorf("attempt %d: incorrect wait: got %v want %v", attempt, got, want)
+		}
+	}
+	if _, ok := ep.retryWait(3); ok {
+		t.Errorf("retried after max retries")
+	}
+
+	ep, _ = newErrorPolicy(errorPolicyContinue, 3, time.Second)
+	if _, ok := ep.retryWait(0); ok {
+		t.Errorf("retried with continue policy")
+	}
+}
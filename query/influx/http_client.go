@@ -1,4 +1,4 @@
-package main
+package influx
 
 import (
 	"bufio"
@@ -32,10 +32,11 @@ type HTTPClientDoOptions struct {
 	database             string
 }
 
-// NewHTTPClient creates a new HTTPClient.
-func NewHTTPClient(host string) *HTTPClient {
+// NewHTTPClient creates a new HTTPClient whose requests fail after timeout,
+// 0 meaning no timeout.
+func NewHTTPClient(host string, timeout time.Duration) *HTTPClient {
 	return &HTTPClient{
-		client:     http.Client{},
+		client:     http.Client{Timeout: timeout},
 		Host:       []byte(host),
 		HostString: host,
 		uri:        []byte{}, // heap optimization
@@ -59,18 +60,18 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 	// populate a request with data from the Query:
 	req, err := http.NewRequest(string(q.Method), string(w.uri), nil)
 	if err != nil {
-		panic(err)
+		return 0, fmt.Errorf("error while creating new request: %s", err)
 	}
 
 	// Perform the request while tracking latency:
 	start := time.Now()
 	resp, err := w.client.Do(req)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		panic("http request did not return status 200 OK")
+		return 0, fmt.Errorf("non-200 statuscode received: %d", resp.StatusCode)
 	}
 
 	reader := bufio.NewReader(resp.Body)
@@ -81,7 +82,7 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 			err = nil
 			break
 		} else if err != nil {
-			panic(err)
+			return 0, err
 		}
 	}
 	lag = float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
@@ -0,0 +1,52 @@
+// Package influx runs benchmark queries against InfluxDB. It is used both by
+// tsbs_run_queries_influx and by the mixed mode of tsbs_load_influx.
+package influx
+
+import (
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Config holds the options for running queries against InfluxDB
+type Config struct {
+	// DaemonURLs are used in a round-robin fashion by the workers
+	DaemonURLs []string
+	// ChunkSize is the number of series to chunk results into, 0 means no chunking
+	ChunkSize uint64
+}
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	c      *Config
+	w      *HTTPClient
+	opts   *HTTPClientDoOptions
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against InfluxDB as configured by c
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, c: c}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.opts = &HTTPClientDoOptions{
+		Debug:                p.runner.DebugLevel(),
+		PrettyPrintResponses: p.runner.DoPrintResponses(),
+		chunkSize:            p.c.ChunkSize,
+		database:             p.runner.DatabaseName(),
+	}
+	url := p.c.DaemonURLs[workerNumber%len(p.c.DaemonURLs)]
+	p.w = NewHTTPClient(url, p.runner.Timeout())
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, err := p.w.Do(hq, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, nil
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"strconv"
+
+	"github.com/hagen1778/tsbs/metrics"
+)
+
+// queryMetrics exposes the queries completed and their latencies, by query
+// label and worker, while the benchmark runs
+type queryMetrics struct {
+	completed *metrics.CounterVec
+	errors    *metrics.CounterVec
+	timeouts  *metrics.CounterVec
+	latencies *metrics.HistogramVec
+}
+
+// newQueryMetrics returns a queryMetrics whose metrics are added to r
+func newQueryMetrics(r *metrics.Registry) *queryMetrics {
+	return &queryMetrics{
+		completed: r.NewCounterVec("tsbs_queries_total", "Number of queries completed.", "label"),
+		errors:    r.NewCounterVec("tsbs_query_errors_total", "Number of queries that failed.", "label"),
+		timeouts:  r.NewCounterVec("tsbs_query_timeouts_total", "Number of queries that failed with a timeout.", "label"),
+		latencies: r.NewHistogramVec("tsbs_query_duration_seconds", "Latency of completed queries.",
+			metrics.LatencyBuckets, "label", "worker"),
+	}
+}
+
+// observe records a query Stat. Partial stats only make up part of a query,
+// so they are not counted.
+func (m *queryMetrics) observe(stat *Stat) {
+	if stat.isPartial {
+		return
+	}
+	label := string(stat.label)
+	if stat.isError {
+		m.errors.With(label).Inc()
+		if stat.isTimeout {
+			m.timeouts.With(label).Inc()
+		}
+		return
+	}
+	m.completed.With(label).Inc()
+	m.latencies.With(label, strconv.Itoa(stat.workerNum)).Observe(stat.value / 1e3)
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hagen1778/tsbs/metrics"
+)
+
+func TestQueryMetricsObserve(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := newQueryMetrics(r)
+
+	s := GetStat().Init([]byte("q1"), 20)
+	s.workerNum = 1
+	m.observe(s)
+	m.observe(GetPartialStat().Init([]byte("q1 part"), 10))
+	m.observe(getErrorStat([]byte("q1"), true))
+	m.observe(getErrorStat([]byte("q2"), false))
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"tsbs_queries_total{label=\"q1\"} 1\n",
+		"tsbs_query_errors_total{label=\"q1\"} 1\n",
+		"tsbs_query_errors_total{label=\"q2\"} 1\n",
+		"tsbs_query_timeouts_total{label=\"q1\"} 1\n",
+		"tsbs_query_duration_seconds_bucket{label=\"q1\",worker=\"1\",le=\"0.025\"} 1\n",
+		"tsbs_query_duration_seconds_sum{label=\"q1\",worker=\"1\"} 0.02\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "q1 part") {
+		t.Errorf("partial stat was counted:\n%s", out)
+	}
+	if strings.Contains(out, "tsbs_query_timeouts_total{label=\"q2\"}") {
+		t.Errorf("non-timeout error was counted as a timeout:\n%s", out)
+	}
+}
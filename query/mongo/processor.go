@@ -0,0 +1,73 @@
+// Package mongo runs benchmark queries against MongoDB using mgo. It is used
+// both by tsbs_run_queries_mongo and by the mixed mode of tsbs_load_mongo.
+package mongo
+
+import (
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/hagen1778/tsbs/query"
+)
+
+func init() {
+	// needed for deserializing the mongo query from gob
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]map[string]interface{}{})
+	gob.Register(bson.M{})
+	gob.Register([]bson.M{})
+}
+
+type processor struct {
+	runner     *query.BenchmarkRunner
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner on copies of session
+func NewProcessorCreate(runner *query.BenchmarkRunner, session *mgo.Session) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, session: session}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	sess := p.session.Copy()
+	if timeout := p.runner.Timeout(); timeout > 0 {
+		sess.SetSocketTimeout(timeout)
+	}
+	db := sess.DB(p.runner.DatabaseName())
+	p.collection = db.C("point_data")
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	mq := q.(*query.Mongo)
+	start := time.Now().UnixNano()
+	pipe := p.collection.Pipe(mq.BsonDoc).AllowDiskUse()
+	iter := pipe.Iter()
+	if p.runner.DebugLevel() > 0 {
+		fmt.Println(mq.BsonDoc)
+	}
+	var result map[string]interface{}
+	cnt := 0
+	for iter.Next(&result) {
+		if p.runner.DoPrintResponses() {
+			fmt.Printf("ID %d: %v\n", q.GetID(), result)
+		}
+		cnt++
+	}
+	if p.runner.DebugLevel() > 0 {
+		fmt.Println(cnt)
+	}
+	err := iter.Close()
+
+	took := time.Now().UnixNano() - start
+	lag := float64(took) / 1e6 // milliseconds
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, err
+}
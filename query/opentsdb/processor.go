@@ -0,0 +1,84 @@
+// Package opentsdb runs benchmark queries against the HTTP API of OpenTSDB.
+// It is used both by tsbs_run_queries_opentsdb and by the mixed mode of
+// tsbs_load_opentsdb.
+package opentsdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+// Config holds the options for running queries against OpenTSDB
+type Config struct {
+	// URLs are used in a round-robin fashion by the workers
+	URLs []string
+}
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	c      *Config
+	url    string
+	client *http.Client
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against OpenTSDB as configured by c
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, c: c}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.url = p.c.URLs[workerNumber%len(p.c.URLs)]
+	p.client = &http.Client{Timeout: p.runner.Timeout()}
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+
+	// populate a request with data from the Query, the query being its JSON body:
+	req, err := http.NewRequest(string(hq.Method), p.url+string(hq.Path), bytes.NewReader(hq.Body))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the request while tracking latency:
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 statuscode received: %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	switch p.runner.DebugLevel() {
+	case 0:
+	case 1:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms\n", hq.HumanLabel, lag)
+	default:
+		fmt.Fprintf(os.Stderr, "debug: %s in %7.2fms -- %s\n", hq.HumanLabel, lag, hq.HumanDescription)
+		fmt.Fprintf(os.Stderr, "debug:   request: %s\n", hq.String())
+	}
+	if p.runner.DoPrintResponses() {
+		fmt.Fprintf(os.Stderr, "ID %d: %s\n", q.GetID(), body)
+	}
+
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, nil
+}
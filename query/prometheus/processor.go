@@ -0,0 +1,80 @@
+// Package prometheus runs benchmark queries against the Prometheus querying
+// API. It is used both by tsbs_run_queries_prometheus and by the mixed mode
+// of tsbs_load_prometheus.
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+)
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	url    string
+	client *http.Client
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors sending
+// the queries of runner to the Prometheus querying API at url
+func NewProcessorCreate(runner *query.BenchmarkRunner, url string) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, url: url}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.client = &http.Client{Timeout: p.runner.Timeout()}
+}
+
+type response struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []result `json:"result"`
+	} `json:"data"`
+}
+
+type result struct {
+	Metric interface{}   `json:"metric"`
+	Values []interface{} `json:"values"`
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+
+	// populate a request with data from the Query:
+	req, err := http.NewRequest(string(hq.Method), p.url+string(hq.Path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+
+	// Perform the request while tracking latency:
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 statuscode received: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response body: %w", err)
+	}
+
+	r := response{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("error while unmarshaling response: %s", err)
+	}
+
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, nil
+}
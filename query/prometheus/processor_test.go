@@ -0,0 +1,38 @@
+package prometheus
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hagen1778/tsbs/load"
+	"github.com/hagen1778/tsbs/query"
+)
+
+func TestProcessQueryTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	runner := query.NewBenchmarkRunner()
+	if err := flag.Set("timeout", "10ms"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := NewProcessorCreate(runner, srv.URL)()
+	p.Init(0)
+
+	q := query.NewHTTP()
+	q.Method = []byte("GET")
+	q.Path = []byte("/api/v1/query_range")
+	_, err := p.ProcessQuery(q, false)
+	if !load.IsTimeout(err) {
+		t.Errorf("query to slow server did not time out: %v", err)
+	}
+}
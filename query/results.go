@@ -0,0 +1,176 @@
+package query
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	resultsFormatCSV  = "csv"
+	resultsFormatJSON = "json"
+)
+
+var resultsCSVHeader = []string{"query_id", "label", "worker", "start_time", "latency_ms", "warm"}
+
+// resultRecord is the record written to the results file for each query
+type resultRecord struct {
+	QueryID   uint64  `json:"query_id"`
+	Label     string  `json:"label"`
+	Worker    int     `json:"worker"`
+	StartTime string  `json:"start_time"`
+	LatencyMs float64 `json:"latency_ms"`
+	Warm      bool    `json:"warm"`
+}
+
+// resultsWriter writes a record per query to a file, either as CSV or as
+// JSON Lines.
+type resultsWriter struct {
+	f      *os.File
+	w      *bufio.Writer
+	csv    *csv.Writer
+	format string
+}
+
+// newResultsWriter creates the file fileName and returns a resultsWriter
+// writing to it in the given format
+func newResultsWriter(fileName, format string) (*resultsWriter, error) {
+	if format != resultsFormatCSV && format != resultsFormatJSON {
+		return nil, fmt.Errorf("unknown results format: %s", format)
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	rw := &resultsWriter{
+		f:      f,
+		w:      bufio.NewWriter(f),
+		format: format,
+	}
+	if format == resultsFormatCSV {
+		rw.csv = csv.NewWriter(rw.w)
+		if err := rw.csv.Write(resultsCSVHeader); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return rw, nil
+}
+
+// write records a single Stat
+func (rw *resultsWriter) write(s *Stat) error {
+	r := resultRecord{
+		QueryID:   s.queryID,
+		Label:     string(s.label),
+		Worker:    s.workerNum,
+		StartTime: s.startTime.UTC().Format(time.RFC3339Nano),
+		LatencyMs: s.value,
+		Warm:      s.isWarm,
+	}
+	if rw.format == resultsFormatCSV {
+		return rw.csv.Write([]string{
+			strconv.FormatUint(r.QueryID, 10),
+			r.Label,
+			strconv.Itoa(r.Worker),
+			r.StartTime,
+			strconv.FormatFloat(r.LatencyMs, 'f', -1, 64),
+			strconv.FormatBool(r.Warm),
+		})
+	}
+	line, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = rw.w.Write(line)
+	return err
+}
+
+// close flushes any buffered records and closes the underlying file
+func (rw *resultsWriter) close() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := rw.w.Flush(); err != nil {
+		return err
+	}
+	return rw.f.Close()
+}
+
+// labelSummary is the summary of the statistics for one label
+type labelSummary struct {
+	Count       int64              `json:"count"`
+	Min         float64            `json:"min_ms"`
+	Mean        float64            `json:"mean_ms"`
+	Median      float64            `json:"median_ms"`
+	Max         float64            `json:"max_ms"`
+	StdDev      float64            `json:"stddev_ms"`
+	Sum         float64            `json:"sum_ms"`
+	Percentiles map[string]float64 `json:"percentiles_ms"`
+	Errors      int64              `json:"errors"`
+	Timeouts    int64              `json:"timeouts"`
+	ErrorRate   float64            `json:"error_rate"`
+}
+
+// dispatchSummary is the summary of the query dispatching in open-loop mode
+type dispatchSummary struct {
+	TargetQPS float64 `json:"target_qps"`
+	Arrival   string  `json:"arrival"`
+	Sent      uint64  `json:"sent"`
+	Late      uint64  `json:"late"`
+	Dropped   uint64  `json:"dropped"`
+}
+
+// runSummary is the summary of a whole benchmark run
+type runSummary struct {
+	Workers     uint                     `json:"workers"`
+	Queries     uint64                   `json:"queries"`
+	WallClockMs float64                  `json:"wall_clock_ms"`
+	Labels      map[string]*labelSummary `json:"labels"`
+	OpenLoop    *dispatchSummary         `json:"open_loop,omitempty"`
+}
+
+// newRunSummary summarizes the statGroups, including the given percentiles
+func newRunSummary(statGroups map[string]*statGroup, percentiles []float64, workers uint, queries uint64, wallTook time.Duration) *runSummary {
+	summary := &runSummary{
+		Workers:     workers,
+		Queries:     queries,
+		WallClockMs: float64(wallTook.Nanoseconds()) / 1e6,
+		Labels:      make(map[string]*labelSummary, len(statGroups)),
+	}
+	for k, sg := range statGroups {
+		ls := &labelSummary{
+			Count:       sg.count,
+			Min:         sg.min,
+			Mean:        sg.mean,
+			Median:      sg.median(),
+			Max:         sg.max,
+			StdDev:      sg.stdDev,
+			Sum:         sg.sum,
+			Percentiles: make(map[string]float64, len(percentiles)),
+			Errors:      sg.errors,
+			Timeouts:    sg.timeouts,
+			ErrorRate:   sg.errorRate(),
+		}
+		for _, p := range percentiles {
+			ls.Percentiles[formatPercentile(p)] = sg.percentile(p)
+		}
+		summary.Labels[k] = ls
+	}
+	return summary
+}
+
+// writeSummary writes the summary as JSON to w
+func writeSummary(w io.Writer, summary *runSummary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summary)
+}
@@ -0,0 +1,108 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testResultsStat(label string, value float64, id uint64, worker int, warm bool) *Stat {
+	s := GetStat().Init([]byte(label), value)
+	s.queryID = id
+	s.workerNum = worker
+	s.startTime = time.Unix(1500000000, 500).UTC()
+	s.isWarm = warm
+	return s
+}
+
+func TestResultsWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-query")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: resultsFormatCSV,
+			want: "query_id,label,worker,start_time,latency_ms,warm\n" +
+				"1,\"foo, bar\",0,2017-07-14T02:40:00.0000005Z,10.5,false\n" +
+				"1,\"foo, bar\",3,2017-07-14T02:40:00.0000005Z,2,true\n",
+		},
+		{
+			format: resultsFormatJSON,
+			want: `{"query_id":1,"label":"foo, bar","worker":0,"start_time":"2017-07-14T02:40:00.0000005Z","latency_ms":10.5,"warm":false}` + "\n" +
+				`{"query_id":1,"label":"foo, bar","worker":3,"start_time":"2017-07-14T02:40:00.0000005Z","latency_ms":2,"warm":true}` + "\n",
+		},
+	}
+	for _, c := range cases {
+		fileName := filepath.Join(dir, "results."+c.format)
+		rw, err := newResultsWriter(fileName, c.format)
+		if err != nil {
+			t.Fatalf("%s: could not create results writer: %v", c.format, err)
+		}
+		if err := rw.write(testResultsStat("foo, bar", 10.5, 1, 0, false)); err != nil {
+			t.Errorf("%s: unexpected write error: %v", c.format, err)
+		}
+		if err := rw.write(testResultsStat("foo, bar", 2, 1, 3, true)); err != nil {
+			t.Errorf("%s: unexpected write error: %v", c.format, err)
+		}
+		if err := rw.close(); err != nil {
+			t.Errorf("%s: unexpected close error: %v", c.format, err)
+		}
+		got, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatalf("%s: could not read results file: %v", c.format, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: incorrect output: got\n%s\nwant\n%s", c.format, got, c.want)
+		}
+	}
+}
+
+func TestResultsWriterUnknownFormat(t *testing.T) {
+	if _, err := newResultsWriter(filepath.Join(os.TempDir(), "results.xml"), "xml"); err == nil {
+		t.Errorf("did not get error for unknown format")
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	sg := newStatGroup()
+	for i := 1; i <= 10; i++ {
+		sg.push(float64(i))
+	}
+	groups := map[string]*statGroup{labelAllQueries: sg}
+
+	var buf bytes.Buffer
+	if err := writeSummary(&buf, newRunSummary(groups, []float64{50, 90, 95, 99, 99.9}, 2, 10, 1500*time.Millisecond)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := &runSummary{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("could not decode summary: %v", err)
+	}
+	if got.Workers != 2 || got.Queries != 10 || got.WallClockMs != 1500 {
+		t.Errorf("incorrect run details: got %d workers, %d queries, %v ms", got.Workers, got.Queries, got.WallClockMs)
+	}
+	ls, ok := got.Labels[labelAllQueries]
+	if !ok {
+		t.Fatalf("summary is missing label %q", labelAllQueries)
+	}
+	if ls.Count != 10 || ls.Min != 1 || ls.Max != 10 || ls.Mean != 5.5 || math.Abs(ls.Median-5) > 5e-3 || ls.Sum != 55 {
+		t.Errorf("incorrect label summary: %+v", ls)
+	}
+	wantPercentiles := map[string]float64{"50": 5, "90": 9, "95": 10, "99": 10, "99.9": 10}
+	for k, want := range wantPercentiles {
+		if got := ls.Percentiles[k]; math.Abs(got-want) > want*1e-3 {
+			t.Errorf("incorrect p%s: got %v want %v", k, got, want)
+		}
+	}
+}
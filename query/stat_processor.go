@@ -9,12 +9,24 @@ import (
 
 // statProcessor is used to collect, analyze, and print query execution statistics.
 type statProcessor struct {
-	prewarmQueries bool       // PrewarmQueries tells the StatProcessor whether we're running each query twice to prewarm the cache
-	c              chan *Stat // c is the channel for Stats to be sent for processing
-	limit          *uint64    // limit is the number of statistics to analyze before stopping
-	burnIn         uint64     // burnIn is the number of statistics to ignore before analyzing
-	printInterval  uint64     // printInterval is how often print intermediate stats (number of queries)
+	prewarmQueries bool          // PrewarmQueries tells the StatProcessor whether we're running each query twice to prewarm the cache
+	c              chan *Stat    // c is the channel for Stats to be sent for processing
+	limit          *uint64       // limit is the number of statistics to analyze before stopping
+	burnIn         uint64        // burnIn is the number of statistics to ignore before analyzing
+	printInterval  uint64        // printInterval is how often print intermediate stats (number of queries)
+	resultsFile    string        // resultsFile is the file to record every query to (empty to disable)
+	resultsFormat  string        // resultsFormat is the format of resultsFile, csv or json
+	percentiles    []float64     // percentiles are the latency percentiles to report
+	metrics        *queryMetrics // metrics exposes the queries as they complete (nil to disable)
 	wg             sync.WaitGroup
+
+	// period holds the queries completed since the last call to takePeriod
+	periodMu sync.Mutex
+	period   *statGroup
+
+	// statGroups and count hold the final results once processing is done
+	statGroups map[string]*statGroup
+	count      uint64
 }
 
 func (sp *statProcessor) sendStats(stats []*Stat) {
@@ -45,12 +57,21 @@ func (sp *statProcessor) process(workers uint) {
 	sp.wg.Add(1)
 	const allQueriesLabel = labelAllQueries
 	statMapping := map[string]*statGroup{
-		allQueriesLabel: newStatGroup(*sp.limit),
+		allQueriesLabel: newStatGroup(),
 	}
 	// Only needed when differentiating between cold & warm
 	if sp.prewarmQueries {
-		statMapping[labelColdQueries] = newStatGroup(*sp.limit)
-		statMapping[labelWarmQueries] = newStatGroup(*sp.limit)
+		statMapping[labelColdQueries] = newStatGroup()
+		statMapping[labelWarmQueries] = newStatGroup()
+	}
+
+	var rw *resultsWriter
+	if len(sp.resultsFile) > 0 {
+		var err error
+		rw, err = newResultsWriter(sp.resultsFile, sp.resultsFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	i := uint64(0)
@@ -65,14 +86,40 @@ func (sp *statProcessor) process(workers uint) {
 				log.Fatal(err)
 			}
 		}
+		if sp.metrics != nil {
+			sp.metrics.observe(stat)
+		}
 		if _, ok := statMapping[string(stat.label)]; !ok {
-			statMapping[string(stat.label)] = newStatGroup(*sp.limit)
+			statMapping[string(stat.label)] = newStatGroup()
+		}
+
+		if stat.isError {
+			// failed queries are counted, but have no latency
+			statMapping[string(stat.label)].pushError(stat.isTimeout)
+			statMapping[allQueriesLabel].pushError(stat.isTimeout)
+			if sp.prewarmQueries {
+				if stat.isWarm {
+					statMapping[labelWarmQueries].pushError(stat.isTimeout)
+				} else {
+					statMapping[labelColdQueries].pushError(stat.isTimeout)
+				}
+			}
+			if !sp.prewarmQueries || !stat.isWarm {
+				i++
+			}
+		} else {
+			statMapping[string(stat.label)].push(stat.value)
 		}
 
-		statMapping[string(stat.label)].push(stat.value)
+		if !stat.isPartial && !stat.isError {
+			if rw != nil {
+				if err := rw.write(stat); err != nil {
+					log.Fatal(err)
+				}
+			}
 
-		if !stat.isPartial {
 			statMapping[allQueriesLabel].push(stat.value)
+			sp.pushPeriod(stat.value)
 
 			// Only needed when differentiating between cold & warm
 			if sp.prewarmQueries {
@@ -99,7 +146,7 @@ func (sp *statProcessor) process(workers uint) {
 			if err != nil {
 				log.Fatal(err)
 			}
-			writeStatGroupMap(os.Stderr, statMapping)
+			writeStatGroupMap(os.Stderr, statMapping, sp.percentiles)
 			_, err = fmt.Fprintf(os.Stderr, "\n")
 			if err != nil {
 				log.Fatal(err)
@@ -112,10 +159,39 @@ func (sp *statProcessor) process(workers uint) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	writeStatGroupMap(os.Stdout, statMapping)
+	writeStatGroupMap(os.Stdout, statMapping, sp.percentiles)
+	if rw != nil {
+		if err := rw.close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	sp.statGroups = statMapping
+	sp.count = i - sp.burnIn
 	sp.wg.Done()
 }
 
+// pushPeriod adds the latency of a completed query to the current period
+func (sp *statProcessor) pushPeriod(value float64) {
+	sp.periodMu.Lock()
+	if sp.period == nil {
+		sp.period = newStatGroup()
+	}
+	sp.period.push(value)
+	sp.periodMu.Unlock()
+}
+
+// takePeriod returns statistics on the current period and starts a new one
+func (sp *statProcessor) takePeriod() PeriodStats {
+	sp.periodMu.Lock()
+	period := sp.period
+	sp.period = nil
+	sp.periodMu.Unlock()
+	if period == nil {
+		return PeriodStats{}
+	}
+	return period.periodStats()
+}
+
 // CloseAndWait closes the stats channel and blocks until the StatProcessor has finished all the stats on its channel.
 func (sp *statProcessor) CloseAndWait() {
 	close(sp.c)
@@ -60,3 +60,32 @@ func TestStatProcessorSendStatsWarm(t *testing.T) {
 		t.Errorf("received stat is NOT warm unexpectedly (2)")
 	}
 }
+
+func TestStatProcessorTakePeriod(t *testing.T) {
+	sp := &statProcessor{}
+	if got := sp.takePeriod(); got != (PeriodStats{}) {
+		t.Errorf("non-empty stats for an empty period: %v", got)
+	}
+
+	for _, v := range []float64{1, 2, 3, 4} {
+		sp.pushPeriod(v)
+	}
+	got := sp.takePeriod()
+	if got.Count != 4 {
+		t.Errorf("incorrect count: got %d want %d", got.Count, 4)
+	}
+	if got.Mean != 2.5 {
+		t.Errorf("incorrect mean: got %v want %v", got.Mean, 2.5)
+	}
+	if got.P50 != 2 {
+		t.Errorf("incorrect p50: got %v want %v", got.P50, 2)
+	}
+	if got.P99 != 4 || got.Max != 4 {
+		t.Errorf("incorrect p99 or max: got %v, %v want %v", got.P99, got.Max, 4)
+	}
+
+	// taking the stats starts a new period
+	if got := sp.takePeriod(); got.Count != 0 {
+		t.Errorf("period not reset: got count %d", got.Count)
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
 )
 
 // Stat represents one statistical measurement, typically used to store the
@@ -16,6 +21,13 @@ type Stat struct {
 	value     float64
 	isWarm    bool
 	isPartial bool
+	isError   bool // isError marks a failed query, which has no latency
+	isTimeout bool // isTimeout marks a failed query that timed out
+
+	// details of the query the Stat belongs to, filled in by the runner
+	queryID   uint64
+	workerNum int
+	startTime time.Time
 }
 
 var statPool = &sync.Pool{
@@ -39,6 +51,14 @@ func GetPartialStat() *Stat {
 	return s
 }
 
+// getErrorStat returns a Stat recording a failed query with the given label
+func getErrorStat(label []byte, timeout bool) *Stat {
+	s := GetStat().Init(label, 0)
+	s.isError = true
+	s.isTimeout = timeout
+	return s
+}
+
 // Init safely initializes a Stat while minimizing heap allocations.
 func (s *Stat) Init(label []byte, value float64) *Stat {
 	s.label = s.label[:0] // clear
@@ -53,16 +73,35 @@ func (s *Stat) reset() *Stat {
 	s.value = 0.0
 	s.isWarm = false
 	s.isPartial = false
+	s.isError = false
+	s.isTimeout = false
+	s.queryID = 0
+	s.workerNum = 0
+	s.startTime = time.Time{}
 	return s
 }
 
-// statGroup collects simple streaming statistics.
+const (
+	// histogramUnit is the resolution of latencies stored in the histogram,
+	// which records integer values: values are in milliseconds, so 1e3
+	// stores microseconds
+	histogramUnit = 1e3
+	// histogramMax is the largest latency the histogram tracks (1 hour, in
+	// histogramUnit); larger values are recorded as histogramMax
+	histogramMax = 3600 * 1e3 * histogramUnit
+	// histogramSigFigs is the number of significant figures kept by the
+	// histogram, i.e., 3 gives a precision of 0.1%
+	histogramSigFigs = 3
+)
+
+// statGroup collects simple streaming statistics. Percentiles are computed
+// from an HDR histogram so memory use does not grow with the number of values.
 type statGroup struct {
-	min    float64
-	max    float64
-	mean   float64
-	sum    float64
-	values []float64
+	min  float64
+	max  float64
+	mean float64
+	sum  float64
+	hist *hdrhistogram.Histogram
 
 	// used for stddev calculations
 	m      float64
@@ -70,31 +109,68 @@ type statGroup struct {
 	stdDev float64
 
 	count int64
+
+	// failed queries, which are not part of the latency statistics
+	errors   int64
+	timeouts int64
+}
+
+// PeriodStats summarizes the latencies, in milliseconds, of the queries
+// completed during a period of time
+type PeriodStats struct {
+	Count int64
+	Mean  float64
+	P50   float64
+	P99   float64
+	Max   float64
 }
 
-// newStatGroup returns a new StatGroup with an initial size
-func newStatGroup(size uint64) *statGroup {
+// newStatGroup returns a new, empty StatGroup
+func newStatGroup() *statGroup {
 	return &statGroup{
-		values: make([]float64, size),
-		count:  0,
+		hist:  hdrhistogram.New(1, histogramMax, histogramSigFigs),
+		count: 0,
 	}
 }
 
 // median returns the median value of the StatGroup
 func (s *statGroup) median() float64 {
-	sort.Float64s(s.values[:s.count])
+	return s.percentile(50)
+}
+
+// percentile returns the value below which p percent of the values of the
+// StatGroup fall, within the precision of the histogram
+func (s *statGroup) percentile(p float64) float64 {
 	if s.count == 0 {
 		return 0
-	} else if s.count%2 == 0 {
-		idx := s.count / 2
-		return (s.values[idx] + s.values[idx-1]) / 2.0
-	} else {
-		return s.values[s.count/2]
+	}
+	// the histogram returns the highest value equivalent to the bucket,
+	// which can be slightly larger than anything actually pushed
+	return math.Min(float64(s.hist.ValueAtQuantile(p))/histogramUnit, s.max)
+}
+
+// periodStats returns the PeriodStats of the values of the StatGroup
+func (s *statGroup) periodStats() PeriodStats {
+	return PeriodStats{
+		Count: s.count,
+		Mean:  s.mean,
+		P50:   s.percentile(50),
+		P99:   s.percentile(99),
+		Max:   s.max,
 	}
 }
 
 // push updates a StatGroup with a new value.
 func (s *statGroup) push(n float64) {
+	v := int64(math.Round(n * histogramUnit))
+	if v > histogramMax {
+		v = histogramMax
+	} else if v < 0 {
+		v = 0
+	}
+	// v is always in range so no error can occur
+	_ = s.hist.RecordValue(v)
+
 	if s.count == 0 {
 		s.min = n
 		s.max = n
@@ -105,11 +181,6 @@ func (s *statGroup) push(n float64) {
 		s.m = n
 		s.s = 0.0
 		s.stdDev = 0.0
-		if len(s.values) > 0 {
-			s.values[0] = n
-		} else {
-			s.values = append(s.values, n)
-		}
 		return
 	}
 
@@ -125,11 +196,6 @@ func (s *statGroup) push(n float64) {
 	// constant-space mean update:
 	sum := s.mean*float64(s.count) + n
 	s.mean = sum / float64(s.count+1)
-	if int(s.count) == len(s.values) {
-		s.values = append(s.values, n)
-	} else {
-		s.values[s.count] = n
-	}
 
 	s.count++
 
@@ -139,19 +205,107 @@ func (s *statGroup) push(n float64) {
 	s.stdDev = math.Sqrt(s.s / (float64(s.count) - 1.0))
 }
 
-// string makes a simple description of a statGroup.
-func (s *statGroup) string() string {
-	return fmt.Sprintf("min: %8.2fms, med: %8.2fms, mean: %8.2fms, max: %7.2fms, stddev: %8.2fms, sum: %5.1fsec, count: %d", s.min, s.median(), s.mean, s.max, s.stdDev, s.sum/1e3, s.count)
+// pushError records a failed query in the StatGroup
+func (s *statGroup) pushError(timeout bool) {
+	s.errors++
+	if timeout {
+		s.timeouts++
+	}
+}
+
+// errorRate returns the fraction of the queries of the StatGroup that failed
+func (s *statGroup) errorRate() float64 {
+	if s.errors == 0 {
+		return 0
+	}
+	return float64(s.errors) / float64(s.count+s.errors)
+}
+
+// merge adds all the values of other to the StatGroup
+func (s *statGroup) merge(other *statGroup) {
+	s.errors += other.errors
+	s.timeouts += other.timeouts
+	if other.count == 0 {
+		return
+	}
+	if s.count == 0 {
+		s.min = other.min
+		s.max = other.max
+	} else {
+		if other.min < s.min {
+			s.min = other.min
+		}
+		if other.max > s.max {
+			s.max = other.max
+		}
+	}
+	s.hist.Merge(other.hist)
+
+	// combine the running mean and variance of both groups (Chan et al.)
+	count := s.count + other.count
+	delta := other.m - s.m
+	s.m += delta * float64(other.count) / float64(count)
+	s.s += other.s + delta*delta*float64(s.count)*float64(other.count)/float64(count)
+	s.sum += other.sum
+	s.mean = s.sum / float64(count)
+	s.count = count
+	if count > 1 {
+		s.stdDev = math.Sqrt(s.s / (float64(count) - 1.0))
+	}
+}
+
+// string makes a simple description of a statGroup, along with its error
+// rate if any query failed, followed by the requested percentiles on a
+// second line (if any).
+func (s *statGroup) string(percentiles []float64) string {
+	ret := fmt.Sprintf("min: %8.2fms, med: %8.2fms, mean: %8.2fms, max: %7.2fms, stddev: %8.2fms, sum: %5.1fsec, count: %d", s.min, s.median(), s.mean, s.max, s.stdDev, s.sum/1e3, s.count)
+	if s.errors > 0 {
+		ret += fmt.Sprintf(", errors: %d (%0.2f%%), timeouts: %d", s.errors, 100*s.errorRate(), s.timeouts)
+	}
+	for i, p := range percentiles {
+		sep := ", "
+		if i == 0 {
+			sep = "\n"
+		}
+		ret += fmt.Sprintf("%sp%s: %8.2fms", sep, formatPercentile(p), s.percentile(p))
+	}
+	return ret
+}
+
+// formatPercentile returns p in its shortest form, e.g., 99.9 or 50
+func formatPercentile(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// parsePercentiles parses a comma-separated list of percentiles, e.g.,
+// "50,90,99.9"
+func parsePercentiles(s string) ([]float64, error) {
+	ret := []float64{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %q: %v", v, err)
+		}
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile out of range (0, 100]: %v", p)
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
 }
 
-func (s *statGroup) write(w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\n", s.string())
+func (s *statGroup) write(w io.Writer, percentiles []float64) error {
+	_, err := fmt.Fprintf(w, "%s\n", s.string(percentiles))
 	return err
 }
 
 // writeStatGroupMap writes a map of StatGroups in an ordered fashion by
-// key that they are stored by
-func writeStatGroupMap(w io.Writer, statGroups map[string]*statGroup) {
+// key that they are stored by, including the given percentiles
+func writeStatGroupMap(w io.Writer, statGroups map[string]*statGroup, percentiles []float64) {
 	maxKeyLength := 0
 	keys := make([]string, 0, len(statGroups))
 	for k := range statGroups {
@@ -173,7 +327,7 @@ func writeStatGroupMap(w io.Writer, statGroups map[string]*statGroup) {
 			log.Fatal(err)
 		}
 
-		err = v.write(w)
+		err = v.write(w, percentiles)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -1,6 +1,9 @@
 package query
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestGetPartialStat(t *testing.T) {
 	s := GetPartialStat()
@@ -73,53 +76,11 @@ func TestStateGroupMedian(t *testing.T) {
 		},
 		{
 			len:  2,
-			want: 2.0,
-		},
-		{
-			len:  4,
-			want: 4.0,
-		},
-		{
-			len:  5,
-			want: 5.0,
-		},
-		{
-			len:  1000,
-			want: 1000,
-		},
-	}
-
-	for _, c := range cases {
-		sg := newStatGroup(c.len)
-		for i := uint64(0); i < c.len; i++ {
-			sg.push(1 + float64(i)*2)
-		}
-		if got := sg.median(); c.want != got {
-			t.Errorf("got: %v want: %v\n", got, c.want)
-		}
-	}
-}
-
-func TestStatGroupMedian0InitialSize(t *testing.T) {
-	cases := []struct {
-		len  uint64
-		want float64
-	}{
-		{
-			len:  0,
-			want: 0.0,
-		},
-		{
-			len:  1,
 			want: 1.0,
 		},
-		{
-			len:  2,
-			want: 2.0,
-		},
 		{
 			len:  4,
-			want: 4.0,
+			want: 3.0,
 		},
 		{
 			len:  5,
@@ -127,16 +88,17 @@ func TestStatGroupMedian0InitialSize(t *testing.T) {
 		},
 		{
 			len:  1000,
-			want: 1000,
+			want: 999,
 		},
 	}
 
 	for _, c := range cases {
-		sg := newStatGroup(0)
+		sg := newStatGroup()
 		for i := uint64(0); i < c.len; i++ {
 			sg.push(1 + float64(i)*2)
 		}
-		if got := sg.median(); c.want != got {
+		// histogram values are only accurate to 3 significant figures
+		if got := sg.median(); math.Abs(got-c.want) > c.want*1e-3 {
 			t.Errorf("got: %v want: %v\n", got, c.want)
 		}
 	}
@@ -182,7 +144,7 @@ func TestStatGroupPush(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		sg := newStatGroup(0)
+		sg := newStatGroup()
 		for _, val := range c.vals {
 			sg.push(val)
 		}
@@ -203,3 +165,162 @@ func TestStatGroupPush(t *testing.T) {
 		}
 	}
 }
+
+func TestStatGroupPercentile(t *testing.T) {
+	sg := newStatGroup()
+	if got := sg.percentile(50); got != 0 {
+		t.Errorf("empty group: got %v want 0", got)
+	}
+	// push in reverse so that values need sorting
+	for i := 100; i > 0; i-- {
+		sg.push(float64(i))
+	}
+	cases := []struct {
+		p    float64
+		want float64
+	}{
+		{p: 1, want: 1},
+		{p: 50, want: 50},
+		{p: 90, want: 90},
+		{p: 99, want: 99},
+		{p: 99.9, want: 100},
+		{p: 100, want: 100},
+	}
+	for _, c := range cases {
+		if got := sg.percentile(c.p); math.Abs(got-c.want) > c.want*1e-3 {
+			t.Errorf("p%v: got %v want %v", c.p, got, c.want)
+		}
+	}
+}
+
+func TestStatGroupLargeValues(t *testing.T) {
+	sg := newStatGroup()
+	sg.push(1.0)
+	sg.push(1e9)
+	if got := sg.max; got != 1e9 {
+		t.Errorf("incorrect max: got %v want %v", got, 1e9)
+	}
+	// values past the histogram range are clamped
+	if got, want := sg.percentile(100), float64(histogramMax)/histogramUnit; math.Abs(got-want) > want*1e-3 {
+		t.Errorf("incorrect p100: got %v want %v", got, want)
+	}
+}
+
+func TestStatGroupMerge(t *testing.T) {
+	sg1 := newStatGroup()
+	sg2 := newStatGroup()
+	all := newStatGroup()
+	for i := 1; i <= 100; i++ {
+		if i%3 == 0 {
+			sg1.push(float64(i))
+		} else {
+			sg2.push(float64(i))
+		}
+		all.push(float64(i))
+	}
+	sg1.merge(sg2)
+	sg1.merge(newStatGroup())
+
+	if sg1.count != all.count || sg1.min != all.min || sg1.max != all.max || sg1.sum != all.sum {
+		t.Errorf("incorrect merge: got count %d, min %v, max %v, sum %v want count %d, min %v, max %v, sum %v",
+			sg1.count, sg1.min, sg1.max, sg1.sum, all.count, all.min, all.max, all.sum)
+	}
+	if math.Abs(sg1.mean-all.mean) > 1e-9 {
+		t.Errorf("incorrect merged mean: got %v want %v", sg1.mean, all.mean)
+	}
+	if math.Abs(sg1.stdDev-all.stdDev) > 1e-9 {
+		t.Errorf("incorrect merged stddev: got %v want %v", sg1.stdDev, all.stdDev)
+	}
+	for _, p := range []float64{50, 90, 99} {
+		if got, want := sg1.percentile(p), all.percentile(p); got != want {
+			t.Errorf("incorrect merged p%v: got %v want %v", p, got, want)
+		}
+	}
+
+	empty := newStatGroup()
+	empty.merge(all)
+	if empty.count != all.count || empty.min != all.min || empty.max != all.max {
+		t.Errorf("incorrect merge into empty group: got count %d, min %v, max %v", empty.count, empty.min, empty.max)
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	cases := []struct {
+		in        string
+		want      []float64
+		shouldErr bool
+	}{
+		{in: "", want: []float64{}},
+		{in: "50", want: []float64{50}},
+		{in: "50, 99,99.9", want: []float64{50, 99, 99.9}},
+		{in: "100", want: []float64{100}},
+		{in: "0", shouldErr: true},
+		{in: "101", shouldErr: true},
+		{in: "fifty", shouldErr: true},
+	}
+	for _, c := range cases {
+		got, err := parsePercentiles(c.in)
+		if c.shouldErr {
+			if err == nil {
+				t.Errorf("%q: did not get error when expected", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.in, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%q: incorrect result: got %v want %v", c.in, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%q: incorrect result: got %v want %v", c.in, got, c.want)
+			}
+		}
+	}
+}
+
+func TestStatGroupString(t *testing.T) {
+	sg := newStatGroup()
+	for i := 1; i <= 4; i++ {
+		sg.push(float64(i))
+	}
+	want := "min:     1.00ms, med:     2.00ms, mean:     2.50ms, max:    4.00ms, stddev:     1.29ms, sum:   0.0sec, count: 4"
+	if got := sg.string(nil); got != want {
+		t.Errorf("incorrect output without percentiles:\ngot\n%s\nwant\n%s", got, want)
+	}
+	want += "\np50:     2.00ms, p99.9:     4.00ms"
+	if got := sg.string([]float64{50, 99.9}); got != want {
+		t.Errorf("incorrect output with percentiles:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStatGroupErrors(t *testing.T) {
+	sg := newStatGroup()
+	for i := 1; i <= 3; i++ {
+		sg.push(float64(i))
+	}
+	if got := sg.errorRate(); got != 0 {
+		t.Errorf("non-zero error rate without errors: %v", got)
+	}
+	sg.pushError(true)
+	if sg.count != 3 || sg.errors != 1 || sg.timeouts != 1 {
+		t.Errorf("incorrect counts: got %d values, %d errors, %d timeouts", sg.count, sg.errors, sg.timeouts)
+	}
+	if got := sg.errorRate(); got != 0.25 {
+		t.Errorf("incorrect error rate: got %v want %v", got, 0.25)
+	}
+	want := "min:     1.00ms, med:     2.00ms, mean:     2.00ms, max:    3.00ms, stddev:     1.00ms, sum:   0.0sec, count: 3, errors: 1 (25.00%), timeouts: 1"
+	if got := sg.string(nil); got != want {
+		t.Errorf("incorrect output with errors:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	other := newStatGroup()
+	other.pushError(false)
+	sg.merge(other)
+	if sg.errors != 2 || sg.timeouts != 1 {
+		t.Errorf("incorrect merged counts: got %d errors, %d timeouts", sg.errors, sg.timeouts)
+	}
+}
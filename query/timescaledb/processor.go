@@ -0,0 +1,170 @@
+// Package timescaledb runs benchmark queries against TimescaleDB. It is used
+// both by tsbs_run_queries_timescaledb and by the mixed mode of
+// tsbs_load_timescaledb.
+package timescaledb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hagen1778/tsbs/query"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// Config holds the options for running queries against TimescaleDB
+type Config struct {
+	// PostgresConnect holds additional PostgreSQL connection parameters,
+	// parameters for host, database and user are ignored
+	PostgresConnect string
+	// Hosts are assigned to the workers in a round-robin fashion
+	Hosts []string
+	// User is the user to connect to PostgreSQL as
+	User string
+	// ShowExplain prints the EXPLAIN ANALYZE output of the queries
+	ShowExplain bool
+}
+
+// Get the connection string for a connection to PostgreSQL.
+
+// If we're running queries against multiple nodes we need to balance the queries
+// across replicas. Each worker is assigned a sequence number -- we'll use that
+// to evenly distribute hosts to worker connections
+func (c *Config) getConnectString(dbName string, workerNumber int) string {
+	// User might be passing in host=hostname the connect string out of habit which may override the
+	// multi host configuration. Same for dbname= and user=. This sanitizes that.
+	re := regexp.MustCompile(`(host|dbname|user)=\S*\b`)
+	connectString := re.ReplaceAllString(c.PostgresConnect, "")
+
+	// Round robin the host/worker assignment by assigning a host based on workerNumber % totalNumberOfHosts
+	host := c.Hosts[workerNumber%len(c.Hosts)]
+	return fmt.Sprintf("host=%s dbname=%s user=%s %s", host, dbName, c.User, connectString)
+}
+
+// prettyPrintResponse prints a Query and its response in JSON format with two
+// keys: 'query' which has a value of the SQL used to generate the second key
+// 'results' which is an array of each row in the return set.
+func prettyPrintResponse(rows *sqlx.Rows, q *query.TimescaleDB) error {
+	resp := make(map[string]interface{})
+	resp["query"] = string(q.SqlQuery)
+
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		r := make(map[string]interface{})
+		if err := rows.MapScan(r); err != nil {
+			return fmt.Errorf("error while scanning row: %w", err)
+		}
+		results = append(results, r)
+		resp["results"] = results
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error while reading rows: %w", err)
+	}
+
+	line, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while marshaling response: %w", err)
+	}
+
+	fmt.Println(string(line) + "\n")
+	return nil
+}
+
+type queryExecutorOptions struct {
+	showExplain   bool
+	debug         bool
+	printResponse bool
+}
+
+type processor struct {
+	runner *query.BenchmarkRunner
+	c      *Config
+	db     *sqlx.DB
+	opts   *queryExecutorOptions
+}
+
+// NewProcessorCreate returns a query.ProcessorCreate for Processors running
+// the queries of runner against TimescaleDB as configured by c
+func NewProcessorCreate(runner *query.BenchmarkRunner, c *Config) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{runner: runner, c: c}
+	}
+}
+
+func (p *processor) Init(workerNumber int) {
+	p.db = sqlx.MustConnect("postgres", p.c.getConnectString(p.runner.DatabaseName(), workerNumber))
+	p.opts = &queryExecutorOptions{
+		showExplain:   p.c.ShowExplain,
+		debug:         p.runner.DebugLevel() > 0,
+		printResponse: p.runner.DoPrintResponses(),
+	}
+}
+
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	// No need to run again for EXPLAIN
+	if isWarm && p.opts.showExplain {
+		return nil, nil
+	}
+	tq := q.(*query.TimescaleDB)
+
+	start := time.Now()
+	qry := string(tq.SqlQuery)
+	if p.opts.showExplain {
+		qry = "EXPLAIN ANALYZE " + qry
+	}
+	ctx := context.Background()
+	if timeout := p.runner.Timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	rows, err := p.db.QueryxContext(ctx, qry)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if p.opts.debug {
+		fmt.Println(qry)
+	}
+	defer rows.Close()
+	if p.opts.showExplain {
+		text := ""
+		for rows.Next() {
+			var s string
+			if err := rows.Scan(&s); err != nil {
+				return nil, fmt.Errorf("error while scanning explain output: %w", err)
+			}
+			text += s + "\n"
+		}
+		fmt.Printf("%s\n\n%s\n-----\n\n", qry, text)
+	} else if p.opts.printResponse {
+		if err := prettyPrintResponse(rows, tq); err != nil {
+			return nil, queryError(ctx, err)
+		}
+	} else {
+		// read the whole response, so that errors while sending it fail the query
+		for rows.Next() {
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took)
+
+	return []*query.Stat{stat}, nil
+}
+
+// queryError returns err of a query run with ctx, reporting a passed deadline
+// for the query to be counted as timed out, as pq reports a cancelled
+// statement as a server error
+func queryError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", err, ctx.Err())
+	}
+	return err
+}
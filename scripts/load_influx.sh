@@ -17,7 +17,7 @@ curl -X POST http://${DATABASE_HOST}:${DATABASE_PORT}/query?q=drop%20database%20
 # Load new data
 cat ${DATA_FILE} | gunzip | ./tsbs_load_influx \
                                 --db-name=${DATABASE_NAME} \
-                                --backoff=${BACKOFF_SECS} \
+                                --retry-backoff=${BACKOFF_SECS} \
                                 --workers=${NUM_WORKERS} \
                                 --batch-size=${BATCH_SIZE} \
                                 --reporting-period=${PROGRESS_INTERVAL} \